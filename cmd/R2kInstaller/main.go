@@ -1,13 +1,22 @@
 // Package main implements the entry point for the R2kInstaller TUI application. It checks for administrator privileges and launches the TUI if the check passes. If not, it displays instructions for running the installer with elevated permissions.
+//
+// Usage:
+//
+//	R2kInstaller.exe                  # interactive TUI
+//	R2kInstaller.exe -maintain-logs   # compress and purge service logs, then exit
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
+	"runtime"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/adcondev/poster-tuis/internal/service"
 	"github.com/adcondev/poster-tuis/internal/ui"
 )
 
@@ -17,7 +26,11 @@ import (
 
 // isAdmin checks if the program is running with administrator privileges
 // by attempting to open PHYSICALDRIVE0, which requires elevated access.
+// On Linux terminals (systemd backend) it checks for root instead.
 func isAdmin() bool {
+	if runtime.GOOS == "linux" {
+		return os.Geteuid() == 0
+	}
 	f, err := os.Open("\\\\.\\PHYSICALDRIVE0")
 	if err != nil {
 		return false
@@ -37,7 +50,23 @@ func isAdmin() bool {
 // ══════════════════════════════════════════════════════════════
 
 func main() {
-	// Enforce admin privileges — required for sc.exe operations
+	maintainLogs := flag.Bool("maintain-logs", false,
+		"aplica la política de retención a los logs de todas las familias y termina (para tareas programadas)")
+	flag.Parse()
+
+	if *maintainLogs {
+		if !isAdmin() {
+			_, _ = fmt.Fprintln(os.Stderr, "error: se requieren permisos de administrador")
+			os.Exit(1)
+		}
+		if err := runLogMaintenance(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Enforce admin privileges — required for sc.exe / systemctl operations
 	if !isAdmin() {
 		errorStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#f7768e")).
@@ -67,3 +96,35 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// ══════════════════════════════════════════════════════════════
+// Log Maintenance (non-interactive)
+// ══════════════════════════════════════════════════════════════
+
+// runLogMaintenance applies the configured retention policy to every family
+// and prints what it did. Every family is processed even if one fails.
+func runLogMaintenance() error {
+	cfg, err := service.LoadInstallerConfig()
+	if err != nil {
+		return err
+	}
+	policy := cfg.LogRetention
+	fmt.Printf("Política (%s): %s\n\n", service.InstallerConfigPath(), policy.Summary())
+
+	failed := 0
+	for _, family := range service.GetFamilyNames() {
+		res, err := service.MaintainLogs(context.Background(), family, policy)
+		fmt.Printf("%s\n  Antes:   %s\n  Después: %s\n  Comprimidos: %d · Eliminados: %d · Liberado: %s\n",
+			family, res.Before.Summary(), res.After.Summary(),
+			res.Compressed, res.Purged, service.FormatSize(max(res.Freed(), 0)))
+		if err != nil {
+			fmt.Printf("  [X] %v\n", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("el mantenimiento falló en %d familia(s)", failed)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Package main generates internal/assets/bin/manifest.json from the service
+// binaries in that directory. Invoked by Taskfile after the services are built.
+//
+// Usage:
+//
+//	go run ./cmd/genmanifest -dir internal/assets/bin -date "2006-01-02 15:04:05" \
+//	    -commit R2k_BasculaServicio_Local.exe=abc1234 ...
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adcondev/poster-tuis/internal/manifest"
+)
+
+// commitFlags collects repeated -commit NAME=SHA pairs
+type commitFlags map[string]string
+
+func (c commitFlags) String() string { return fmt.Sprint(map[string]string(c)) }
+
+func (c commitFlags) Set(v string) error {
+	name, commit, ok := strings.Cut(v, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("formato esperado NOMBRE.exe=COMMIT, recibido %q", v)
+	}
+	c[name] = commit
+	return nil
+}
+
+func main() {
+	commits := commitFlags{}
+	dir := flag.String("dir", "internal/assets/bin", "directorio con los binarios de servicios")
+	date := flag.String("date", "", "fecha de compilación")
+	flag.Var(commits, "commit", "commit de origen por binario (NOMBRE.exe=COMMIT), repetible")
+	flag.Parse()
+
+	if err := run(*dir, *date, commits); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, date string, commits commitFlags) error {
+	exes, err := filepath.Glob(filepath.Join(dir, "*.exe"))
+	if err != nil {
+		return err
+	}
+	if len(exes) == 0 {
+		return fmt.Errorf("no se encontraron binarios en %s", dir)
+	}
+	sort.Strings(exes)
+
+	m := manifest.Manifest{Generated: date, Binaries: make(map[string]manifest.Entry, len(exes))}
+	for _, path := range exes {
+		//nolint:gosec // build-time tool reading its own asset directory
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(path)
+		commit := commits[name]
+		if commit == "" {
+			commit = "unknown"
+		}
+		m.Binaries[name] = manifest.NewEntry(data, commit, date)
+		fmt.Printf("  %s  %s  %d bytes  (%s)\n", manifest.Hash(data)[:12], name, len(data), commit)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	//nolint:gosec // manifest is embedded, not secret
+	return os.WriteFile(filepath.Join(dir, manifest.FileName), append(data, '\n'), 0644)
+}
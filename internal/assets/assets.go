@@ -31,3 +31,9 @@ var TicketLocalBinary []byte
 //
 //go:embed bin/R2k_TicketServicio_Remote.exe
 var TicketRemoteBinary []byte
+
+// Manifest contains the build-generated integrity manifest (SHA-256, size,
+// source commit and build date of each binary above)
+//
+//go:embed bin/manifest.json
+var Manifest []byte
@@ -15,6 +15,19 @@ var (
 	TicketIDRemote      string
 	TicketDisplayLocal  string
 	TicketDisplayRemote string
+	ScaleDescLocal      string
+	ScaleDescRemote     string
+	TicketDescLocal     string
+	TicketDescRemote    string
+)
+
+// Daemon endpoints (ldflags, the same values built into the daemons): the
+// port each family listens on and the token its clients authenticate with
+var (
+	ScalePort       string
+	ScaleAuthToken  string
+	TicketPort      string
+	TicketAuthToken string
 )
 
 // Define ANSI color codes for the "dope" look
@@ -0,0 +1,101 @@
+// Package manifest describes the build-time record (hash, size, provenance)
+// of each service binary embedded in the installer.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// FileName is the manifest file written next to the binaries in internal/assets/bin
+const FileName = "manifest.json"
+
+// ══════════════════════════════════════════════════════════════
+// Manifest Types
+// ══════════════════════════════════════════════════════════════
+
+// Entry records what a single embedded binary should be
+type Entry struct {
+	SHA256    string `json:"sha256"`     // Hex-encoded SHA-256 of the binary
+	Size      int64  `json:"size"`       // Size in bytes
+	Commit    string `json:"commit"`     // Source commit the binary was built from
+	BuildDate string `json:"build_date"` // Build timestamp ("2006-01-02 15:04:05")
+}
+
+// Manifest maps each binary filename to its entry
+type Manifest struct {
+	Generated string           `json:"generated"`
+	Binaries  map[string]Entry `json:"binaries"`
+}
+
+// Parse decodes a manifest
+func Parse(data []byte) (Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, fmt.Errorf("manifiesto inválido: %w", err)
+	}
+	if m.Binaries == nil {
+		m.Binaries = make(map[string]Entry)
+	}
+	return m, nil
+}
+
+// Lookup returns the entry for a binary filename
+func (m Manifest) Lookup(name string) (Entry, bool) {
+	e, ok := m.Binaries[name]
+	return e, ok
+}
+
+// ══════════════════════════════════════════════════════════════
+// Hashing
+// ══════════════════════════════════════════════════════════════
+
+// Hash returns the hex-encoded SHA-256 of data
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashFile returns the hex-encoded SHA-256 and size of the file at path
+func HashFile(path string) (string, int64, error) {
+	//nolint:gosec // callers pass validated install paths
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// NewEntry builds the entry for a binary
+func NewEntry(data []byte, commit, buildDate string) Entry {
+	return Entry{
+		SHA256:    Hash(data),
+		Size:      int64(len(data)),
+		Commit:    commit,
+		BuildDate: buildDate,
+	}
+}
+
+// Matches reports whether data is the binary this entry describes
+func (e Entry) Matches(data []byte) bool {
+	return int64(len(data)) == e.Size && Hash(data) == e.SHA256
+}
+
+// Short returns the first 12 hex digits of the hash for display
+func (e Entry) Short() string {
+	if len(e.SHA256) < 12 {
+		return e.SHA256
+	}
+	return e.SHA256[:12]
+}
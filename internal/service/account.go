@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Service Account
+// ══════════════════════════════════════════════════════════════
+
+// Built-in accounts a service can run under (`sc create/config obj=`)
+const (
+	AccountLocalSystem    = "LocalSystem"
+	AccountLocalService   = `NT AUTHORITY\LocalService`
+	AccountNetworkService = `NT AUTHORITY\NetworkService`
+)
+
+// BuiltinAccounts lists the built-in accounts in the order the TUI offers them
+var BuiltinAccounts = []string{AccountLocalService, AccountNetworkService, AccountLocalSystem}
+
+// ServiceAccount is the account a service logs on as. Password is only set
+// for custom accounts; it is never printed (see String) and never persisted.
+type ServiceAccount struct {
+	Name     string
+	Password string
+}
+
+// DefaultAccount returns the account a variant is installed under: Local
+// variants only need the machine, Remoto variants also reach the network.
+func DefaultAccount(variant string) ServiceAccount {
+	if variant == Remoto {
+		return ServiceAccount{Name: AccountNetworkService}
+	}
+	return ServiceAccount{Name: AccountLocalService}
+}
+
+// IsBuiltin reports whether the account is one of BuiltinAccounts
+func (a ServiceAccount) IsBuiltin() bool {
+	return isBuiltinAccount(a.Name)
+}
+
+// isBuiltinAccount matches a name against BuiltinAccounts, also accepting
+// the short forms sc qc may report ("NT Authority\LocalService", "LocalService")
+func isBuiltinAccount(name string) bool {
+	return builtinAccountName(name) != ""
+}
+
+// builtinAccountName returns the canonical built-in name for name, or ""
+func builtinAccountName(name string) string {
+	short := name
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		short = name[i+1:]
+	}
+	for _, builtin := range BuiltinAccounts {
+		builtinShort := builtin[strings.LastIndex(builtin, `\`)+1:]
+		if strings.EqualFold(name, builtin) || strings.EqualFold(short, builtinShort) {
+			return builtin
+		}
+	}
+	return ""
+}
+
+// AccountLabel returns the Spanish name of an account as services.msc shows
+// it; custom accounts are returned as-is
+func AccountLabel(name string) string {
+	switch builtinAccountName(name) {
+	case AccountLocalSystem:
+		return "Sistema local"
+	case AccountLocalService:
+		return "Servicio local"
+	case AccountNetworkService:
+		return "Servicio de red"
+	default:
+		return name
+	}
+}
+
+// String returns the account name without the password
+func (a ServiceAccount) String() string {
+	return a.Name
+}
+
+// GoString keeps the password out of %#v
+func (a ServiceAccount) GoString() string {
+	return fmt.Sprintf("service.ServiceAccount{Name: %q}", a.Name)
+}
+
+// Validate checks the account before it is handed to the backend
+func (a ServiceAccount) Validate() error {
+	if a.IsBuiltin() {
+		if a.Password != "" {
+			return fmt.Errorf("las cuentas integradas no usan contraseña")
+		}
+		return nil
+	}
+
+	if a.Name == "" {
+		return fmt.Errorf("indique la cuenta (DOMINIO\\usuario, .\\usuario o usuario@dominio)")
+	}
+	if len(a.Name) > 256 {
+		return fmt.Errorf("el nombre de cuenta excede 256 caracteres")
+	}
+	for _, c := range a.Name {
+		if c == '"' || c == '\'' || c == '`' || c == '$' || c == '&' || c == '|' || c == ';' ||
+			c == '/' || c == ':' || c == '*' || c == '?' || c == '<' || c == '>' || c < ' ' {
+			return fmt.Errorf("el nombre de cuenta contiene caracteres no permitidos")
+		}
+	}
+	if !strings.Contains(a.Name, `\`) && !strings.Contains(a.Name, "@") {
+		return fmt.Errorf("la cuenta debe incluir el dominio o equipo (DOMINIO\\usuario, .\\usuario o usuario@dominio)")
+	}
+	if a.Password == "" {
+		return fmt.Errorf("las cuentas personalizadas requieren contraseña")
+	}
+	if strings.ContainsAny(a.Password, "\r\n\x00") {
+		return fmt.Errorf("la contraseña contiene caracteres no permitidos")
+	}
+	return nil
+}
+
+// scArgs renders the account as `sc create/config` arguments. Built-in
+// accounts other than LocalSystem take an empty password.
+func (a ServiceAccount) scArgs() []string {
+	switch builtinAccountName(a.Name) {
+	case AccountLocalSystem:
+		return []string{"obj=", AccountLocalSystem}
+	case "":
+		return []string{"obj=", a.Name, "password=", a.Password}
+	default:
+		return []string{"obj=", builtinAccountName(a.Name), "password=", ""}
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Manager Operations
+// ══════════════════════════════════════════════════════════════
+
+// Account returns the account used for the next install (without password
+// when it is a built-in account)
+func (m *Manager) Account() ServiceAccount {
+	return m.variant.Account
+}
+
+// SetInstallAccount chooses the account used by the next Install
+func (m *Manager) SetInstallAccount(a ServiceAccount) error {
+	if err := a.Validate(); err != nil {
+		return m.opError("install", CategoryInvalid, err, "cuenta de servicio: %v", err)
+	}
+	m.variant.Account = a
+	return nil
+}
+
+// ChangeAccount changes the account of the installed service
+// (`sc config obj= password=`). The change takes effect on the next start.
+func (m *Manager) ChangeAccount(ctx context.Context, a ServiceAccount) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditAccount)
+	defer end(&err)
+	defer progressFrom(ctx).step("Cambiar cuenta de servicio")(&err)
+	defer m.invalidateStatus()
+
+	if err := a.Validate(); err != nil {
+		return m.opError("config", CategoryInvalid, err, "cuenta de servicio: %v", err)
+	}
+	if m.status(ctx) == StatusNotInstalled {
+		return m.opError("config", CategoryNotInstalled, nil, "el servicio no está instalado")
+	}
+	if output, err := m.ctrl.SetAccount(ctx, m.variant.RegistryName, a); err != nil {
+		if cErr := m.cancelled(ctx, "config"); cErr != nil {
+			return cErr
+		}
+		svcErr := m.toolError("config", output, err)
+		svcErr.Msg = "cambiar cuenta de servicio: " + svcErr.Error()
+		return svcErr
+	}
+	m.variant.Account = a
+	return nil
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Operation Audit Log
+// ══════════════════════════════════════════════════════════════
+// Every lifecycle operation appends one JSON line to audit.jsonl in the
+// installer's data directory, whoever started it (the TUI, journal recovery
+// at startup, a command line entry point). Operations run by another one
+// (Restart's stop and start, a switch's uninstall and install) belong to the
+// outer record. The file rotates by size, keeping auditBackups old files.
+
+// Audit file name and rotation limits
+const (
+	auditFileName = "audit.jsonl"
+	auditMaxSize  = 1 << 20 // Rotate once the file reaches 1 MiB
+	auditBackups  = 3       // audit.jsonl.1 (newest) … audit.jsonl.3
+)
+
+// Audited actions
+const (
+	AuditInstall             = "install"
+	AuditInstallStart        = "install-start"
+	AuditUninstall           = "uninstall"
+	AuditUninstallDependents = "uninstall-dependents"
+	AuditStart               = "start"
+	AuditStop                = "stop"
+	AuditStopDependents      = "stop-dependents"
+	AuditForceStop           = "force-stop"
+	AuditRestart             = "restart"
+	AuditUpgrade             = "upgrade"
+	AuditStartType           = "start-type"
+	AuditAccount             = "account"
+	AuditRecovery            = "recovery"
+	AuditRecoverJournal      = "recover-journal"
+	AuditSwitch              = "switch"
+	AuditResolveConflict     = "resolve-conflict"
+	AuditLogMaintenance      = "log-maintenance"
+)
+
+// Outcomes of an audited operation
+const (
+	AuditOK        = "ok"
+	AuditFailed    = "failed"
+	AuditCancelled = "cancelled"
+)
+
+// AuditEntry is one line of the audit log
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Machine    string    `json:"machine"`
+	User       string    `json:"user"`
+	Family     string    `json:"family"`
+	Variant    string    `json:"variant"` // Variant.ID
+	Action     string    `json:"action"`
+	DurationMs int64     `json:"duration_ms"`
+	Outcome    string    `json:"outcome"`
+	Category   string    `json:"category,omitempty"` // Error category of a failed operation
+	Error      string    `json:"error,omitempty"`
+	Output     string    `json:"output,omitempty"` // Raw tool output (sc.exe / systemctl)
+}
+
+// Duration returns how long the operation took
+func (e AuditEntry) Duration() time.Duration {
+	return time.Duration(e.DurationMs) * time.Millisecond
+}
+
+// ActionLabel returns the Spanish name of the audited action
+func (e AuditEntry) ActionLabel() string {
+	switch e.Action {
+	case AuditInstall:
+		return "Instalar"
+	case AuditInstallStart:
+		return "Instalar e iniciar"
+	case AuditUninstall:
+		return "Desinstalar"
+	case AuditUninstallDependents:
+		return "Desinstalar con dependientes"
+	case AuditStart:
+		return "Iniciar"
+	case AuditStop:
+		return "Detener"
+	case AuditStopDependents:
+		return "Detener con dependientes"
+	case AuditForceStop:
+		return "Forzar detención"
+	case AuditRestart:
+		return "Reiniciar"
+	case AuditUpgrade:
+		return "Actualizar"
+	case AuditStartType:
+		return "Tipo de inicio"
+	case AuditAccount:
+		return "Cuenta de servicio"
+	case AuditRecovery:
+		return "Recuperación"
+	case AuditRecoverJournal:
+		return "Recuperar operación"
+	case AuditSwitch:
+		return "Cambiar variante"
+	case AuditResolveConflict:
+		return "Reparar conflicto"
+	case AuditLogMaintenance:
+		return "Mantenimiento de logs"
+	default:
+		return e.Action
+	}
+}
+
+// auditKey marks a context whose operation is already being audited
+type auditKey struct{}
+
+// beginAudit starts the audit record of a lifecycle operation on this
+// manager's variant, meant for
+//
+//	ctx, end := m.beginAudit(ctx, AuditStart)
+//	defer end(&err)
+//
+// Operations called with the returned context are part of this record.
+func (m *Manager) beginAudit(ctx context.Context, action string) (context.Context, func(err *error)) {
+	return beginAudit(ctx, m.ctrl, m.variant, action)
+}
+
+// beginAudit is Manager.beginAudit for operations that span a family
+func beginAudit(ctx context.Context, ctrl Controller, v Variant, action string) (context.Context, func(err *error)) {
+	if ctx.Value(auditKey{}) != nil {
+		return ctx, func(*error) {}
+	}
+	ctx = context.WithValue(ctx, auditKey{}, action)
+	start := time.Now()
+
+	return ctx, func(err *error) {
+		entry := AuditEntry{
+			Time:       start,
+			Machine:    machineName(),
+			User:       osUserName(),
+			Family:     v.Family,
+			Variant:    v.ID,
+			Action:     action,
+			DurationMs: time.Since(start).Milliseconds(),
+			Outcome:    AuditOK,
+		}
+		if err != nil && *err != nil {
+			entry.Outcome = AuditFailed
+			if CategoryOf(*err) == CategoryCancelled {
+				entry.Outcome = AuditCancelled
+			}
+			entry.Category = CategoryOf(*err).String()
+			entry.Error = (*err).Error()
+			entry.Output = toolOutputOf(*err)
+		}
+		// Best effort: a full disk must not turn a finished operation into a failure
+		_ = appendAudit(auditDir(ctrl), entry)
+	}
+}
+
+// familyVariant returns the variant called name among a family's variants,
+// or a bare one carrying the names when the family does not have it
+func familyVariant(family string, variants []Variant, name string) Variant {
+	for _, v := range variants {
+		if v.Variant == name {
+			return v
+		}
+	}
+	return Variant{Family: family, Variant: name}
+}
+
+// toolOutputOf returns the first raw tool output found in err's chain
+func toolOutputOf(err error) string {
+	var svcErr *Error
+	for errors.As(err, &svcErr) {
+		if svcErr.Output != "" {
+			return svcErr.Output
+		}
+		if svcErr.Err == nil {
+			break
+		}
+		err = svcErr.Err
+	}
+	return ""
+}
+
+// machineName returns the host name, or "" if it cannot be read
+func machineName() string {
+	name, _ := os.Hostname()
+	return name
+}
+
+// osUserName returns the account running the installer (DOMAIN\user on Windows)
+func osUserName() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USERNAME"); name != "" {
+		return name
+	}
+	return os.Getenv("USER")
+}
+
+// auditDir returns the directory of the audit log for a backend
+func auditDir(ctrl Controller) string {
+	return filepath.Join(ctrl.LogRoot(), installerDataDir)
+}
+
+// auditMu serializes appends and rotation within the process
+var auditMu sync.Mutex
+
+// appendAudit writes entry as one line, rotating the file first if it is full
+func appendAudit(dir string, entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("serializar auditoría: %w", err)
+	}
+	line = append(line, '\n')
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("crear directorio de auditoría: %w", err)
+	}
+	path := filepath.Join(dir, auditFileName)
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > auditMaxSize {
+		rotateAudit(path)
+	}
+
+	//nolint:gosec // path is built from the backend's data root and a constant name
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("abrir auditoría: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("escribir auditoría: %w", err)
+	}
+	return f.Close()
+}
+
+// rotateAudit shifts audit.jsonl.N to .N+1, dropping the oldest, and moves
+// the current file to .1
+func rotateAudit(path string) {
+	_ = os.Remove(fmt.Sprintf("%s.%d", path, auditBackups))
+	for i := auditBackups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	_ = os.Rename(path, path+".1")
+}
+
+// ReadAuditLog returns the audit entries of the platform backend, rotated
+// files included, newest first
+func ReadAuditLog() ([]AuditEntry, error) {
+	return readAudit(auditDir(defaultController))
+}
+
+// AuditLogPath returns the current audit file of the platform backend
+func AuditLogPath() string {
+	return filepath.Join(auditDir(defaultController), auditFileName)
+}
+
+// readAudit reads the audit file and its backups. Lines that do not parse
+// (a write cut short by a crash) are skipped.
+func readAudit(dir string) ([]AuditEntry, error) {
+	path := filepath.Join(dir, auditFileName)
+	files := []string{path}
+	for i := 1; i <= auditBackups; i++ {
+		files = append(files, fmt.Sprintf("%s.%d", path, i))
+	}
+
+	var entries []AuditEntry
+	for _, name := range files {
+		//nolint:gosec // fixed names under the backend's data root
+		f, err := os.Open(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("leer auditoría: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), auditMaxSize)
+		for scanner.Scan() {
+			var e AuditEntry
+			if json.Unmarshal(scanner.Bytes(), &e) == nil {
+				entries = append(entries, e)
+			}
+		}
+		err = scanner.Err()
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("leer auditoría %s: %w", filepath.Base(name), err)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	return entries, nil
+}
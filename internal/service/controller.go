@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Controller Interface
+// ══════════════════════════════════════════════════════════════
+
+// Controller is the service-control backend used by Manager. Each method
+// operates on a registry name that callers have already validated.
+//
+// Mutating calls return the raw tool output alongside the error. Failures
+// are reported the way sc.exe reports them ("FAILED <code>:" followed by the
+// Win32 message), so Manager can apply the same error policy to any backend.
+type Controller interface {
+	// Create registers a new service pointing at binPath
+	Create(ctx context.Context, regName, binPath, displayName string, opts CreateOptions) ([]byte, error)
+	// Delete removes the service registration
+	Delete(ctx context.Context, regName string) ([]byte, error)
+	// Start requests the service to start
+	Start(ctx context.Context, regName string) ([]byte, error)
+	// Stop requests the service to stop
+	Stop(ctx context.Context, regName string) ([]byte, error)
+	// SetDescription sets the description shown in services.msc
+	SetDescription(ctx context.Context, regName, description string) ([]byte, error)
+	// Dependents lists the services that depend on this one
+	Dependents(ctx context.Context, regName string) ([]string, error)
+	// SetAccount changes the account the service logs on as
+	SetAccount(ctx context.Context, regName string, account ServiceAccount) ([]byte, error)
+	// SetStartType changes how the service is started at boot
+	SetStartType(ctx context.Context, regName string, startType StartType) ([]byte, error)
+	// Query returns the current state of the service
+	Query(ctx context.Context, regName string) (ServiceState, error)
+	// QueryConfig returns the registered configuration of the service
+	QueryConfig(ctx context.Context, regName string) (ServiceConfig, error)
+	// ConfigureFailure applies the recovery policy (failure actions and flag)
+	ConfigureFailure(ctx context.Context, regName string, policy RecoveryPolicy) ([]byte, error)
+	// QueryFailure reads back the recovery policy in effect
+	QueryFailure(ctx context.Context, regName string) (RecoveryPolicy, error)
+	// Kill forcibly terminates the service process
+	Kill(ctx context.Context, regName string) error
+	// InstallRoot returns the directory that holds one folder per installed service
+	InstallRoot() (string, error)
+	// LogRoot returns the directory that holds one log folder per service
+	LogRoot() string
+}
+
+// defaultController is the backend used by NewManager
+var defaultController = newDefaultController()
+
+// newDefaultController selects the backend for the current platform
+func newDefaultController() Controller {
+	if runtime.GOOS == "linux" {
+		return NewSystemdController()
+	}
+	return SCController{}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Win32 Error Codes
+// ══════════════════════════════════════════════════════════════
+
+// Win32 error codes reported by the service control manager
+const (
+	win32AccessDenied          = 5
+	win32ServiceRequestTimeout = 1053
+	win32ServiceAlreadyRunning = 1056
+	win32InvalidServiceAccount = 1057
+	win32ServiceDisabled       = 1058
+	win32DependentsRunning     = 1051
+	win32ServiceCannotAccept   = 1061
+	win32ServiceNotActive      = 1062
+	win32ServiceDoesNotExist   = 1060
+	win32ServiceMarkedDelete   = 1072
+	win32ServiceLogonFailed    = 1069
+	win32ServiceExists         = 1073
+)
+
+// win32Messages holds the English sc.exe text for each emulated code
+var win32Messages = map[int]string{
+	win32AccessDenied:          "Access is denied.",
+	win32ServiceRequestTimeout: "The service did not respond to the start or control request in a timely fashion.",
+	win32ServiceAlreadyRunning: "An instance of the service is already running.",
+	win32InvalidServiceAccount: "The account name is invalid or does not exist, or the password is invalid for the account name specified.",
+	win32ServiceLogonFailed:    "The service did not start due to a logon failure.",
+	win32ServiceDisabled:       "The service cannot be started, either because it is disabled or because it has no enabled devices associated with it.",
+	win32DependentsRunning:     "A stop control has been sent to a service that other running services are dependent on.",
+	win32ServiceCannotAccept:   "The service cannot accept control messages at this time.",
+	win32ServiceNotActive:      "The service has not been started.",
+	win32ServiceDoesNotExist:   "The specified service does not exist as an installed service.",
+	win32ServiceMarkedDelete:   "The specified service has been marked for deletion.",
+	win32ServiceExists:         "The specified service already exists.",
+}
+
+// scFailure formats an sc.exe-style failure for the given Win32 code.
+// Non-sc backends use it so Manager sees the same output on every platform.
+func scFailure(call string, code int) ([]byte, error) {
+	msg, ok := win32Messages[code]
+	if !ok {
+		msg = "The operation failed."
+	}
+	output := fmt.Sprintf("[SC] %s FAILED %d:\n\n%s", call, code, msg)
+	return []byte(output), fmt.Errorf("exit status %d", code)
+}
+
+// ══════════════════════════════════════════════════════════════
+// sc.exe Backend
+// ══════════════════════════════════════════════════════════════
+
+// SCController drives the Windows service control manager through sc.exe
+// and taskkill.exe.
+type SCController struct{}
+
+// Create registers the service with `sc create`
+func (SCController) Create(ctx context.Context, regName, binPath, displayName string, opts CreateOptions) ([]byte, error) {
+	return secureScCreate(ctx, regName, binPath, displayName, opts)
+}
+
+// Delete removes the service with `sc delete`
+func (SCController) Delete(ctx context.Context, regName string) ([]byte, error) {
+	return secureScRun(ctx, "delete", regName)
+}
+
+// Start starts the service with `sc start`
+func (SCController) Start(ctx context.Context, regName string) ([]byte, error) {
+	return secureScRun(ctx, "start", regName)
+}
+
+// Stop stops the service with `sc stop`
+func (SCController) Stop(ctx context.Context, regName string) ([]byte, error) {
+	return secureScRun(ctx, "stop", regName)
+}
+
+// Dependents parses `sc enumdepend`
+func (SCController) Dependents(ctx context.Context, regName string) ([]string, error) {
+	output, err := secureScRun(ctx, "enumdepend", regName)
+	if err != nil {
+		return nil, fmt.Errorf("sc enumdepend: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	return ParseEnumDepend(output), nil
+}
+
+// SetAccount changes the logon account with `sc config obj= password=`
+func (SCController) SetAccount(ctx context.Context, regName string, account ServiceAccount) ([]byte, error) {
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+	return secureScRun(ctx, "config", regName, account.scArgs()...)
+}
+
+// SetStartType changes the start type with `sc config start=`
+func (SCController) SetStartType(ctx context.Context, regName string, startType StartType) ([]byte, error) {
+	if err := startType.Validate(); err != nil {
+		return nil, err
+	}
+	return secureScRun(ctx, "config", regName, "start=", string(startType))
+}
+
+// Query parses `sc queryex` output into a ServiceState
+func (SCController) Query(ctx context.Context, regName string) (ServiceState, error) {
+	output, err := secureScRun(ctx, "queryex", regName)
+	state := ParseQueryEx(output)
+	if err != nil && state.Status != StatusNotInstalled {
+		// Unexpected error from sc; return unknown so callers can handle/log if needed.
+		return ServiceState{Status: StatusUnknown}, err
+	}
+	return state, nil
+}
+
+// QueryConfig parses `sc qc` output into a ServiceConfig
+func (SCController) QueryConfig(ctx context.Context, regName string) (ServiceConfig, error) {
+	output, err := secureScRun(ctx, "qc", regName)
+	if err != nil {
+		return ServiceConfig{}, fmt.Errorf("sc qc: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	cfg := ParseQC(output)
+	// The description is not part of sc qc; a failure here leaves it empty
+	if descOutput, err := secureScRun(ctx, "qdescription", regName); err == nil {
+		cfg.Description = ParseQDescription(descOutput)
+	}
+	return cfg, nil
+}
+
+// SetDescription runs `sc description`
+func (SCController) SetDescription(ctx context.Context, regName, description string) ([]byte, error) {
+	if !isValidDescription(description) {
+		return nil, fmt.Errorf("invalid Description")
+	}
+	return secureScRun(ctx, "description", regName, description)
+}
+
+// ConfigureFailure applies the policy with `sc failure` and `sc failureflag`
+func (SCController) ConfigureFailure(ctx context.Context, regName string, policy RecoveryPolicy) ([]byte, error) {
+	output, err := secureScFailure(ctx, regName, policy)
+	if err != nil {
+		return output, err
+	}
+	flag := "0"
+	if policy.NonCrashFailures {
+		flag = "1"
+	}
+	flagOutput, err := secureScRun(ctx, "failureflag", regName, flag)
+	return append(output, flagOutput...), err
+}
+
+// QueryFailure parses `sc qfailure` and `sc qfailureflag` output
+func (SCController) QueryFailure(ctx context.Context, regName string) (RecoveryPolicy, error) {
+	output, err := secureScRun(ctx, "qfailure", regName)
+	if err != nil {
+		return RecoveryPolicy{}, fmt.Errorf("sc qfailure: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	policy := ParseQFailure(output)
+
+	flagOutput, err := secureScRun(ctx, "qfailureflag", regName)
+	if err != nil {
+		return RecoveryPolicy{}, fmt.Errorf("sc qfailureflag: %w (%s)", err, strings.TrimSpace(string(flagOutput)))
+	}
+	policy.NonCrashFailures = ParseQFailureFlag(flagOutput)
+	return policy, nil
+}
+
+// Kill terminates the service process with taskkill
+func (SCController) Kill(ctx context.Context, regName string) error {
+	return secureTaskKillByService(ctx, regName)
+}
+
+// InstallRoot returns %ProgramFiles%
+func (SCController) InstallRoot() (string, error) {
+	programFiles := os.Getenv("ProgramFiles")
+	if programFiles == "" {
+		return "", fmt.Errorf("environment variable `ProgramFiles` is empty")
+	}
+	return programFiles, nil
+}
+
+// LogRoot returns %PROGRAMDATA%
+func (SCController) LogRoot() string {
+	return os.Getenv("PROGRAMDATA")
+}
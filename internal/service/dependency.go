@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Service Dependencies
+// ══════════════════════════════════════════════════════════════
+// A variant declares the services it needs (`sc create depend=`) so the SCM
+// starts them first after a reboot. A dependency is either a fixed service
+// name or a sibling family, resolved at install time to whichever of its
+// variants is registered.
+
+// Well-known Windows services the daemons depend on
+const (
+	DependencySpooler = "Spooler" // Print spooler
+	DependencyTcpip   = "Tcpip"   // TCP/IP protocol driver (network stack)
+)
+
+// maxDependencyDepth bounds how deep DependencyTree follows dependencies
+const maxDependencyDepth = 3
+
+// Dependency is one entry of a variant's dependency list. Exactly one of
+// Service and Family is set.
+type Dependency struct {
+	Service string // Registry name of a system or third-party service
+	Family  string // Service family ("scale", "ticket") resolved to its installed variant
+}
+
+// String returns the service or family the dependency names
+func (d Dependency) String() string {
+	if d.Family != "" {
+		return "familia " + d.Family
+	}
+	return d.Service
+}
+
+// DefaultDependencies returns the dependencies declared for a variant: the
+// ticket daemon prints through the spooler, and Remoto variants serve the
+// LAN so they need the network stack.
+func DefaultDependencies(family, variant string) []Dependency {
+	var deps []Dependency
+	if family == "ticket" {
+		deps = append(deps, Dependency{Service: DependencySpooler})
+	}
+	if variant == Remoto {
+		deps = append(deps, Dependency{Service: DependencyTcpip})
+	}
+	return deps
+}
+
+// validateDependencies checks the declared dependencies of a variant
+func validateDependencies(variant Variant) error {
+	families := GetFamilyNames()
+	for _, d := range variant.Dependencies {
+		switch {
+		case (d.Service == "") == (d.Family == ""):
+			return fmt.Errorf("dependencia %q: indique un servicio o una familia", d)
+		case d.Service != "" && !isValidServiceName(d.Service):
+			return fmt.Errorf("dependencia %q: nombre de servicio no válido", d.Service)
+		case d.Service != "" && strings.EqualFold(d.Service, variant.RegistryName):
+			return fmt.Errorf("el servicio no puede depender de sí mismo")
+		case d.Family == variant.Family:
+			return fmt.Errorf("el servicio no puede depender de su propia familia")
+		case d.Family != "" && !containsString(families, d.Family):
+			return fmt.Errorf("dependencia: familia desconocida %q", d.Family)
+		}
+	}
+	return nil
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveDependencies turns the declared dependencies into registry names,
+// checking that every one of them is registered. A family dependency
+// resolves to whichever of its variants is installed.
+func (m *Manager) resolveDependencies(ctx context.Context) ([]string, error) {
+	registry := GetServiceRegistry()
+	names := make([]string, 0, len(m.variant.Dependencies))
+
+	for _, d := range m.variant.Dependencies {
+		if d.Service != "" {
+			state, err := m.ctrl.Query(ctx, d.Service)
+			if err != nil || state.Status == StatusNotInstalled {
+				return nil, m.opError("install", CategoryInvalid, err,
+					"la dependencia '%s' no está instalada en este equipo", d.Service)
+			}
+			names = append(names, d.Service)
+			continue
+		}
+
+		resolved := ""
+		for _, v := range registry[d.Family] {
+			if state, err := m.ctrl.Query(ctx, v.RegistryName); err == nil && state.Status != StatusNotInstalled {
+				resolved = v.RegistryName
+				break
+			}
+		}
+		if resolved == "" {
+			return nil, m.opError("install", CategoryInvalid, nil,
+				"la dependencia '%s' no está instalada — instale primero %s", d, capitalizeFamily(d.Family))
+		}
+		names = append(names, resolved)
+	}
+	return names, nil
+}
+
+// capitalizeFamily returns the family name with its first letter uppercased
+func capitalizeFamily(family string) string {
+	if family == "" {
+		return family
+	}
+	return strings.ToUpper(family[:1]) + family[1:]
+}
+
+// ══════════════════════════════════════════════════════════════
+// Dependency Tree and Dependents
+// ══════════════════════════════════════════════════════════════
+
+// DependencyNode is one service in a dependency tree
+type DependencyNode struct {
+	Name     string
+	Status   Status
+	Children []DependencyNode
+}
+
+// DependencyTree returns the registered dependencies of the installed
+// service with their current status, following each dependency's own
+// dependencies up to maxDependencyDepth levels. Lookups go through the
+// status cache.
+func (m *Manager) DependencyTree(ctx context.Context) []DependencyNode {
+	return m.dependencyTree(ctx, nil)
+}
+
+// dependencyTree is DependencyTree counting its lookups in counter
+func (m *Manager) dependencyTree(ctx context.Context, counter *queryCounter) []DependencyNode {
+	cfg, err := m.cachedConfig(ctx, counter)
+	if err != nil {
+		return nil
+	}
+	visited := map[string]bool{strings.ToLower(m.variant.RegistryName): true}
+	return m.dependencyNodes(ctx, counter, cfg.Dependencies, 1, visited)
+}
+
+// dependencyNodes builds the nodes for names, skipping services already on
+// the path (dependency cycles)
+func (m *Manager) dependencyNodes(ctx context.Context, counter *queryCounter, names []string, depth int, visited map[string]bool) []DependencyNode {
+	var nodes []DependencyNode
+	for _, name := range names {
+		// sc qc prefixes load order groups with '+'; they are not services
+		if name == "" || strings.HasPrefix(name, "+") || visited[strings.ToLower(name)] {
+			continue
+		}
+		node := DependencyNode{Name: name, Status: StatusUnknown}
+		if state, err := queryCache.query(ctx, m.ctrl, name, counter); err == nil {
+			node.Status = state.Status
+		}
+		if depth < maxDependencyDepth && node.Status != StatusNotInstalled {
+			if cfg, err := queryCache.queryConfig(ctx, m.ctrl, name, counter); err == nil {
+				visited[strings.ToLower(name)] = true
+				node.Children = m.dependencyNodes(ctx, counter, cfg.Dependencies, depth+1, visited)
+				delete(visited, strings.ToLower(name))
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// StopWithDependents stops the running services that depend on this one,
+// then this service, as services.msc does. Without it the SCM refuses the
+// stop (ERROR_DEPENDENT_SERVICES_RUNNING).
+func (m *Manager) StopWithDependents(ctx context.Context) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditStopDependents)
+	defer end(&err)
+
+	if err := m.stopDependents(ctx, "stop"); err != nil {
+		return err
+	}
+	return m.Stop(ctx)
+}
+
+// UninstallWithDependents stops the running services that depend on this
+// one, then uninstalls it. Without it Uninstall refuses to run while any
+// dependent is up.
+func (m *Manager) UninstallWithDependents(ctx context.Context) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditUninstallDependents)
+	defer end(&err)
+
+	if err := m.stopDependents(ctx, "uninstall"); err != nil {
+		return err
+	}
+	return m.Uninstall(ctx)
+}
+
+// runningDependents returns the dependents that are not stopped. Failing to
+// list them yields none: the stop itself then reports
+// ERROR_DEPENDENT_SERVICES_RUNNING if any are still up.
+func (m *Manager) runningDependents(ctx context.Context) []DependencyNode {
+	dependents, _ := m.dependents(ctx)
+	running := dependents[:0]
+	for _, dep := range dependents {
+		if dep.Status != StatusStopped && dep.Status != StatusNotInstalled {
+			running = append(running, dep)
+		}
+	}
+	return running
+}
+
+// stopDependents stops every running dependent and waits for it to stop
+func (m *Manager) stopDependents(ctx context.Context, op string) error {
+	running := m.runningDependents(ctx)
+	if len(running) == 0 {
+		return nil
+	}
+
+	return runStep(ctx, stepDependents, func() error {
+		for _, dep := range running {
+			progressFrom(ctx).detail("deteniendo '%s'", dep.Name)
+			queryCache.invalidate(m.ctrl, dep.Name)
+			if output, err := m.ctrl.Stop(ctx, dep.Name); err != nil {
+				if cErr := m.cancelled(ctx, op); cErr != nil {
+					return cErr
+				}
+				if svcErr := m.toolError(op, output, err); svcErr.Category != CategoryNotRunning {
+					svcErr.Msg = fmt.Sprintf("detener servicio dependiente '%s': %v", dep.Name, svcErr)
+					return svcErr
+				}
+			}
+			if !waitForService(ctx, m.ctrl, dep.Name, StatusStopped, m.opts.StopTimeout) {
+				if cErr := m.cancelled(ctx, op); cErr != nil {
+					return cErr
+				}
+				return m.opError(op, CategoryTimeout, nil, "el servicio dependiente '%s' no se detuvo a tiempo", dep.Name)
+			}
+		}
+		return nil
+	})
+}
+
+// waitForService polls any service (not only this manager's) until it
+// reaches want, timeout elapses or ctx is done
+func waitForService(ctx context.Context, ctrl Controller, regName string, want Status, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(watcherMinInterval)
+	defer ticker.Stop()
+	for {
+		state, err := ctrl.Query(ctx, regName)
+		if err == nil && statusReached(state.Status, want) {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dependents returns the services that depend on this one, with their
+// status (`sc enumdepend`). Stopping or removing this service affects them.
+// Lookups go through the status cache.
+func (m *Manager) Dependents(ctx context.Context) ([]DependencyNode, error) {
+	return m.cachedDependents(ctx, nil)
+}
+
+// cachedDependents is Dependents counting its lookups in counter
+func (m *Manager) cachedDependents(ctx context.Context, counter *queryCounter) ([]DependencyNode, error) {
+	names, err := queryCache.queryDependents(ctx, m.ctrl, m.variant.RegistryName, counter)
+	if err != nil {
+		return nil, m.opError("enumdepend", CategoryUnknown, err, "consultar servicios dependientes: %v", err)
+	}
+	nodes := make([]DependencyNode, 0, len(names))
+	for _, name := range names {
+		node := DependencyNode{Name: name, Status: StatusUnknown}
+		if state, err := queryCache.query(ctx, m.ctrl, name, counter); err == nil {
+			node.Status = state.Status
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// dependents is Dependents straight from the backend: a running operation
+// must see the current status of what it is about to stop
+func (m *Manager) dependents(ctx context.Context) ([]DependencyNode, error) {
+	names, err := m.ctrl.Dependents(ctx, m.variant.RegistryName)
+	if err != nil {
+		return nil, m.opError("enumdepend", CategoryUnknown, err, "consultar servicios dependientes: %v", err)
+	}
+	nodes := make([]DependencyNode, 0, len(names))
+	for _, name := range names {
+		node := DependencyNode{Name: name, Status: StatusUnknown}
+		if state, err := m.ctrl.Query(ctx, name); err == nil {
+			node.Status = state.Status
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Error Categories
+// ══════════════════════════════════════════════════════════════
+
+// ErrorCategory classifies a failed service operation so callers can react
+// without inspecting tool output
+type ErrorCategory int
+
+const (
+	// CategoryUnknown is an unclassified failure; see Error.Output
+	CategoryUnknown ErrorCategory = iota
+	// CategoryNotInstalled means the service is not registered (Win32 1060)
+	CategoryNotInstalled
+	// CategoryAlreadyRunning means a start was requested on a running service (1056)
+	CategoryAlreadyRunning
+	// CategoryNotRunning means a stop was requested on a stopped service (1062)
+	CategoryNotRunning
+	// CategoryMarkedForDeletion means the SCM will delete the service once its process exits (1072)
+	CategoryMarkedForDeletion
+	// CategoryAlreadyExists means a service with the same name is registered (1073)
+	CategoryAlreadyExists
+	// CategoryAccessDenied means the installer lacks administrator rights (5)
+	CategoryAccessDenied
+	// CategoryBusy means the service is in a transition and cannot accept controls (1061)
+	CategoryBusy
+	// CategoryTimeout means the service did not reach the expected state in time (1053)
+	CategoryTimeout
+	// CategoryInvalid means the variant configuration failed validation
+	CategoryInvalid
+	// CategoryFilesystem means reading or writing the install directory failed
+	CategoryFilesystem
+	// CategoryDisabled means a start was requested on a disabled service (1058)
+	CategoryDisabled
+	// CategoryDependentsRunning means a stop was refused because running
+	// services depend on this one (1051)
+	CategoryDependentsRunning
+	// CategoryLogonFailed means the service account could not log on: wrong
+	// password, unknown account or no "log on as a service" right (1069, 1057)
+	CategoryLogonFailed
+	// CategoryCancelled means the caller cancelled the operation's context
+	CategoryCancelled
+	// CategoryPortInUse means something already listens on the daemon's port,
+	// so it would die on bind (see AllowPortConflicts)
+	CategoryPortInUse
+)
+
+// Sentinel errors usable with errors.Is against any *Error
+var (
+	ErrNotInstalled      = errors.New("el servicio no está instalado")
+	ErrAlreadyRunning    = errors.New("el servicio ya está en ejecución")
+	ErrNotRunning        = errors.New("el servicio no está en ejecución")
+	ErrMarkedForDeletion = errors.New("servicio marcado para eliminación (se completará al cerrar el proceso)")
+	ErrAlreadyExists     = errors.New("el servicio ya existe en el registro de Windows (use Desinstalar primero)")
+	ErrAccessDenied      = errors.New("acceso denegado")
+	ErrTimeout           = errors.New("el servicio no respondió a tiempo")
+	ErrDisabled          = errors.New("el servicio está deshabilitado (cambie el tipo de inicio)")
+	ErrDependentsRunning = errors.New("otros servicios en ejecución dependen de este servicio; deténgalos primero")
+	ErrCancelled         = errors.New("operación cancelada por el usuario")
+	ErrPortInUse         = errors.New("el puerto del servicio ya está en uso")
+	ErrLogonFailed       = errors.New("la cuenta del servicio no pudo iniciar sesión (contraseña, cuenta inexistente o sin derecho 'Iniciar sesión como servicio')")
+)
+
+// categorySentinels maps each category to the sentinel it matches
+var categorySentinels = map[ErrorCategory]error{
+	CategoryNotInstalled:      ErrNotInstalled,
+	CategoryAlreadyRunning:    ErrAlreadyRunning,
+	CategoryNotRunning:        ErrNotRunning,
+	CategoryMarkedForDeletion: ErrMarkedForDeletion,
+	CategoryAlreadyExists:     ErrAlreadyExists,
+	CategoryAccessDenied:      ErrAccessDenied,
+	CategoryTimeout:           ErrTimeout,
+	CategoryDisabled:          ErrDisabled,
+	CategoryLogonFailed:       ErrLogonFailed,
+	CategoryDependentsRunning: ErrDependentsRunning,
+	CategoryCancelled:         ErrCancelled,
+	CategoryPortInUse:         ErrPortInUse,
+}
+
+// categoryFromCode maps a Win32 error code to its category
+func categoryFromCode(code int) ErrorCategory {
+	switch code {
+	case win32ServiceDoesNotExist:
+		return CategoryNotInstalled
+	case win32ServiceAlreadyRunning:
+		return CategoryAlreadyRunning
+	case win32ServiceNotActive:
+		return CategoryNotRunning
+	case win32ServiceMarkedDelete:
+		return CategoryMarkedForDeletion
+	case win32ServiceExists:
+		return CategoryAlreadyExists
+	case win32AccessDenied:
+		return CategoryAccessDenied
+	case win32ServiceCannotAccept:
+		return CategoryBusy
+	case win32ServiceRequestTimeout:
+		return CategoryTimeout
+	case win32ServiceDisabled:
+		return CategoryDisabled
+	case win32DependentsRunning:
+		return CategoryDependentsRunning
+	case win32ServiceLogonFailed, win32InvalidServiceAccount:
+		return CategoryLogonFailed
+	default:
+		return CategoryUnknown
+	}
+}
+
+// String returns the category identifier used in logs
+func (c ErrorCategory) String() string {
+	switch c {
+	case CategoryNotInstalled:
+		return "not-installed"
+	case CategoryAlreadyRunning:
+		return "already-running"
+	case CategoryNotRunning:
+		return "not-running"
+	case CategoryMarkedForDeletion:
+		return "marked-for-deletion"
+	case CategoryAlreadyExists:
+		return "already-exists"
+	case CategoryAccessDenied:
+		return "access-denied"
+	case CategoryBusy:
+		return "busy"
+	case CategoryTimeout:
+		return "timeout"
+	case CategoryInvalid:
+		return "invalid"
+	case CategoryFilesystem:
+		return "filesystem"
+	case CategoryDisabled:
+		return "disabled"
+	case CategoryDependentsRunning:
+		return "dependents-running"
+	case CategoryLogonFailed:
+		return "logon-failed"
+	case CategoryCancelled:
+		return "cancelled"
+	case CategoryPortInUse:
+		return "port-in-use"
+	default:
+		return "unknown"
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Error Type
+// ══════════════════════════════════════════════════════════════
+
+// Error describes a failed service operation
+type Error struct {
+	Op       string        // Operation: "install", "uninstall", "start", "stop", "restart"
+	Variant  string        // Variant.ID the operation targeted
+	Code     int           // Win32 error code, 0 if none was reported
+	Output   string        // Raw tool output (sc.exe / systemctl)
+	Category ErrorCategory // Classification for programmatic handling
+	Msg      string        // Human-readable detail (Spanish)
+	Err      error         // Underlying error, if any
+}
+
+// Error returns the human-readable message
+func (e *Error) Error() string {
+	if e.Msg != "" {
+		return e.Msg
+	}
+	if sentinel, ok := categorySentinels[e.Category]; ok {
+		return sentinel.Error()
+	}
+	if e.Output != "" {
+		return fmt.Sprintf("%s falló (%v) - salida: '%s'", e.Op, e.Err, e.Output)
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s falló: %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("%s falló", e.Op)
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel for this error's category
+func (e *Error) Is(target error) bool {
+	sentinel, ok := categorySentinels[e.Category]
+	return ok && target == sentinel
+}
+
+// CategoryOf returns the category of err, or CategoryUnknown if err is not
+// (and does not wrap) an *Error
+func CategoryOf(err error) ErrorCategory {
+	var svcErr *Error
+	if errors.As(err, &svcErr) {
+		return svcErr.Category
+	}
+	return CategoryUnknown
+}
+
+// ══════════════════════════════════════════════════════════════
+// Constructors
+// ══════════════════════════════════════════════════════════════
+
+// toolError builds an *Error from a failed backend call, classifying it by
+// the Win32 code found in the tool output
+func (m *Manager) toolError(op string, output []byte, err error) *Error {
+	code, _ := ParseSCError(output)
+	return &Error{
+		Op:       op,
+		Variant:  m.variant.ID,
+		Code:     code,
+		Output:   strings.TrimSpace(string(output)),
+		Category: categoryFromCode(code),
+		Err:      err,
+	}
+}
+
+// opError builds an *Error for a failure detected by Manager itself
+func (m *Manager) opError(op string, category ErrorCategory, err error, format string, args ...any) *Error {
+	return &Error{
+		Op:       op,
+		Variant:  m.variant.ID,
+		Category: category,
+		Msg:      fmt.Sprintf(format, args...),
+		Err:      err,
+	}
+}
+
+// cancelled returns the error for an operation whose context is done, or nil
+// while it is still live. A deadline set by the caller reads as a timeout.
+func (m *Manager) cancelled(ctx context.Context, op string) *Error {
+	switch err := ctx.Err(); {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Op: op, Variant: m.variant.ID, Category: CategoryTimeout, Err: err}
+	default:
+		return &Error{Op: op, Variant: m.variant.ID, Category: CategoryCancelled, Err: err}
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Installer Configuration
+// ══════════════════════════════════════════════════════════════
+// Settings the installer keeps between runs live in config.json in its data
+// directory, next to the journals and the audit log. The file is written
+// with the defaults the first time it is read, so an administrator finds it
+// in place to edit.
+
+// installerConfigFile is the configuration file name
+const installerConfigFile = "config.json"
+
+// InstallerConfig is the installer's persisted configuration
+type InstallerConfig struct {
+	LogRetention LogRetention `json:"log_retention"`
+}
+
+// DefaultInstallerConfig returns the configuration used when none is saved
+func DefaultInstallerConfig() InstallerConfig {
+	return InstallerConfig{
+		LogRetention: LogRetention{
+			CompressAfterDays: 7,
+			KeepArchives:      30,
+			MaxArchiveMB:      100,
+		},
+	}
+}
+
+// LoadInstallerConfig reads the configuration of the platform backend
+func LoadInstallerConfig() (InstallerConfig, error) {
+	return loadInstallerConfig(auditDir(defaultController))
+}
+
+// InstallerConfigPath returns the configuration file of the platform backend
+func InstallerConfigPath() string {
+	return filepath.Join(auditDir(defaultController), installerConfigFile)
+}
+
+// loadInstallerConfig reads dir/config.json. Fields missing from the file
+// keep their defaults; a missing file is created with the defaults (best
+// effort, the defaults are returned either way).
+func loadInstallerConfig(dir string) (InstallerConfig, error) {
+	cfg := DefaultInstallerConfig()
+	path := filepath.Join(dir, installerConfigFile)
+
+	//nolint:gosec // fixed name under the backend's data root
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		_ = saveInstallerConfig(dir, cfg)
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("leer configuración: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DefaultInstallerConfig(), fmt.Errorf("configuración corrupta %s: %w", path, err)
+	}
+	if err := cfg.LogRetention.Validate(); err != nil {
+		return DefaultInstallerConfig(), fmt.Errorf("configuración %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// saveInstallerConfig atomically writes dir/config.json
+func saveInstallerConfig(dir string, cfg InstallerConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("serializar configuración: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("crear directorio de configuración: %w", err)
+	}
+	path := filepath.Join(dir, installerConfigFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("escribir configuración: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
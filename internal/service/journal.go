@@ -0,0 +1,492 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Operation Journal
+// ══════════════════════════════════════════════════════════════
+// Multi-step operations (install, upgrade) are recorded in a journal under the
+// installer's data directory. Every step is written as started before it
+// runs and as done after it succeeds, so an installer killed mid-operation
+// can finish or undo the half-done work on its next launch.
+
+// installerDataDir is the installer's own folder under the log root
+// (%PROGRAMDATA%\R2k_POS_Instalador on Windows)
+const installerDataDir = "R2k_POS_Instalador"
+
+// Journal is the persisted record of an in-flight operation
+type Journal struct {
+	Op      string        `json:"op"`
+	Variant string        `json:"variant"`
+	Started time.Time     `json:"started"`
+	Planned []string      `json:"planned"`
+	Steps   []JournalStep `json:"steps"`
+}
+
+// JournalStep records one step that was started. Failed is set when the
+// step itself returned an error, as opposed to being interrupted.
+type JournalStep struct {
+	Name   string    `json:"name"`
+	Done   bool      `json:"done"`
+	Failed bool      `json:"failed,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// complete reports whether every planned step finished
+func (j *Journal) complete() bool {
+	if len(j.Steps) != len(j.Planned) {
+		return false
+	}
+	for _, st := range j.Steps {
+		if !st.Done {
+			return false
+		}
+	}
+	return true
+}
+
+// txStep is a journaled step with its compensating action
+type txStep struct {
+	name   string // Journal key
+	label  string // Step name reported as progress
+	do     func(ctx context.Context) error
+	undo   func(ctx context.Context) error // Must tolerate a partially applied or absent step
+	commit func() error                    // Optional cleanup once the whole operation succeeded
+	atomic bool                            // A failed do changed nothing, so it is not undone
+}
+
+// Modes of the install directory and binary. The service runs under its
+// own account (LocalService, NetworkService or a systemd DynamicUser), which
+// must traverse the directory and execute the binary; only the installer
+// writes them.
+const (
+	installDirMode = 0755
+	binaryFileMode = 0755
+)
+
+// setServiceModes applies installDirMode and binaryFileMode to an installed
+// binary and its directory, which an earlier install, MkdirAll on an existing
+// directory or the umask may have left more restrictive
+func setServiceModes(binPath string) error {
+	//nolint:gosec // The service account must traverse the install directory
+	if err := os.Chmod(filepath.Dir(binPath), installDirMode); err != nil {
+		return err
+	}
+	//nolint:gosec // The service account must execute the binary
+	return os.Chmod(binPath, binaryFileMode)
+}
+
+// stateDir returns the installer's data directory
+func (m *Manager) stateDir() string {
+	return filepath.Join(m.ctrl.LogRoot(), installerDataDir)
+}
+
+// journalPath returns the journal file for this manager's variant
+func (m *Manager) journalPath() string {
+	return filepath.Join(m.stateDir(), "journal-"+m.variant.ID+".json")
+}
+
+// saveJournal atomically persists the journal
+func (m *Manager) saveJournal(j *Journal) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("serializar bitácora: %w", err)
+	}
+	if err := os.MkdirAll(m.stateDir(), 0750); err != nil {
+		return fmt.Errorf("crear directorio de bitácora: %w", err)
+	}
+	tmp := m.journalPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("escribir bitácora: %w", err)
+	}
+	return os.Rename(tmp, m.journalPath())
+}
+
+// removeJournal deletes the journal once an operation is settled
+func (m *Manager) removeJournal() error {
+	if err := os.Remove(m.journalPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("eliminar bitácora: %w", err)
+	}
+	return nil
+}
+
+// PendingJournal returns the journal of an interrupted operation on this
+// variant, or nil if there is none.
+func (m *Manager) PendingJournal() (*Journal, error) {
+	data, err := os.ReadFile(m.journalPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("leer bitácora: %w", err)
+	}
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("bitácora corrupta %s: %w", m.journalPath(), err)
+	}
+	return &j, nil
+}
+
+// RecoverJournal settles an interrupted operation. If every planned step had
+// finished the operation is completed; otherwise the started steps are
+// undone in reverse order. Returns whether the operation was completed
+// (true) or rolled back (false). It is a no-op when no journal exists.
+func (m *Manager) RecoverJournal(ctx context.Context) (completed bool, err error) {
+	defer m.invalidateStatus()
+	j, err := m.PendingJournal()
+	if err != nil || j == nil {
+		return false, err
+	}
+
+	// Only an actual recovery is worth a record; most launches find nothing
+	ctx, end := m.beginAudit(ctx, AuditRecoverJournal)
+	defer end(&err)
+
+	steps, err := m.journalSteps(j)
+	if err != nil {
+		return false, err
+	}
+
+	if j.complete() {
+		if err := commitSteps(steps); err != nil {
+			return false, err
+		}
+		return true, m.removeJournal()
+	}
+
+	if err := m.undoSteps(ctx, j, steps); err != nil {
+		return false, err
+	}
+	return false, m.removeJournal()
+}
+
+// journalSteps rebuilds the step list of a journaled operation
+func (m *Manager) journalSteps(j *Journal) ([]txStep, error) {
+	switch j.Op {
+	case "install":
+		absDir, absPath, err := m.installPaths()
+		if err != nil {
+			return nil, err
+		}
+		// The superset of install steps; only recorded ones are undone
+		return m.installSteps(absDir, absPath, nil, true), nil
+	case "upgrade":
+		_, absPath, err := m.installPaths()
+		if err != nil {
+			return nil, err
+		}
+		// The service was running before the upgrade if a restart was planned
+		return m.upgradeSteps(absPath, slices.Contains(j.Planned, "inicio")), nil
+	default:
+		return nil, fmt.Errorf("operación desconocida en bitácora: %q", j.Op)
+	}
+}
+
+// runTransaction executes steps in order, journaling each one. On failure
+// every started step is undone in reverse order and the original error is
+// returned; the journal is kept if the rollback itself fails so the next
+// launch can retry it. Cancelling ctx stops before the next step (or aborts
+// the current one) and rolls back; the rollback itself is not cancellable.
+func (m *Manager) runTransaction(ctx context.Context, op string, steps []txStep) error {
+	if j, err := m.PendingJournal(); err != nil {
+		return m.opError(op, CategoryFilesystem, err, "%v", err)
+	} else if j != nil {
+		return m.opError(op, CategoryBusy, nil,
+			"existe una operación '%s' interrumpida para %s; reinicie el instalador para recuperarla", j.Op, j.Variant)
+	}
+
+	progress := progressFrom(ctx)
+	j := &Journal{Op: op, Variant: m.variant.ID, Started: time.Now()}
+	for _, st := range steps {
+		j.Planned = append(j.Planned, st.name)
+		progress.plan(st.label)
+	}
+
+	for i, st := range steps {
+		j.Steps = append(j.Steps, JournalStep{Name: st.name, At: time.Now()})
+		if err := m.saveJournal(j); err != nil {
+			return m.opError(op, CategoryFilesystem, err, "%v", err)
+		}
+
+		err := runStep(ctx, st.label, func() error {
+			if cErr := m.cancelled(ctx, op); cErr != nil {
+				return cErr
+			}
+			return st.do(ctx)
+		})
+		if err != nil {
+			// A step killed by the cancellation reports it as such
+			if cErr := m.cancelled(ctx, op); cErr != nil {
+				err = cErr
+			}
+			// Best effort: without the mark, a retried rollback falls back to
+			// the checks in each undo
+			j.Steps[i].Failed = true
+			_ = m.saveJournal(j)
+			// Undo even when ctx is cancelled: a half-done install must not stay
+			rbErr := runStep(ctx, stepRollback, func() error {
+				return m.undoSteps(context.WithoutCancel(ctx), j, steps)
+			})
+			if rbErr != nil {
+				return m.opError(op, CategoryOf(err), err,
+					"%v — la reversión falló: %v (se reintentará al iniciar el instalador)", err, rbErr)
+			}
+			_ = m.removeJournal()
+			return m.opError(op, CategoryOf(err), err, "%v — se revirtieron todos los cambios", err)
+		}
+
+		j.Steps[i].Done = true
+		if err := m.saveJournal(j); err != nil {
+			return m.opError(op, CategoryFilesystem, err, "%v", err)
+		}
+	}
+
+	if err := commitSteps(steps); err != nil {
+		return m.opError(op, CategoryFilesystem, err, "%v", err)
+	}
+	if err := m.removeJournal(); err != nil {
+		return m.opError(op, CategoryFilesystem, err, "%v", err)
+	}
+	return nil
+}
+
+// undoSteps runs the compensating action of every step recorded in the
+// journal, last one first
+func (m *Manager) undoSteps(ctx context.Context, j *Journal, steps []txStep) error {
+	byName := make(map[string]txStep, len(steps))
+	for _, st := range steps {
+		byName[st.name] = st
+	}
+
+	var errs []error
+	for i := len(j.Steps) - 1; i >= 0; i-- {
+		st, ok := byName[j.Steps[i].Name]
+		if !ok || st.undo == nil || (st.atomic && j.Steps[i].Failed) {
+			continue
+		}
+		if err := st.undo(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", st.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// commitSteps runs the optional cleanup of every step
+func commitSteps(steps []txStep) error {
+	for _, st := range steps {
+		if st.commit == nil {
+			continue
+		}
+		if err := st.commit(); err != nil {
+			return fmt.Errorf("%s: %w", st.name, err)
+		}
+	}
+	return nil
+}
+
+// ══════════════════════════════════════════════════════════════
+// Install Steps
+// ══════════════════════════════════════════════════════════════
+
+// installSteps builds the journaled install sequence. deps are the resolved
+// dependency names. When withStart is set the service is also started and
+// must reach RUNNING.
+func (m *Manager) installSteps(absTargetDir, absTargetPath string, deps []string, withStart bool) []txStep {
+	backupPath := absTargetPath + ".bak"
+	prevDescription := "" // Description before step 4; empty for a fresh registration
+
+	steps := []txStep{
+		{
+			// 1. Create target directory (using validated absolute path)
+			name:  "directorio",
+			label: "Crear directorio",
+			do: func(ctx context.Context) error {
+				//nolint:gosec // We have validated the path; the service account must read it
+				if err := os.MkdirAll(absTargetDir, installDirMode); err != nil {
+					return m.opError("install", CategoryFilesystem, err, "crear directorio: %v", err)
+				}
+				return nil
+			},
+			undo: func(ctx context.Context) error {
+				// Only remove a directory, and only if nothing else lives in it
+				if info, err := os.Lstat(absTargetDir); err != nil || !info.IsDir() {
+					return nil
+				}
+				err := os.Remove(absTargetDir)
+				if err != nil && !os.IsNotExist(err) && !isDirNotEmpty(absTargetDir) {
+					return err
+				}
+				return nil
+			},
+		},
+		{
+			// 2. Write embedded binary to disk, keeping any leftover file aside
+			name:  "binario",
+			label: "Escribir binario",
+			do: func(ctx context.Context) error {
+				if _, err := os.Stat(absTargetPath); err == nil {
+					if err := os.Rename(absTargetPath, backupPath); err != nil {
+						return m.opError("install", CategoryFilesystem, err, "respaldar binario existente: %v", err)
+					}
+				}
+				//nolint:gosec // We have validated the path; the service account must execute it
+				if err := os.WriteFile(absTargetPath, m.variant.Binary, binaryFileMode); err != nil {
+					return m.opError("install", CategoryFilesystem, err, "extraer binario: %v", err)
+				}
+				if err := setServiceModes(absTargetPath); err != nil {
+					return m.opError("install", CategoryFilesystem, err, "permisos del binario: %v", err)
+				}
+				// Read the file back so a short or corrupted write is caught now
+				return m.verifyWritten("install", absTargetPath)
+			},
+			undo: func(ctx context.Context) error {
+				if _, err := os.Stat(backupPath); err == nil {
+					return os.Rename(backupPath, absTargetPath)
+				}
+				// Without a backup, only remove the file if it is the one we wrote
+				//nolint:gosec // We have validated the path, so this is not vulnerable to injection
+				if data, err := os.ReadFile(absTargetPath); err == nil && bytes.Equal(data, m.variant.Binary) {
+					return os.Remove(absTargetPath)
+				}
+				return nil
+			},
+			commit: func() error {
+				if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				return nil
+			},
+		},
+		{
+			// 3. Register service with the backend using the validated absolute binary path.
+			// Create either registers the service or fails without changes, so a
+			// failed attempt (e.g. someone else registered it first) is not undone.
+			name:   "registro",
+			label:  "Registrar servicio",
+			atomic: true,
+			do: func(ctx context.Context) error {
+				output, err := m.ctrl.Create(ctx, m.variant.RegistryName, absTargetPath, m.variant.DisplayName,
+					CreateOptions{StartType: m.variant.StartType, Account: m.variant.Account, Dependencies: deps})
+				if err != nil {
+					// CLAVE: el error conserva la salida de la herramienta para no volar a ciegas
+					return m.toolError("install", output, err)
+				}
+				return nil
+			},
+			undo: func(ctx context.Context) error {
+				// Only delete a registration that points at our binary; this also
+				// covers an installer killed before it knew whether Create succeeded
+				cfg, err := m.ctrl.QueryConfig(ctx, m.variant.RegistryName)
+				if err != nil || !samePath(cfg.BinaryPath, absTargetPath) {
+					return nil
+				}
+				output, err := m.ctrl.Delete(ctx, m.variant.RegistryName)
+				if err != nil {
+					svcErr := m.toolError("rollback", output, err)
+					if svcErr.Category != CategoryNotInstalled && svcErr.Category != CategoryMarkedForDeletion {
+						return svcErr
+					}
+				}
+				return nil
+			},
+		},
+		{
+			// 4. Set the description shown in services.msc
+			name:  "descripcion",
+			label: "Establecer descripción",
+			do: func(ctx context.Context) error {
+				if m.variant.Description == "" {
+					return nil
+				}
+				if cfg, err := m.ctrl.QueryConfig(ctx, m.variant.RegistryName); err == nil {
+					prevDescription = cfg.Description
+				}
+				if output, err := m.ctrl.SetDescription(ctx, m.variant.RegistryName, m.variant.Description); err != nil {
+					svcErr := m.toolError("install", output, err)
+					svcErr.Msg = "establecer descripción: " + svcErr.Error()
+					return svcErr
+				}
+				return nil
+			},
+			undo: func(ctx context.Context) error {
+				// Only touch a registration that points at our binary
+				cfg, err := m.ctrl.QueryConfig(ctx, m.variant.RegistryName)
+				if err != nil || !samePath(cfg.BinaryPath, absTargetPath) || cfg.Description == prevDescription {
+					return nil
+				}
+				if output, err := m.ctrl.SetDescription(ctx, m.variant.RegistryName, prevDescription); err != nil {
+					return m.toolError("rollback", output, err)
+				}
+				return nil
+			},
+		},
+		{
+			// 5. Configure failure recovery with the variant's policy
+			name:  "recuperacion",
+			label: stepRecovery,
+			do: func(ctx context.Context) error {
+				if output, err := m.ctrl.ConfigureFailure(ctx, m.variant.RegistryName, m.variant.Recovery); err != nil {
+					svcErr := m.toolError("install", output, err)
+					svcErr.Msg = "configurar recuperación: " + svcErr.Error()
+					return svcErr
+				}
+				return nil
+			},
+			// Removed together with the registration
+		},
+	}
+
+	if withStart {
+		steps = append(steps, txStep{
+			// 6. Start the service and wait until it is running
+			name:  "inicio",
+			label: stepStart,
+			do: func(ctx context.Context) error {
+				return m.startAndWait(ctx, "install")
+			},
+			undo: func(ctx context.Context) error {
+				return m.stopAndWait(ctx, "rollback")
+			},
+		})
+	}
+
+	return steps
+}
+
+// isDirNotEmpty reports whether dir exists and has entries
+func isDirNotEmpty(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) > 0
+}
+
+// samePath compares two binary paths as the SCM stores them (case-insensitive,
+// optionally quoted and followed by arguments)
+func samePath(a, b string) bool {
+	clean := func(p string) string {
+		return filepath.Clean(commandPath(p))
+	}
+	return strings.EqualFold(clean(a), clean(b))
+}
+
+// commandPath returns the binary of a registered command line: the quoted
+// part when the path is quoted, otherwise the whole line
+func commandPath(line string) string {
+	line = strings.TrimSpace(line)
+	rest, quoted := strings.CutPrefix(line, `"`)
+	if !quoted {
+		return line
+	}
+	path, _, _ := strings.Cut(rest, `"`)
+	return path
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// oldBinary is the content of a leftover binary found before an install
+var oldBinary = []byte("binario anterior")
+
+// writeOldBinary leaves a previous binary where the manager installs its own
+func writeOldBinary(t *testing.T, m *Manager) {
+	t.Helper()
+	path := binaryPath(t, m)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatalf("crear directorio: %v", err)
+	}
+	if err := os.WriteFile(path, oldBinary, 0600); err != nil {
+		t.Fatalf("escribir binario anterior: %v", err)
+	}
+}
+
+// assertOldBinaryRestored checks that the leftover binary is back in place
+// and no backup is left beside it
+func assertOldBinaryRestored(t *testing.T, m *Manager) {
+	t.Helper()
+	path := binaryPath(t, m)
+	if data, err := os.ReadFile(path); err != nil || string(data) != string(oldBinary) {
+		t.Errorf("binario anterior no restaurado: %q, %v", data, err)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("quedó el respaldo .bak (stat: %v)", err)
+	}
+}
+
+// assertUnregistered checks that the rollback left no registration nor journal
+func assertUnregistered(t *testing.T, m *Manager, ctrl *MemoryController) {
+	t.Helper()
+	if registered, _ := ctrl.Installed(m.variant.RegistryName); registered {
+		t.Error("el servicio quedó registrado tras la reversión")
+	}
+	if j, err := m.PendingJournal(); err != nil || j != nil {
+		t.Errorf("bitácora pendiente tras la reversión: %v, %v", j, err)
+	}
+}
+
+func TestInstallRollbackPerStep(t *testing.T) {
+	tests := []struct {
+		name      string
+		op        string // MemoryController operation that fails
+		withStart bool
+		want      ErrorCategory
+	}{
+		{"registro", "create", false, CategoryAccessDenied},
+		{"descripcion", "description", false, CategoryAccessDenied},
+		{"recuperacion", "failure", false, CategoryAccessDenied},
+		{"inicio", "start", true, CategoryLogonFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ctrl := newTestManager(t)
+			writeOldBinary(t, m)
+			code := win32AccessDenied
+			if tt.op == "start" {
+				code = win32ServiceLogonFailed
+			}
+			ctrl.FailNext(tt.op, code)
+
+			install := m.Install
+			if tt.withStart {
+				install = m.InstallAndStart
+			}
+			assertCategory(t, install(context.Background()), tt.want)
+			assertOldBinaryRestored(t, m)
+			assertUnregistered(t, m, ctrl)
+		})
+	}
+}
+
+func TestInstallRollbackDirectoryStep(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	// A file where the install directory should go makes MkdirAll fail
+	dir := filepath.Dir(binaryPath(t, m))
+	if err := os.MkdirAll(filepath.Dir(dir), 0750); err != nil {
+		t.Fatalf("crear directorio padre: %v", err)
+	}
+	if err := os.WriteFile(dir, []byte("no soy un directorio"), 0600); err != nil {
+		t.Fatalf("escribir archivo: %v", err)
+	}
+
+	assertCategory(t, m.Install(context.Background()), CategoryFilesystem)
+	if data, err := os.ReadFile(dir); err != nil || string(data) != "no soy un directorio" {
+		t.Errorf("la reversión tocó un archivo ajeno: %q, %v", data, err)
+	}
+	assertUnregistered(t, m, ctrl)
+}
+
+func TestInstallRollbackBinaryStep(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("los enlaces simbólicos requieren privilegios en Windows")
+	}
+	m, ctrl := newTestManager(t)
+	// A dangling symlink is not backed up, and writing through it fails
+	path := binaryPath(t, m)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatalf("crear directorio: %v", err)
+	}
+	target := filepath.Join(t.TempDir(), "no-existe", "binario")
+	if err := os.Symlink(target, path); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	assertCategory(t, m.Install(context.Background()), CategoryFilesystem)
+	if got, err := os.Readlink(path); err != nil || got != target {
+		t.Errorf("la reversión tocó el enlace existente: %q, %v", got, err)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("quedó un respaldo .bak (stat: %v)", err)
+	}
+	assertUnregistered(t, m, ctrl)
+}
+
+// racingController registers the service on behalf of another installer
+// right before the manager's own Create
+type racingController struct {
+	*MemoryController
+}
+
+func (c racingController) Create(ctx context.Context, regName, binPath, displayName string, opts CreateOptions) ([]byte, error) {
+	if _, err := c.MemoryController.Create(ctx, regName, binPath, displayName, opts); err != nil {
+		return nil, err
+	}
+	return c.MemoryController.Create(ctx, regName, binPath, displayName, opts)
+}
+
+func TestInstallRollbackKeepsForeignRegistration(t *testing.T) {
+	ctrl := NewMemoryController(t.TempDir())
+	m := NewManagerWithController(testVariant("test-local", "Test_Servicio_Local"), racingController{ctrl})
+
+	assertCategory(t, m.Install(context.Background()), CategoryAlreadyExists)
+	if registered, _ := ctrl.Installed(m.variant.RegistryName); !registered {
+		t.Error("la reversión eliminó un registro que no creó esta instalación")
+	}
+	if j, err := m.PendingJournal(); err != nil || j != nil {
+		t.Errorf("bitácora pendiente tras la reversión: %v, %v", j, err)
+	}
+}
+
+func TestRecoverJournalPartialInstall(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	// The install was killed while setting the description, after it had
+	// moved a leftover binary aside
+	path := binaryPath(t, m)
+	if err := os.WriteFile(path+".bak", oldBinary, 0600); err != nil {
+		t.Fatalf("escribir respaldo: %v", err)
+	}
+	now := time.Now()
+	j := &Journal{
+		Op:      "install",
+		Variant: m.variant.ID,
+		Started: now,
+		Planned: []string{"directorio", "binario", "registro", "descripcion", "recuperacion"},
+		Steps: []JournalStep{
+			{Name: "directorio", Done: true, At: now},
+			{Name: "binario", Done: true, At: now},
+			{Name: "registro", Done: true, At: now},
+			{Name: "descripcion", At: now},
+		},
+	}
+	if err := m.saveJournal(j); err != nil {
+		t.Fatalf("saveJournal: %v", err)
+	}
+
+	completed, err := m.RecoverJournal(ctx)
+	if err != nil {
+		t.Fatalf("RecoverJournal: %v", err)
+	}
+	if completed {
+		t.Error("RecoverJournal completó una instalación a medias")
+	}
+	assertOldBinaryRestored(t, m)
+	assertUnregistered(t, m, ctrl)
+}
+
+func TestRecoverJournalCompleteInstall(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	// Killed after the last step but before the backup was committed
+	path := binaryPath(t, m)
+	if err := os.WriteFile(path+".bak", oldBinary, 0600); err != nil {
+		t.Fatalf("escribir respaldo: %v", err)
+	}
+	j := &Journal{Op: "install", Variant: m.variant.ID, Started: time.Now()}
+	for _, name := range []string{"directorio", "binario", "registro", "descripcion", "recuperacion"} {
+		j.Planned = append(j.Planned, name)
+		j.Steps = append(j.Steps, JournalStep{Name: name, Done: true, At: time.Now()})
+	}
+	if err := m.saveJournal(j); err != nil {
+		t.Fatalf("saveJournal: %v", err)
+	}
+
+	completed, err := m.RecoverJournal(ctx)
+	if err != nil || !completed {
+		t.Fatalf("RecoverJournal = %v, %v; se esperaba completar", completed, err)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("el respaldo .bak no se eliminó al completar (stat: %v)", err)
+	}
+	if registered, _ := ctrl.Installed(m.variant.RegistryName); !registered {
+		t.Error("el servicio dejó de estar registrado al completar")
+	}
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Log Maintenance
+// ══════════════════════════════════════════════════════════════
+// Every variant logs to its own directory under the log root, and nothing
+// removes those directories (Uninstall leaves them for diagnosis). Log
+// maintenance keeps them bounded: rotated logs that have not been written
+// for a while are compressed into dated .gz archives, and the oldest
+// archives are deleted once a service has more than the policy allows. The
+// file the service is writing is never touched.
+
+// Step names of a log maintenance run
+const (
+	stepCompressLogs = "Comprimir logs antiguos"
+	stepPurgeLogs    = "Depurar archivos comprimidos"
+)
+
+// logArchiveExt marks the archives written by log maintenance
+const logArchiveExt = ".gz"
+
+// removeLog deletes a log once it is archived. Windows refuses while the
+// service still holds the file open; tests replace it to fail the same way.
+var removeLog = os.Remove
+
+// LogRetention is the log maintenance policy; it applies to each service
+// directory separately. A zero field disables its rule.
+type LogRetention struct {
+	CompressAfterDays int `json:"compress_after_days"` // Rotated logs not modified for this many days are compressed
+	KeepArchives      int `json:"keep_archives"`       // Newest archives kept
+	MaxArchiveMB      int `json:"max_archive_mb"`      // Size cap of the archives kept
+}
+
+// Validate rejects negative limits
+func (p LogRetention) Validate() error {
+	if p.CompressAfterDays < 0 || p.KeepArchives < 0 || p.MaxArchiveMB < 0 {
+		return fmt.Errorf("política de logs no válida: los límites no pueden ser negativos")
+	}
+	return nil
+}
+
+// Summary returns a one-line Spanish description of the policy
+func (p LogRetention) Summary() string {
+	compress := "no comprimir"
+	if p.CompressAfterDays > 0 {
+		compress = fmt.Sprintf("comprimir tras %d días", p.CompressAfterDays)
+	}
+	keep := "conservar todos los archivos"
+	if p.KeepArchives > 0 {
+		keep = fmt.Sprintf("conservar %d archivos", p.KeepArchives)
+	}
+	size := "sin límite de tamaño"
+	if p.MaxArchiveMB > 0 {
+		size = fmt.Sprintf("máximo %d MB", p.MaxArchiveMB)
+	}
+	return compress + " · " + keep + " · " + size
+}
+
+// LogUsage is the disk use of a family's log directories (both variants)
+type LogUsage struct {
+	Family       string
+	Files        int   // Plain log files, the active ones included
+	Bytes        int64 // Size of the plain log files
+	Archives     int   // Compressed archives
+	ArchiveBytes int64 // Size of the archives
+}
+
+// TotalFiles returns the number of files of every kind
+func (u LogUsage) TotalFiles() int {
+	return u.Files + u.Archives
+}
+
+// TotalBytes returns the size of every file
+func (u LogUsage) TotalBytes() int64 {
+	return u.Bytes + u.ArchiveBytes
+}
+
+// Summary returns a one-line Spanish description of the usage
+func (u LogUsage) Summary() string {
+	return fmt.Sprintf("%d logs (%s) · %d comprimidos (%s)",
+		u.Files, FormatSize(u.Bytes), u.Archives, FormatSize(u.ArchiveBytes))
+}
+
+// FormatSize renders a byte count in the largest binary unit that fits
+// ("512 B", "1.5 MB")
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for rest := n / unit; rest >= unit; rest /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+// LogMaintenance is the outcome of a maintenance run on one family
+type LogMaintenance struct {
+	Family     string
+	Compressed int      // Logs replaced by an archive
+	Purged     int      // Archives deleted
+	Before     LogUsage // Usage measured before the run
+	After      LogUsage // Usage measured after the run
+}
+
+// Freed returns the bytes released by the run
+func (r LogMaintenance) Freed() int64 {
+	return r.Before.TotalBytes() - r.After.TotalBytes()
+}
+
+// FamilyLogUsage measures the log directories of one family on the
+// platform backend
+func FamilyLogUsage(family string) (LogUsage, error) {
+	return familyLogUsage(defaultController, family, GetServiceRegistry()[family])
+}
+
+// familyLogUsage adds up the log directories of a family's variants. A
+// variant that never logged has no directory and counts as empty.
+func familyLogUsage(ctrl Controller, family string, variants []Variant) (LogUsage, error) {
+	u := LogUsage{Family: family}
+	for _, v := range variants {
+		files, err := logFiles(NewManagerWithController(v, ctrl).GetLogDir())
+		if err != nil {
+			return u, err
+		}
+		for _, f := range files {
+			if isLogArchive(f.Name()) {
+				u.Archives++
+				u.ArchiveBytes += f.Size()
+			} else {
+				u.Files++
+				u.Bytes += f.Size()
+			}
+		}
+	}
+	return u, nil
+}
+
+// MaintainLogs applies policy to the log directories of both variants of
+// family on the platform backend
+func MaintainLogs(ctx context.Context, family string, policy LogRetention) (LogMaintenance, error) {
+	return maintainLogs(ctx, defaultController, family, GetServiceRegistry()[family], policy, time.Now())
+}
+
+// maintainLogs implements MaintainLogs on the given variants of family; now
+// dates the age of the logs. A file that cannot be processed is reported and
+// skipped, so one locked log does not stop the rest.
+func maintainLogs(ctx context.Context, ctrl Controller, family string, variants []Variant, policy LogRetention, now time.Time) (result LogMaintenance, err error) {
+	ctx, end := beginAudit(ctx, ctrl, Variant{Family: family}, AuditLogMaintenance)
+	defer end(&err)
+
+	result.Family = family
+	if len(variants) == 0 {
+		return result, &Error{Op: "log-maintenance", Category: CategoryInvalid, Msg: fmt.Sprintf("familia desconocida: %q", family)}
+	}
+	if err := policy.Validate(); err != nil {
+		return result, &Error{Op: "log-maintenance", Category: CategoryInvalid, Msg: err.Error()}
+	}
+	if result.Before, err = familyLogUsage(ctrl, family, variants); err != nil {
+		return result, &Error{Op: "log-maintenance", Category: CategoryFilesystem, Msg: err.Error(), Err: err}
+	}
+
+	progressFrom(ctx).plan(stepCompressLogs, stepPurgeLogs)
+	var failures []error
+
+	err = runStep(ctx, stepCompressLogs, func() error {
+		if policy.CompressAfterDays == 0 {
+			return nil
+		}
+		cutoff := now.AddDate(0, 0, -policy.CompressAfterDays)
+		for _, v := range variants {
+			mgr := NewManagerWithController(v, ctrl)
+			n, errs := compressOldLogs(ctx, mgr.GetLogDir(), filepath.Base(mgr.GetLogPath()), cutoff)
+			result.Compressed += n
+			failures = append(failures, errs...)
+		}
+		return ctx.Err()
+	})
+	if err == nil {
+		err = runStep(ctx, stepPurgeLogs, func() error {
+			for _, v := range variants {
+				n, errs := purgeArchives(ctx, NewManagerWithController(v, ctrl).GetLogDir(), policy)
+				result.Purged += n
+				failures = append(failures, errs...)
+			}
+			return ctx.Err()
+		})
+	}
+
+	// Measured whatever happened, so the caller sees what was done
+	result.After, _ = familyLogUsage(ctrl, family, variants)
+
+	switch {
+	case err != nil:
+		return result, &Error{Op: "log-maintenance", Category: CategoryCancelled,
+			Msg: "mantenimiento de logs cancelado", Err: err}
+	case len(failures) > 0:
+		return result, &Error{Op: "log-maintenance", Category: CategoryFilesystem,
+			Msg: fmt.Sprintf("%d archivo(s) de log no se pudieron procesar", len(failures)), Err: errors.Join(failures...)}
+	}
+	return result, nil
+}
+
+// compressOldLogs replaces every rotated copy of the active log in dir last
+// modified before cutoff with a gzip archive. The active log and any other
+// file in the directory are left alone. Returns how many were compressed and
+// the files that failed.
+func compressOldLogs(ctx context.Context, dir, active string, cutoff time.Time) (int, []error) {
+	files, err := logFiles(dir)
+	if err != nil {
+		return 0, []error{err}
+	}
+
+	compressed := 0
+	var errs []error
+	for _, f := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		if !isRotatedLog(f.Name(), active) || !f.ModTime().Before(cutoff) {
+			continue
+		}
+		progressFrom(ctx).detail("comprimiendo '%s'", f.Name())
+		if err := compressLog(dir, f); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		compressed++
+	}
+	return compressed, errs
+}
+
+// compressLog writes name.YYYY-MM-DD.gz (dated by the log's last write,
+// which the archive keeps as its own modification time) and removes the
+// log. The log is kept if anything fails.
+func compressLog(dir string, f os.FileInfo) error {
+	src := filepath.Join(dir, f.Name())
+	dst := archiveName(dir, f.Name(), f.ModTime())
+	tmp := dst + ".tmp"
+
+	if err := gzipFile(src, tmp, f); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("comprimir %s: %w", f.Name(), err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("comprimir %s: %w", f.Name(), err)
+	}
+	_ = os.Chtimes(dst, f.ModTime(), f.ModTime())
+
+	if err := removeLog(src); err != nil {
+		// Still held open (a log the service has not released): drop the
+		// archive so the next run does not compress the file twice
+		_ = os.Remove(dst)
+		return fmt.Errorf("eliminar %s tras comprimirlo: %w", f.Name(), err)
+	}
+	return nil
+}
+
+// gzipFile compresses src into dst
+func gzipFile(src, dst string, f os.FileInfo) error {
+	//nolint:gosec // src is a file listed from the service's log directory
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	//nolint:gosec // dst is built from the log directory and the source name
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	gz, err := gzip.NewWriterLevel(out, gzip.BestCompression)
+	if err != nil {
+		_ = out.Close()
+		return err
+	}
+	gz.Name = f.Name()
+	gz.ModTime = f.ModTime()
+
+	if _, err := io.Copy(gz, in); err != nil {
+		_ = gz.Close()
+		_ = out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// archiveName returns a free archive path for name last written at mod
+func archiveName(dir, name string, mod time.Time) string {
+	base := filepath.Join(dir, fmt.Sprintf("%s.%s", name, mod.Format("2006-01-02")))
+	path := base + logArchiveExt
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s.%d%s", base, i, logArchiveExt)
+	}
+}
+
+// purgeArchives deletes the archives in dir beyond the policy's count and
+// size limits, oldest first. Returns how many were deleted and the files
+// that failed.
+func purgeArchives(ctx context.Context, dir string, policy LogRetention) (int, []error) {
+	if policy.KeepArchives == 0 && policy.MaxArchiveMB == 0 {
+		return 0, nil
+	}
+	files, err := logFiles(dir)
+	if err != nil {
+		return 0, []error{err}
+	}
+
+	var archives []os.FileInfo
+	for _, f := range files {
+		if isLogArchive(f.Name()) {
+			archives = append(archives, f)
+		}
+	}
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].ModTime().After(archives[j].ModTime())
+	})
+
+	maxBytes := int64(policy.MaxArchiveMB) << 20
+	var kept int64
+	purged := 0
+	var errs []error
+	for i, f := range archives {
+		if ctx.Err() != nil {
+			break
+		}
+		withinCount := policy.KeepArchives == 0 || i < policy.KeepArchives
+		withinSize := maxBytes == 0 || kept+f.Size() <= maxBytes
+		if withinCount && withinSize {
+			kept += f.Size()
+			continue
+		}
+		progressFrom(ctx).detail("eliminando '%s'", f.Name())
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil {
+			errs = append(errs, fmt.Errorf("eliminar %s: %w", f.Name(), err))
+			continue
+		}
+		purged++
+	}
+	return purged, errs
+}
+
+// logFiles lists the regular files of a log directory; a missing directory
+// has none
+func logFiles(dir string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("leer %s: %w", dir, err)
+	}
+
+	var files []os.FileInfo
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasSuffix(e.Name(), ".tmp") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // Deleted since the listing
+		}
+		files = append(files, info)
+	}
+	return files, nil
+}
+
+// isRotatedLog reports whether name is a rotated copy of the active log:
+// the active name with a suffix (R.log.1, R.log.2026-01-02) or its stem with
+// one before the extension (R-2026-01-02.log, R.1.log)
+func isRotatedLog(name, active string) bool {
+	name, active = strings.ToLower(name), strings.ToLower(active)
+	if name == active || isLogArchive(name) {
+		return false
+	}
+	if strings.HasPrefix(name, active+".") {
+		return true
+	}
+	ext := filepath.Ext(active)
+	rest, ok := strings.CutPrefix(name, strings.TrimSuffix(active, ext))
+	return ok && len(rest) > len(ext) && strings.ContainsAny(rest[:1], ".-_") && strings.HasSuffix(rest, ext)
+}
+
+// isLogArchive reports whether name is a compressed log
+func isLogArchive(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), logArchiveExt)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLog creates dir/name with size bytes, last modified at mod
+func writeLog(t *testing.T, dir, name string, size int, mod time.Time) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		t.Fatalf("crear directorio: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0600); err != nil {
+		t.Fatalf("escribir %s: %v", name, err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatalf("Chtimes %s: %v", name, err)
+	}
+	return path
+}
+
+// dirNames lists the files left in dir, sorted
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestMaintainLogsCompressesRotatedLogsOnly(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	dir := m.GetLogDir()
+	active := filepath.Base(m.GetLogPath())
+	stem := strings.TrimSuffix(active, ".log")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	old := now.AddDate(0, 0, -30)
+
+	activePath := writeLog(t, dir, active, 100, old)
+	writeLog(t, dir, active+".1", 100, old)
+	writeLog(t, dir, stem+"-2026-01-15.log", 100, old)
+	writeLog(t, dir, active+".2", 100, now.AddDate(0, 0, -1)) // Too recent
+	writeLog(t, dir, "notas.txt", 100, old)                   // Not a log of the daemon
+	writeLog(t, dir, "otro.log", 100, old)
+
+	res, err := maintainLogs(context.Background(), ctrl, "test", []Variant{m.variant},
+		LogRetention{CompressAfterDays: 7}, now)
+	if err != nil {
+		t.Fatalf("maintainLogs: %v", err)
+	}
+	if res.Compressed != 2 {
+		t.Errorf("Compressed = %d, se esperaban 2", res.Compressed)
+	}
+
+	archive := active + ".1." + old.Format("2006-01-02") + logArchiveExt
+	want := []string{
+		active, active + ".2", archive,
+		stem + "-2026-01-15.log." + old.Format("2006-01-02") + logArchiveExt,
+		"notas.txt", "otro.log",
+	}
+	slices.Sort(want)
+	if got := dirNames(t, dir); !slices.Equal(got, want) {
+		t.Errorf("archivos = %v, se esperaba %v", got, want)
+	}
+	if info, err := os.Stat(activePath); err != nil || info.Size() != 100 || !info.ModTime().Equal(old) {
+		t.Errorf("el log activo cambió: %v, %v", info, err)
+	}
+
+	// The archive holds the original content
+	f, err := os.Open(filepath.Join(dir, archive))
+	if err != nil {
+		t.Fatalf("abrir archivo comprimido: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	if data, err := io.ReadAll(gz); err != nil || string(data) != strings.Repeat("x", 100) {
+		t.Errorf("contenido del archivo comprimido = %d bytes, %v", len(data), err)
+	}
+
+	_, err = maintainLogs(context.Background(), ctrl, "desconocida", nil, LogRetention{}, now)
+	assertCategory(t, err, CategoryInvalid)
+}
+
+func TestPurgeArchivesLimits(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		name   string
+		policy LogRetention
+		size   int
+		want   int // Newest archives left
+	}{
+		{"solo cantidad", LogRetention{KeepArchives: 2}, 1024, 2},
+		{"solo tamaño", LogRetention{MaxArchiveMB: 1}, 600 << 10, 1},
+		{"sin límites", LogRetention{}, 1024, 4},
+		{"ambos", LogRetention{KeepArchives: 3, MaxArchiveMB: 1}, 300 << 10, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var names []string // Newest first
+			for i := range 4 {
+				name := "R.log." + now.AddDate(0, 0, -i).Format("2006-01-02") + logArchiveExt
+				writeLog(t, dir, name, tt.size, now.AddDate(0, 0, -i))
+				names = append(names, name)
+			}
+
+			purged, errs := purgeArchives(context.Background(), dir, tt.policy)
+			if len(errs) > 0 {
+				t.Fatalf("purgeArchives: %v", errs)
+			}
+			if purged != 4-tt.want {
+				t.Errorf("purged = %d, se esperaban %d", purged, 4-tt.want)
+			}
+			want := slices.Clone(names[:tt.want])
+			slices.Sort(want)
+			if got := dirNames(t, dir); !slices.Equal(got, want) {
+				t.Errorf("archivos = %v, se esperaba %v", got, want)
+			}
+		})
+	}
+}
+
+func TestArchiveNameNoCollision(t *testing.T) {
+	dir := t.TempDir()
+	mod := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	seen := make(map[string]bool)
+	for range 3 {
+		path := archiveName(dir, "R.log.1", mod)
+		if seen[path] {
+			t.Fatalf("archiveName repitió %s", path)
+		}
+		seen[path] = true
+		writeLog(t, dir, filepath.Base(path), 1, mod)
+	}
+	for _, name := range []string{"R.log.1.2026-01-02.gz", "R.log.1.2026-01-02.2.gz", "R.log.1.2026-01-02.3.gz"} {
+		if !seen[filepath.Join(dir, name)] {
+			t.Errorf("no se generó %s", name)
+		}
+	}
+}
+
+func TestCompressLogKeepsSourceWhenDeleteFails(t *testing.T) {
+	removeLog = func(string) error { return errors.New("el archivo está en uso") }
+	t.Cleanup(func() { removeLog = os.Remove })
+
+	dir := t.TempDir()
+	mod := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	path := writeLog(t, dir, "R.log.1", 100, mod)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+
+	if err := compressLog(dir, info); err == nil {
+		t.Fatal("compressLog no reportó el fallo al eliminar el log")
+	}
+	// Only the source is left: no archive to compress twice, no temporary file
+	if got := dirNames(t, dir); !slices.Equal(got, []string{"R.log.1"}) {
+		t.Errorf("archivos = %v, se esperaba solo R.log.1", got)
+	}
+}
+
+func TestIsRotatedLog(t *testing.T) {
+	tests := map[string]bool{
+		"R.log":            false,
+		"R.log.1":          true,
+		"r.LOG.2026-01-02": true,
+		"R-2026-01-02.log": true,
+		"R.1.log":          true,
+		"R.log.1.2026.gz":  false,
+		"RX.log":           false,
+		"notas.txt":        false,
+		"otro.log":         false,
+	}
+	for name, want := range tests {
+		if got := isRotatedLog(name, "R.log"); got != want {
+			t.Errorf("isRotatedLog(%q) = %v, se esperaba %v", name, got, want)
+		}
+	}
+}
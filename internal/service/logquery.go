@@ -0,0 +1,242 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Log Records and Queries
+// ══════════════════════════════════════════════════════════════
+// The daemons write with the standard log or log/slog packages, so a record
+// starts with one of
+//
+//	2026/01/02 15:04:05 [ERROR] impresora fuera de línea
+//	time=2026-01-02T15:04:05.000-06:00 level=ERROR msg="impresora fuera de línea"
+//	{"time":"2026-01-02T15:04:05-06:00","level":"ERROR","msg":"impresora fuera de línea"}
+//
+// Lines that start with neither a timestamp nor a level (stack traces,
+// multi-line payloads) continue the previous record.
+
+// LogLevel is the severity of a log record
+type LogLevel int
+
+const (
+	// LevelDebug is diagnostic output
+	LevelDebug LogLevel = iota
+	// LevelInfo is normal operation; records without a level are INFO
+	LevelInfo
+	// LevelWarn is a recoverable problem
+	LevelWarn
+	// LevelError is a failure
+	LevelError
+)
+
+// LogLevels lists the levels from least to most severe
+var LogLevels = []LogLevel{LevelDebug, LevelInfo, LevelWarn, LevelError}
+
+// String returns the level name as it appears in the logs
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return "INFO"
+	}
+}
+
+// LogRecord is one logical entry: a line and its continuation lines
+type LogRecord struct {
+	Line    int       // Index of the record's first line in the parsed input
+	Time    time.Time // Zero when the line carries no recognizable timestamp
+	Level   LogLevel
+	Message string   // Text of the first line after the timestamp and level
+	Lines   []string // Raw lines, continuations included
+}
+
+// Text returns the raw lines of the record joined by newlines
+func (r LogRecord) Text() string {
+	return strings.Join(r.Lines, "\n")
+}
+
+var (
+	// Leading timestamp: 2026/01/02 15:04:05, 2026-01-02T15:04:05.123Z, …
+	logTimeRe = regexp.MustCompile(`^(\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\s*`)
+	// Level token: [ERROR], ERROR:, WARN, …
+	logLevelRe = regexp.MustCompile(`(?i)^\[?(error|err|fatal|panic|warning|warn|info|debug|trace)\]?:?(?:\s+|$)`)
+	// log/slog text handler: time=… level=… msg=…
+	logSlogRe = regexp.MustCompile(`^time=(\S+)\s+level=(\S+)\s+msg=(.*)$`)
+)
+
+// logTimeLayouts are tried in order on a normalized timestamp
+var logTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// ParseLogLines groups log lines into records
+func ParseLogLines(lines []string) []LogRecord {
+	var records []LogRecord
+	for i, line := range lines {
+		rec, ok := parseLogLine(line)
+		if !ok && len(records) > 0 {
+			last := &records[len(records)-1]
+			last.Lines = append(last.Lines, line)
+			continue
+		}
+		rec.Line = i
+		rec.Lines = []string{line}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// parseLogLine parses the head of a record. ok is false for a line that
+// carries neither a timestamp nor a level (a continuation line).
+func parseLogLine(line string) (rec LogRecord, ok bool) {
+	rec.Level = LevelInfo
+
+	if strings.HasPrefix(line, "{") {
+		var entry struct {
+			Time  string `json:"time"`
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}
+		if json.Unmarshal([]byte(line), &entry) == nil && (entry.Time != "" || entry.Level != "") {
+			rec.Time = parseLogTime(entry.Time)
+			rec.Level = parseLogLevel(entry.Level)
+			rec.Message = entry.Msg
+			return rec, true
+		}
+	}
+
+	if m := logSlogRe.FindStringSubmatch(line); m != nil {
+		rec.Time = parseLogTime(m[1])
+		rec.Level = parseLogLevel(m[2])
+		rec.Message = m[3]
+		return rec, true
+	}
+
+	rest := line
+	if m := logTimeRe.FindStringSubmatch(rest); m != nil {
+		rec.Time = parseLogTime(m[1])
+		rest = rest[len(m[0]):]
+		ok = true
+	}
+	if m := logLevelRe.FindStringSubmatch(rest); m != nil {
+		rec.Level = parseLogLevel(m[1])
+		rest = rest[len(m[0]):]
+		ok = true
+	}
+	rec.Message = rest
+	return rec, ok
+}
+
+// parseLogTime parses a timestamp in any of the supported shapes; a
+// timestamp without zone is local time. Returns the zero time on failure.
+func parseLogTime(s string) time.Time {
+	s = strings.NewReplacer("/", "-", "T", " ", ",", ".").Replace(s)
+	for _, layout := range logTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseLogLevel maps the level spellings of the supported formats
+func parseLogLevel(s string) LogLevel {
+	switch strings.ToUpper(s) {
+	case "ERROR", "ERR", "FATAL", "PANIC":
+		return LevelError
+	case "WARN", "WARNING":
+		return LevelWarn
+	case "DEBUG", "TRACE":
+		return LevelDebug
+	default:
+		return LevelInfo
+	}
+}
+
+// ── Queries ──
+
+// LogQuery selects log records. The zero value matches every record.
+type LogQuery struct {
+	Pattern  *regexp.Regexp // Searched in every line of a record; nil matches all
+	MinLevel LogLevel       // Least severe level shown
+	From, To time.Time      // Time range; a zero bound is open. Records without a timestamp always pass.
+}
+
+// CompileLogQuery builds a query from a case-insensitive regular expression
+// (empty for none) and the level and time filters
+func CompileLogQuery(pattern string, minLevel LogLevel, from, to time.Time) (LogQuery, error) {
+	q := LogQuery{MinLevel: minLevel, From: from, To: to}
+	if pattern == "" {
+		return q, nil
+	}
+	// Validated without the flag so the error quotes the pattern as typed
+	if _, err := regexp.Compile(pattern); err != nil {
+		return q, fmt.Errorf("expresión de búsqueda no válida: %w", err)
+	}
+	q.Pattern = regexp.MustCompile("(?i)" + pattern)
+	return q, nil
+}
+
+// Selects reports whether r passes the level and time filters
+func (q LogQuery) Selects(r LogRecord) bool {
+	if r.Level < q.MinLevel {
+		return false
+	}
+	if !r.Time.IsZero() {
+		if !q.From.IsZero() && r.Time.Before(q.From) {
+			return false
+		}
+		if !q.To.IsZero() && r.Time.After(q.To) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether r passes the filters and contains the pattern
+func (q LogQuery) Match(r LogRecord) bool {
+	if !q.Selects(r) {
+		return false
+	}
+	if q.Pattern == nil {
+		return true
+	}
+	for _, line := range r.Lines {
+		if q.Pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the records that match q
+func (q LogQuery) Filter(records []LogRecord) []LogRecord {
+	var out []LogRecord
+	for _, r := range records {
+		if q.Match(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Highlights returns the [start, end) byte ranges of the pattern in line
+func (q LogQuery) Highlights(line string) [][]int {
+	if q.Pattern == nil {
+		return nil
+	}
+	return q.Pattern.FindAllStringIndex(line, -1)
+}
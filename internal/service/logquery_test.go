@@ -0,0 +1,183 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLogLine(t *testing.T) {
+	utc6 := time.FixedZone("", -6*60*60)
+	tests := []struct {
+		name  string
+		line  string
+		ok    bool
+		time  time.Time
+		level LogLevel
+		msg   string
+	}{
+		{"log estándar", "2026/01/02 15:04:05 [ERROR] impresora fuera de línea", true,
+			time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local), LevelError, "impresora fuera de línea"},
+		{"slog texto", `time=2026-01-02T15:04:05.000-06:00 level=WARN msg="papel bajo"`, true,
+			time.Date(2026, 1, 2, 15, 4, 5, 0, utc6), LevelWarn, `"papel bajo"`},
+		{"slog JSON", `{"time":"2026-01-02T15:04:05-06:00","level":"ERROR","msg":"sin conexión"}`, true,
+			time.Date(2026, 1, 2, 15, 4, 5, 0, utc6), LevelError, "sin conexión"},
+		{"zona Z con milisegundos", "2026-01-02T15:04:05.123Z INFO listo", true,
+			time.Date(2026, 1, 2, 15, 4, 5, 123e6, time.UTC), LevelInfo, "listo"},
+		{"zona sin dos puntos", "2026-01-02 15:04:05+0100 DEBUG puerto abierto", true,
+			time.Date(2026, 1, 2, 15, 4, 5, 0, time.FixedZone("", 60*60)), LevelDebug, "puerto abierto"},
+		{"fracción con coma, sin zona", "2026-01-02 15:04:05,250 err: fallo", true,
+			time.Date(2026, 1, 2, 15, 4, 5, 250e6, time.Local), LevelError, "fallo"},
+		{"solo nivel", "WARNING: reintentando", true, time.Time{}, LevelWarn, "reintentando"},
+		{"sin nivel", "2026/01/02 15:04:05 iniciado", true,
+			time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local), LevelInfo, "iniciado"},
+		{"continuación", "\tmain.main()", false, time.Time{}, LevelInfo, "\tmain.main()"},
+		{"JSON sin tiempo ni nivel", `{"msg":"carga"}`, false, time.Time{}, LevelInfo, `{"msg":"carga"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, ok := parseLogLine(tt.line)
+			if ok != tt.ok {
+				t.Errorf("ok = %v, se esperaba %v", ok, tt.ok)
+			}
+			if !rec.Time.Equal(tt.time) {
+				t.Errorf("Time = %v, se esperaba %v", rec.Time, tt.time)
+			}
+			if rec.Level != tt.level {
+				t.Errorf("Level = %v, se esperaba %v", rec.Level, tt.level)
+			}
+			if rec.Message != tt.msg {
+				t.Errorf("Message = %q, se esperaba %q", rec.Message, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	tests := map[string]LogLevel{
+		"ERROR":   LevelError,
+		"err":     LevelError,
+		"Fatal":   LevelError,
+		"panic":   LevelError,
+		"WARN":    LevelWarn,
+		"warning": LevelWarn,
+		"DEBUG":   LevelDebug,
+		"trace":   LevelDebug,
+		"info":    LevelInfo,
+		"":        LevelInfo,
+		"AVISO":   LevelInfo,
+	}
+	for in, want := range tests {
+		if got := parseLogLevel(in); got != want {
+			t.Errorf("parseLogLevel(%q) = %v, se esperaba %v", in, got, want)
+		}
+	}
+}
+
+func TestParseLogLines(t *testing.T) {
+	lines := []string{
+		"sin marca de tiempo al inicio",
+		"2026/01/02 15:04:05 [ERROR] pánico",
+		"goroutine 1 [running]:",
+		"\tmain.main()",
+		"2026/01/02 15:04:06 INFO reanudado",
+	}
+	records := ParseLogLines(lines)
+
+	want := []struct {
+		line  int
+		level LogLevel
+		lines []string
+	}{
+		// A leading line without timestamp opens a record of its own
+		{0, LevelInfo, lines[0:1]},
+		// The stack trace belongs to the record above it
+		{1, LevelError, lines[1:4]},
+		{4, LevelInfo, lines[4:5]},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("%d registros, se esperaban %d: %+v", len(records), len(want), records)
+	}
+	for i, w := range want {
+		r := records[i]
+		if r.Line != w.line || r.Level != w.level || !reflect.DeepEqual(r.Lines, w.lines) {
+			t.Errorf("registro %d = {Line:%d Level:%v Lines:%q}, se esperaba {Line:%d Level:%v Lines:%q}",
+				i, r.Line, r.Level, r.Lines, w.line, w.level, w.lines)
+		}
+	}
+	if !records[0].Time.IsZero() {
+		t.Errorf("registro 0: Time = %v, se esperaba sin marca de tiempo", records[0].Time)
+	}
+}
+
+func TestLogQueryMatch(t *testing.T) {
+	records := ParseLogLines([]string{
+		"2026/01/02 15:00:00 [INFO] báscula conectada",
+		"2026/01/02 15:10:00 [ERROR] Impresora fuera de línea",
+		"\tcausa: papel atascado",
+		"2026/01/02 15:20:00 [WARN] reintento",
+		"[DEBUG] sin marca de tiempo",
+	})
+	at := func(minute int) time.Time { return time.Date(2026, 1, 2, 15, minute, 0, 0, time.Local) }
+
+	tests := []struct {
+		name     string
+		pattern  string
+		minLevel LogLevel
+		from, to time.Time
+		want     []int // Line of each matching record
+	}{
+		{"consulta vacía", "", LevelDebug, time.Time{}, time.Time{}, []int{0, 1, 3, 4}},
+		{"nivel mínimo", "", LevelWarn, time.Time{}, time.Time{}, []int{1, 3}},
+		{"sin distinguir mayúsculas", "impresora", LevelDebug, time.Time{}, time.Time{}, []int{1}},
+		{"línea de continuación", "atascado", LevelDebug, time.Time{}, time.Time{}, []int{1}},
+		// Records without a timestamp always pass the time range
+		{"rango de tiempo", "", LevelDebug, at(5), at(15), []int{1, 4}},
+		{"desde", "", LevelDebug, at(10), time.Time{}, []int{1, 3, 4}},
+		{"hasta", "", LevelDebug, time.Time{}, at(10), []int{0, 1, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := CompileLogQuery(tt.pattern, tt.minLevel, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("CompileLogQuery: %v", err)
+			}
+			var got []int
+			for _, r := range q.Filter(records) {
+				got = append(got, r.Line)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("registros = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileLogQueryInvalid(t *testing.T) {
+	if _, err := CompileLogQuery("([", LevelDebug, time.Time{}, time.Time{}); err == nil {
+		t.Error("CompileLogQuery aceptó una expresión no válida")
+	}
+}
+
+func TestLogQueryHighlights(t *testing.T) {
+	tests := []struct {
+		pattern string
+		line    string
+		want    [][]int
+	}{
+		{"impresora", "la Impresora y la impresora", [][]int{{3, 12}, {18, 27}}},
+		// Ranges are in bytes: "í" takes two
+		{"nea", "línea", [][]int{{3, 6}}},
+		{"báscula", "sin coincidencias", nil},
+		{"", "sin patrón", nil},
+	}
+	for _, tt := range tests {
+		q, err := CompileLogQuery(tt.pattern, LevelDebug, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("CompileLogQuery(%q): %v", tt.pattern, err)
+		}
+		if got := q.Highlights(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Highlights(%q, %q) = %v, se esperaba %v", tt.pattern, tt.line, got, tt.want)
+		}
+	}
+}
@@ -13,19 +13,20 @@ import (
 // ══════════════════════════════════════════════════════════════
 
 // GetLogPath returns the full path to the service's log file.
-// Pattern: %PROGRAMDATA%\{RegistryName}\{RegistryName}.log
+// Pattern: {LogRoot}\{RegistryName}\{RegistryName}.log
+// (%PROGRAMDATA% on Windows, /var/log on systemd hosts)
 func (m *Manager) GetLogPath() string {
 	return filepath.Join(
-		os.Getenv("PROGRAMDATA"),
+		m.ctrl.LogRoot(),
 		m.variant.RegistryName,
 		m.variant.RegistryName+".log",
 	)
 }
 
 // GetLogDir returns the directory containing log files.
-// Pattern: %PROGRAMDATA%\{RegistryName}
+// Pattern: {LogRoot}\{RegistryName}
 func (m *Manager) GetLogDir() string {
-	return filepath.Join(os.Getenv("PROGRAMDATA"), m.variant.RegistryName)
+	return filepath.Join(m.ctrl.LogRoot(), m.variant.RegistryName)
 }
 
 func secureLaunch(exe, target, allowedBase string) error {
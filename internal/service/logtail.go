@@ -0,0 +1,147 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Log Tail
+// ══════════════════════════════════════════════════════════════
+// A LogTail reads the end of a service log and then follows it as the daemon
+// appends. Only the last logTailBytes of a large file are loaded. A file that
+// shrinks (truncated in place) or is replaced (rotated to a backup and
+// recreated) is read again from its start.
+
+// logTailBytes bounds how much of an existing log is loaded when opened
+const logTailBytes = 256 << 10
+
+// LogTail follows one log file
+type LogTail struct {
+	path    string
+	info    os.FileInfo // Identity of the file being followed, for os.SameFile
+	offset  int64       // Bytes consumed so far
+	partial []byte      // Last line read without its newline yet
+}
+
+// LogUpdate is the outcome of reading a log
+type LogUpdate struct {
+	Lines     []string // Complete lines read
+	Reset     bool     // The file was truncated or rotated; Lines replace what was shown
+	Truncated bool     // The start of the file was skipped (only the tail was loaded)
+}
+
+// OpenLogTail opens the service's log file and returns its last lines
+func (m *Manager) OpenLogTail() (*LogTail, LogUpdate, error) {
+	t := &LogTail{path: m.GetLogPath()}
+	update, err := t.load()
+	if err != nil {
+		return nil, LogUpdate{}, err
+	}
+	return t, update, nil
+}
+
+// Path returns the file being followed
+func (t *LogTail) Path() string {
+	return t.path
+}
+
+// Poll returns the lines appended since the last read. A log that is
+// missing for a moment (mid-rotation) reports nothing until it reappears.
+func (t *LogTail) Poll() (LogUpdate, error) {
+	info, err := os.Stat(t.path)
+	if os.IsNotExist(err) {
+		return LogUpdate{}, nil
+	}
+	if err != nil {
+		return LogUpdate{}, fmt.Errorf("consultar log: %w", err)
+	}
+
+	switch {
+	case t.info != nil && !os.SameFile(t.info, info), info.Size() < t.offset:
+		// Rotated or truncated: start over from the new content
+		update, err := t.load()
+		update.Reset = true
+		return update, err
+	case info.Size() == t.offset:
+		return LogUpdate{}, nil
+	}
+
+	data, err := t.readFrom(t.offset, info.Size())
+	if err != nil {
+		return LogUpdate{}, err
+	}
+	t.info = info
+	return LogUpdate{Lines: t.split(data)}, nil
+}
+
+// load reads the tail of the file from scratch
+func (t *LogTail) load() (LogUpdate, error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return LogUpdate{}, fmt.Errorf("abrir log: %w", err)
+	}
+
+	start := max(info.Size()-logTailBytes, 0)
+	data, err := t.readFrom(start, info.Size())
+	if err != nil {
+		return LogUpdate{}, err
+	}
+	t.info = info
+	t.partial = nil
+
+	update := LogUpdate{Truncated: start > 0}
+	if start > 0 {
+		// The first line was cut by the tail boundary
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		} else {
+			data = nil
+		}
+	}
+	update.Lines = t.split(data)
+	return update, nil
+}
+
+// readFrom reads [from, to) and advances the offset past it
+func (t *LogTail) readFrom(from, to int64) ([]byte, error) {
+	//nolint:gosec // path comes from GetLogPath (log root + registry name)
+	f, err := os.Open(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("abrir log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	data := make([]byte, to-from)
+	n, err := f.ReadAt(data, from)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("leer log: %w", err)
+	}
+	t.offset = from + int64(n)
+	return data[:n], nil
+}
+
+// split turns data into complete lines, carrying an unfinished last line
+// over to the next read
+func (t *LogTail) split(data []byte) []string {
+	if len(t.partial) > 0 {
+		data = append(t.partial, data...)
+		t.partial = nil
+	}
+	var lines []string
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, strings.TrimRight(string(data[:i]), "\r"))
+		data = data[i+1:]
+	}
+	if len(data) > 0 {
+		t.partial = append([]byte(nil), data...)
+	}
+	return lines
+}
@@ -0,0 +1,472 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ══════════════════════════════════════════════════════════════
+// In-Memory Backend
+// ══════════════════════════════════════════════════════════════
+
+// memoryService is the emulated SCM record for one service
+type memoryService struct {
+	binPath      string
+	displayName  string
+	description  string
+	startType    StartType
+	account      string
+	dependencies []string
+	state        Status
+	pid          int
+	exitCode     int             // WIN32_EXIT_CODE reported once stopped
+	pending      int             // Remaining queries before a pending state settles
+	hung         bool            // Stop requests never complete until Kill
+	markedDelete bool            // Deleted while running; removed once stopped
+	recovery     *RecoveryPolicy // Policy applied by ConfigureFailure, nil if none
+}
+
+// MemoryController is an in-memory Controller that emulates the SCM state
+// machine (STOPPED → START_PENDING → RUNNING → STOP_PENDING → STOPPED).
+// It reports failures with the same Win32 codes as sc.exe, which makes it
+// suitable for exercising Manager flows on any platform.
+type MemoryController struct {
+	root     string
+	mu       sync.Mutex
+	services map[string]*memoryService
+	failNext map[string]int
+	noLogon  map[string]bool // Accounts without the "log on as a service" right
+	nextPID  int
+
+	// PendingQueries is the number of Query calls a service remains in
+	// START_PENDING or STOP_PENDING before reaching its target state.
+	PendingQueries int
+}
+
+// NewMemoryController creates an empty in-memory backend. Binaries and logs
+// are placed under root, which is typically a test's temporary directory.
+func NewMemoryController(root string) *MemoryController {
+	return &MemoryController{
+		root:           root,
+		services:       make(map[string]*memoryService),
+		failNext:       make(map[string]int),
+		noLogon:        make(map[string]bool),
+		nextPID:        1000,
+		PendingQueries: 1,
+	}
+}
+
+// FailNext makes the next call to op ("create", "delete", "start", "stop",
+// "config", "description", "failure", "kill") fail with the given Win32 code.
+func (c *MemoryController) FailNext(op string, code int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failNext[op] = code
+}
+
+// DenyServiceLogon makes services running as account fail to start with
+// ERROR_SERVICE_LOGON_FAILED, as when it lacks "log on as a service"
+func (c *MemoryController) DenyServiceLogon(account string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.noLogon[strings.ToLower(account)] = true
+}
+
+// Hang makes Stop requests for regName stay in STOP_PENDING until Kill is called
+func (c *MemoryController) Hang(regName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if svc, ok := c.services[regName]; ok {
+		svc.hung = true
+	}
+}
+
+// Installed reports whether regName is registered and whether its failure
+// recovery policy has been configured.
+func (c *MemoryController) Installed(regName string) (registered, failureConfigured bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	svc, ok := c.services[regName]
+	if !ok {
+		return false, false
+	}
+	return true, svc.recovery != nil
+}
+
+// Create registers a new service in the STOPPED state
+func (c *MemoryController) Create(_ context.Context, regName, binPath, displayName string, opts CreateOptions) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if out, err := c.injected("create", "CreateService"); err != nil {
+		return out, err
+	}
+	if svc, ok := c.services[regName]; ok {
+		if svc.markedDelete {
+			return scFailure("CreateService", win32ServiceMarkedDelete)
+		}
+		return scFailure("CreateService", win32ServiceExists)
+	}
+
+	c.services[regName] = &memoryService{
+		binPath:      binPath,
+		displayName:  displayName,
+		startType:    opts.StartType,
+		account:      accountOrDefault(opts.Account),
+		dependencies: append([]string(nil), opts.Dependencies...),
+		state:        StatusStopped,
+	}
+	return []byte("[SC] CreateService SUCCESS"), nil
+}
+
+// Delete removes a stopped service, or marks a running one for deletion
+func (c *MemoryController) Delete(_ context.Context, regName string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if out, err := c.injected("delete", "DeleteService"); err != nil {
+		return out, err
+	}
+	svc, ok := c.services[regName]
+	if !ok {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+	if svc.markedDelete {
+		return scFailure("DeleteService", win32ServiceMarkedDelete)
+	}
+
+	if svc.state == StatusStopped {
+		delete(c.services, regName)
+	} else {
+		svc.markedDelete = true
+	}
+	return []byte("[SC] DeleteService SUCCESS"), nil
+}
+
+// Start moves a stopped service into START_PENDING
+func (c *MemoryController) Start(_ context.Context, regName string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if out, err := c.injected("start", "StartService"); err != nil {
+		return out, err
+	}
+	svc, ok := c.services[regName]
+	if !ok {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+	switch svc.state {
+	case StatusRunning, StatusStartPending:
+		return scFailure("StartService", win32ServiceAlreadyRunning)
+	case StatusStopPending:
+		return scFailure("StartService", win32ServiceCannotAccept)
+	}
+	if svc.markedDelete {
+		return scFailure("StartService", win32ServiceMarkedDelete)
+	}
+	if svc.startType == StartDisabled {
+		return scFailure("StartService", win32ServiceDisabled)
+	}
+	if c.noLogon[strings.ToLower(svc.account)] {
+		return scFailure("StartService", win32ServiceLogonFailed)
+	}
+
+	c.transition(svc, StatusStartPending)
+	return []byte("SERVICE_NAME: " + regName + "\n        STATE              : 2  START_PENDING"), nil
+}
+
+// Stop moves a running service into STOP_PENDING
+func (c *MemoryController) Stop(_ context.Context, regName string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if out, err := c.injected("stop", "ControlService"); err != nil {
+		return out, err
+	}
+	svc, ok := c.services[regName]
+	if !ok {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+	switch svc.state {
+	case StatusStopped:
+		return scFailure("ControlService", win32ServiceNotActive)
+	case StatusStartPending, StatusStopPending:
+		return scFailure("ControlService", win32ServiceCannotAccept)
+	}
+	for _, name := range c.dependents(regName) {
+		if c.services[name].state != StatusStopped {
+			return scFailure("ControlService", win32DependentsRunning)
+		}
+	}
+
+	c.transition(svc, StatusStopPending)
+	return []byte("SERVICE_NAME: " + regName + "\n        STATE              : 3  STOP_PENDING"), nil
+}
+
+// Query returns the current state, advancing pending transitions
+func (c *MemoryController) Query(_ context.Context, regName string) (ServiceState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	svc, ok := c.services[regName]
+	if !ok {
+		return ServiceState{Status: StatusNotInstalled}, nil
+	}
+
+	state := ServiceState{
+		Name:          regName,
+		Type:          "WIN32_OWN_PROCESS",
+		Status:        svc.state,
+		StateCode:     stateCodeOf(svc.state),
+		PID:           svc.pid,
+		Win32ExitCode: svc.exitCode,
+	}
+	if svc.pending > 0 && !(svc.hung && svc.state == StatusStopPending) {
+		svc.pending--
+		if svc.pending == 0 {
+			c.settle(regName, svc)
+		}
+	}
+	return state, nil
+}
+
+// QueryConfig returns the configuration recorded at Create
+func (c *MemoryController) QueryConfig(_ context.Context, regName string) (ServiceConfig, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	svc, ok := c.services[regName]
+	if !ok {
+		_, err := scFailure("OpenService", win32ServiceDoesNotExist)
+		return ServiceConfig{}, err
+	}
+	startType := "AUTO_START"
+	switch svc.startType {
+	case StartManual:
+		startType = "DEMAND_START"
+	case StartDisabled:
+		startType = "DISABLED"
+	}
+	return ServiceConfig{
+		Name:         regName,
+		Type:         "WIN32_OWN_PROCESS",
+		StartType:    startType,
+		DelayedStart: svc.startType == StartDelayedAuto,
+		ErrorControl: "NORMAL",
+		BinaryPath:   svc.binPath,
+		DisplayName:  svc.displayName,
+		Description:  svc.description,
+		Dependencies: append([]string(nil), svc.dependencies...),
+		Account:      svc.account,
+	}, nil
+}
+
+// SetDescription records the description
+func (c *MemoryController) SetDescription(_ context.Context, regName, description string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if out, err := c.injected("description", "ChangeServiceConfig2"); err != nil {
+		return out, err
+	}
+	svc, ok := c.services[regName]
+	if !ok {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+	svc.description = description
+	return []byte("[SC] ChangeServiceConfig2 SUCCESS"), nil
+}
+
+// AddService registers an external service (e.g. "Spooler") so it can be
+// named as a dependency; it is created RUNNING when running is set
+func (c *MemoryController) AddService(regName string, running bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	svc := &memoryService{displayName: regName, account: AccountLocalSystem, state: StatusStopped}
+	if running {
+		svc.state = StatusRunning
+		svc.pid = c.nextPID
+		c.nextPID++
+	}
+	c.services[regName] = svc
+}
+
+// Dependents lists the services whose dependencies include regName
+func (c *MemoryController) Dependents(_ context.Context, regName string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.services[regName]; !ok {
+		_, err := scFailure("OpenService", win32ServiceDoesNotExist)
+		return nil, err
+	}
+	return c.dependents(regName), nil
+}
+
+// dependents lists the services depending on regName; c.mu must be held
+func (c *MemoryController) dependents(regName string) []string {
+	var names []string
+	for name, svc := range c.services {
+		for _, dep := range svc.dependencies {
+			if strings.EqualFold(dep, regName) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetAccount records a new logon account (the password is not kept)
+func (c *MemoryController) SetAccount(_ context.Context, regName string, account ServiceAccount) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if out, err := c.injected("config", "ChangeServiceConfig"); err != nil {
+		return out, err
+	}
+	svc, ok := c.services[regName]
+	if !ok {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+	svc.account = accountOrDefault(account)
+	return []byte("[SC] ChangeServiceConfig SUCCESS"), nil
+}
+
+// accountOrDefault returns the account name, LocalSystem if none was given
+func accountOrDefault(account ServiceAccount) string {
+	if account.Name == "" {
+		return AccountLocalSystem
+	}
+	return account.Name
+}
+
+// SetStartType records a new start type
+func (c *MemoryController) SetStartType(_ context.Context, regName string, startType StartType) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if out, err := c.injected("config", "ChangeServiceConfig"); err != nil {
+		return out, err
+	}
+	svc, ok := c.services[regName]
+	if !ok {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+	svc.startType = startType
+	return []byte("[SC] ChangeServiceConfig SUCCESS"), nil
+}
+
+// ConfigureFailure records the recovery policy
+func (c *MemoryController) ConfigureFailure(_ context.Context, regName string, policy RecoveryPolicy) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if out, err := c.injected("failure", "ChangeServiceConfig2"); err != nil {
+		return out, err
+	}
+	svc, ok := c.services[regName]
+	if !ok {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+	policy.Actions = append([]RecoveryAction(nil), policy.Actions...)
+	svc.recovery = &policy
+	return []byte("[SC] ChangeServiceConfig2 SUCCESS"), nil
+}
+
+// QueryFailure returns the recorded recovery policy (empty if none was applied)
+func (c *MemoryController) QueryFailure(_ context.Context, regName string) (RecoveryPolicy, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	svc, ok := c.services[regName]
+	if !ok {
+		_, err := scFailure("OpenService", win32ServiceDoesNotExist)
+		return RecoveryPolicy{}, err
+	}
+	if svc.recovery == nil {
+		return RecoveryPolicy{}, nil
+	}
+	policy := *svc.recovery
+	policy.Actions = policy.trimmedActions()
+	return policy, nil
+}
+
+// Kill forces the service into the STOPPED state
+func (c *MemoryController) Kill(_ context.Context, regName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.injected("kill", "TerminateProcess"); err != nil {
+		return err
+	}
+	svc, ok := c.services[regName]
+	if !ok {
+		return errors.New("no tasks running with the specified criteria")
+	}
+	svc.state = StatusStopped
+	svc.pid = 0
+	svc.exitCode = 1067 // ERROR_PROCESS_ABORTED
+	svc.pending = 0
+	svc.hung = false
+	if svc.markedDelete {
+		delete(c.services, regName)
+	}
+	return nil
+}
+
+// transition enters a pending state that settles after PendingQueries queries
+func (c *MemoryController) transition(svc *memoryService, pending Status) {
+	svc.state = pending
+	svc.pending = c.PendingQueries
+	if svc.pending <= 0 {
+		svc.pending = 1
+	}
+}
+
+// settle completes a pending transition
+func (c *MemoryController) settle(regName string, svc *memoryService) {
+	switch svc.state {
+	case StatusStartPending:
+		svc.state = StatusRunning
+		svc.pid = c.nextPID
+		svc.exitCode = 0
+		c.nextPID++
+	case StatusStopPending:
+		svc.state = StatusStopped
+		svc.pid = 0
+		if svc.markedDelete {
+			delete(c.services, regName)
+		}
+	}
+}
+
+// injected consumes a failure registered with FailNext for op
+func (c *MemoryController) injected(op, call string) ([]byte, error) {
+	code, ok := c.failNext[op]
+	if !ok {
+		return nil, nil
+	}
+	delete(c.failNext, op)
+	return scFailure(call, code)
+}
+
+// InstallRoot returns {root}/ProgramFiles
+func (c *MemoryController) InstallRoot() (string, error) {
+	if c.root == "" {
+		return "", fmt.Errorf("memory controller root is empty")
+	}
+	return filepath.Join(c.root, "ProgramFiles"), nil
+}
+
+// LogRoot returns {root}/ProgramData
+func (c *MemoryController) LogRoot() string {
+	return filepath.Join(c.root, "ProgramData")
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Listening Ports
+// ══════════════════════════════════════════════════════════════
+// Each daemon listens on the port built into it (SCALE_PORT, TICKET_PORT):
+// Local variants on the loopback, Remoto variants on every interface so the
+// LAN reaches them. When another program already holds the port the daemon
+// dies on bind and loops through its recovery restarts, so every operation
+// that brings a daemon up checks the port first, unless the caller chose to
+// go ahead anyway (AllowPortConflicts). The port is fixed in the daemon's
+// build; the installer cannot move it.
+
+// Bind addresses of the variants
+const (
+	bindLoopback = "127.0.0.1"
+	bindAll      = "0.0.0.0"
+)
+
+// portDialTimeout bounds the connection attempt of a port check
+const portDialTimeout = 500 * time.Millisecond
+
+// ListenAddress is where a variant's daemon accepts connections
+type ListenAddress struct {
+	Host string // Bind address
+	Port int    // 0 if the installer was built without the daemon's port
+}
+
+// DefaultListenAddress returns the address a variant's daemon listens on.
+// The daemons take their port from the build (ServerPort).
+func DefaultListenAddress(family, variant string) ListenAddress {
+	port, _ := familyEndpoint(family)
+	host := bindLoopback
+	if variant == Remoto {
+		host = bindAll
+	}
+	return ListenAddress{Host: host, Port: port}
+}
+
+// String returns host:port
+func (a ListenAddress) String() string {
+	return net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+}
+
+// overlaps reports whether two listeners compete for the same port: same
+// port, and the same host or either one bound to every interface
+func (a ListenAddress) overlaps(b ListenAddress) bool {
+	if a.Port == 0 || a.Port != b.Port {
+		return false
+	}
+	return a.Host == b.Host || isWildcardHost(a.Host) || isWildcardHost(b.Host)
+}
+
+// isWildcardHost reports whether host binds every interface
+func isWildcardHost(host string) bool {
+	return host == "" || host == bindAll || host == "::"
+}
+
+// Listen returns the address this manager's daemon listens on
+func (m *Manager) Listen() ListenAddress {
+	return m.variant.Listen
+}
+
+// ══════════════════════════════════════════════════════════════
+// Port Conflicts
+// ══════════════════════════════════════════════════════════════
+
+// PortConflict is something standing in the way of a daemon's listener
+type PortConflict struct {
+	Address ListenAddress // Address the variant listens on
+	Holder  string        // Another family's variant on the same port; empty for an unrelated program
+	InUse   bool          // Something already listens on the port
+}
+
+// String describes the conflict for display
+func (c PortConflict) String() string {
+	switch {
+	case c.Holder != "" && c.InUse:
+		return fmt.Sprintf("%s ya está en uso por %s", c.Address, c.Holder)
+	case c.Holder != "":
+		return fmt.Sprintf("%s también es el puerto de %s (no está en ejecución)", c.Address, c.Holder)
+	default:
+		return fmt.Sprintf("%s ya está en uso por otro programa", c.Address)
+	}
+}
+
+// PortConflicts checks this manager's port against the platform registry
+// before an Install or Start: variants of other families declared on the
+// same port, the other variant of the family while it runs on the port, and
+// anything else already listening on it. A variant that is already running
+// holds its own port and reports nothing. A switch skips the variant it
+// replaces (see replacingVariant).
+func (m *Manager) PortConflicts(ctx context.Context) []PortConflict {
+	return m.portConflicts(ctx, GetServiceRegistry())
+}
+
+// portConflicts checks this manager's port against the given families
+func (m *Manager) portConflicts(ctx context.Context, registry map[string][]Variant) []PortConflict {
+	switch m.status(ctx) {
+	case StatusRunning, StatusStartPending, StatusStopPending:
+		return nil
+	default:
+	}
+	addr := m.Listen()
+	if addr.Port == 0 {
+		return nil
+	}
+
+	replaced, _ := ctx.Value(replacingKey{}).(string)
+	var conflicts []PortConflict
+	held := false // A running variant explains a busy port
+	for _, family := range sortedFamilies(registry) {
+		for _, v := range registry[family] {
+			if v.ID == m.variant.ID || !addr.overlaps(v.Listen) {
+				continue
+			}
+			status := NewManagerWithController(v, m.ctrl).status(ctx)
+			running := status == StatusRunning || status == StatusStartPending
+			held = held || running
+			switch {
+			case v.ID == replaced:
+				// Released before this variant comes up
+			case family == m.variant.Family && !running:
+				// The variants of a family are never installed together
+			default:
+				conflicts = append(conflicts, PortConflict{Address: addr, Holder: v.DisplayName, InUse: running})
+			}
+		}
+	}
+
+	if !held && portInUse(ctx, addr) {
+		conflicts = append(conflicts, PortConflict{Address: addr, InUse: true})
+	}
+	return conflicts
+}
+
+// allowPortConflictsKey marks a context whose operation skips the port check
+type allowPortConflictsKey struct{}
+
+// AllowPortConflicts returns a context under which the operations that
+// bring a daemon up skip the port check: the user saw the conflicts and
+// chose to go ahead
+func AllowPortConflicts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowPortConflictsKey{}, true)
+}
+
+// replacingKey marks a context whose operation replaces a variant (a switch)
+type replacingKey struct{}
+
+// replacingVariant returns a context under which the port checks ignore the
+// variant id, which the operation removes before the new one comes up
+func replacingVariant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, replacingKey{}, id)
+}
+
+// checkPort refuses to bring the daemon up while something already listens
+// on its port. Variants of other families merely declared on the same port
+// are left to the caller (PortConflicts): they only clash once both run.
+func (m *Manager) checkPort(ctx context.Context, op string) error {
+	if allowed, _ := ctx.Value(allowPortConflictsKey{}).(bool); allowed {
+		return nil
+	}
+	for _, c := range m.portConflicts(ctx, GetServiceRegistry()) {
+		if c.InUse {
+			return m.opError(op, CategoryPortInUse, nil, "no se puede iniciar %s: %s", m.variant.DisplayName, c)
+		}
+	}
+	return nil
+}
+
+// portInUse reports whether something listens on addr: the bind fails, or
+// the port accepts a connection on the loopback. Windows lets a specific
+// bind share a port with a wildcard one, so the bind alone can miss it.
+func portInUse(ctx context.Context, addr ListenAddress) bool {
+	var lc net.ListenConfig
+	l, err := lc.Listen(ctx, "tcp", addr.String())
+	if err != nil {
+		return true
+	}
+	_ = l.Close()
+
+	d := net.Dialer{Timeout: portDialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(bindLoopback, strconv.Itoa(addr.Port)))
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
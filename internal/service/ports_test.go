@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// holdPort listens on a loopback port for the rest of the test and points
+// the manager's daemon at it
+func holdPort(t *testing.T, m *Manager) {
+	t.Helper()
+	ln, err := net.Listen("tcp", bindLoopback+":0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	m.variant.Listen = ListenAddress{Host: bindLoopback, Port: ln.Addr().(*net.TCPAddr).Port}
+}
+
+func TestManagerInstallPortInUse(t *testing.T) {
+	for _, withStart := range []bool{false, true} {
+		m, ctrl := newTestManager(t)
+		holdPort(t, m)
+
+		install := m.Install
+		if withStart {
+			install = m.InstallAndStart
+		}
+		assertCategory(t, install(context.Background()), CategoryPortInUse)
+		assertRolledBack(t, m, ctrl)
+	}
+}
+
+func TestManagerStartPortInUse(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	holdPort(t, m)
+
+	assertCategory(t, m.Start(ctx), CategoryPortInUse)
+	assertCategory(t, m.Restart(ctx), CategoryPortInUse)
+	if st := m.status(ctx); st != StatusStopped {
+		t.Errorf("estado = %s, el servicio no debía iniciarse con el puerto ocupado", st)
+	}
+}
+
+func TestManagerAllowPortConflicts(t *testing.T) {
+	m, _ := newTestManager(t)
+	holdPort(t, m)
+	ctx := AllowPortConflicts(context.Background())
+
+	if err := m.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+	if err := m.Restart(ctx); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+}
+
+func TestPortConflictsRunningSibling(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	ln, err := net.Listen("tcp", bindLoopback+":0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+	m.variant.Listen = ListenAddress{Host: bindLoopback, Port: port}
+
+	sibling := testVariant("test-remoto", "Test_Servicio_Remoto")
+	sibling.Variant = Remoto
+	sibling.Listen = ListenAddress{Host: bindAll, Port: port}
+	registry := map[string][]Variant{m.variant.Family: {m.variant, sibling}}
+
+	// Installed but stopped, the other variant of the family is no conflict
+	other := NewManagerWithController(sibling, ctrl)
+	if err := other.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if got := m.portConflicts(ctx, registry); len(got) != 0 {
+		t.Errorf("conflictos con la otra variante detenida = %v", got)
+	}
+
+	if err := other.startAndWait(AllowPortConflicts(ctx), "start"); err != nil {
+		t.Fatalf("startAndWait: %v", err)
+	}
+	got := m.portConflicts(ctx, registry)
+	want := []PortConflict{{Address: m.variant.Listen, Holder: sibling.DisplayName, InUse: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("conflictos = %v, se esperaba %v", got, want)
+	}
+
+	// A switch replaces the running variant, so its port is about to be free
+	if got := m.portConflicts(replacingVariant(ctx, sibling.ID), registry); len(got) != 0 {
+		t.Errorf("conflictos al reemplazar la otra variante = %v", got)
+	}
+}
@@ -0,0 +1,467 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // mandated by the WebSocket handshake (RFC 6455), not used for security
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/adcondev/poster-tuis/internal/config"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Health Probes
+// ══════════════════════════════════════════════════════════════
+// StatusRunning only says the SCM sees the process alive; a daemon whose
+// listener crashed still reads EN EJECUCIÓN. Each variant declares a probe
+// that checks the daemon actually answers on its port: a TCP connect, an
+// HTTP GET, or a WebSocket handshake, carrying the daemon's auth token when
+// it has one. Probes always dial the local machine, where the daemon runs.
+
+// ProbeKind is how a health probe talks to the daemon
+type ProbeKind string
+
+const (
+	// ProbeNone declares no probe; the health of a running daemon is unknown
+	ProbeNone ProbeKind = ""
+	// ProbeTCP only checks that the port accepts connections
+	ProbeTCP ProbeKind = "tcp"
+	// ProbeHTTP sends a GET and expects a 2xx or 3xx answer
+	ProbeHTTP ProbeKind = "http"
+	// ProbeWebSocket performs the WebSocket opening handshake
+	ProbeWebSocket ProbeKind = "websocket"
+)
+
+// Label returns the probe kind for display
+func (k ProbeKind) Label() string {
+	switch k {
+	case ProbeTCP:
+		return "TCP"
+	case ProbeHTTP:
+		return "HTTP"
+	case ProbeWebSocket:
+		return "WebSocket"
+	default:
+		return "sin sonda"
+	}
+}
+
+// Probe defaults: every variant is reachable on the loopback (see
+// DefaultListenAddress) and serves its clients on /ws
+const (
+	probeHost           = "127.0.0.1"
+	probePath           = "/ws"
+	defaultProbeTimeout = 3 * time.Second
+)
+
+// HealthProbe describes how to check that a daemon answers
+type HealthProbe struct {
+	Kind    ProbeKind
+	Host    string        // Address dialed (empty means probeHost)
+	Port    int           // Port the daemon listens on
+	Path    string        // Request path of HTTP and WebSocket probes
+	Token   string        // Auth token; sent only as a bearer header, never in the URL
+	Timeout time.Duration // Zero means defaultProbeTimeout
+}
+
+// DefaultHealthProbe returns the probe declared for a service family: a
+// WebSocket handshake on the port and with the token built into its daemon.
+// Without a valid port (an installer built without the daemon settings)
+// the family has no probe.
+func DefaultHealthProbe(family string) HealthProbe {
+	port, token := familyEndpoint(family)
+	if port == 0 {
+		return HealthProbe{}
+	}
+	return HealthProbe{Kind: ProbeWebSocket, Port: port, Path: probePath, Token: token}
+}
+
+// familyEndpoint returns the port and auth token built into a family's
+// daemon; the port is 0 if unset or not a valid port number
+func familyEndpoint(family string) (int, string) {
+	var port, token string
+	switch family {
+	case "scale":
+		port, token = config.ScalePort, config.ScaleAuthToken
+	case "ticket":
+		port, token = config.TicketPort, config.TicketAuthToken
+	default:
+		return 0, ""
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return 0, ""
+	}
+	return n, token
+}
+
+// Address returns the host:port the probe dials
+func (p HealthProbe) Address() string {
+	host := p.Host
+	if host == "" {
+		host = probeHost
+	}
+	return net.JoinHostPort(host, strconv.Itoa(p.Port))
+}
+
+// url returns the request URL of HTTP and WebSocket probes. It carries no
+// token: the URL ends up in error messages, which reach the dashboard and
+// the support bundle.
+func (p HealthProbe) url() string {
+	u := url.URL{Scheme: "http", Host: p.Address(), Path: p.Path}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String()
+}
+
+// ProbeResult is the outcome of one probe
+type ProbeResult struct {
+	Kind    ProbeKind
+	Address string
+	Healthy bool
+	Latency time.Duration // Time until the daemon answered (or the probe gave up)
+	Detail  string        // Answer on success ("HTTP 200 OK"), the failure otherwise
+	Err     error         `json:"-"`
+	At      time.Time     // When the probe ran; zero if it never did
+}
+
+// Probed reports whether a probe ran
+func (r ProbeResult) Probed() bool {
+	return !r.At.IsZero()
+}
+
+// LatencyText returns the latency rounded for display ("4ms", "<1ms")
+func (r ProbeResult) LatencyText() string {
+	if r.Latency < time.Millisecond {
+		return "<1ms"
+	}
+	return r.Latency.Round(time.Millisecond).String()
+}
+
+// Summary describes the result for display, e.g.
+// "responde en 4ms (WebSocket 127.0.0.1:8765)"
+func (r ProbeResult) Summary() string {
+	switch {
+	case !r.Probed():
+		return "sin sonda de salud"
+	case r.Healthy:
+		return fmt.Sprintf("responde en %s (%s %s)", r.LatencyText(), r.Kind.Label(), r.Address)
+	default:
+		return fmt.Sprintf("sin respuesta: %s (%s %s)", r.Detail, r.Kind.Label(), r.Address)
+	}
+}
+
+// Probe checks that this manager's daemon answers on its port. A variant
+// without a probe returns a result that was never Probed.
+func (m *Manager) Probe(ctx context.Context) ProbeResult {
+	return m.variant.Probe.Run(ctx)
+}
+
+// Run performs the probe once
+func (p HealthProbe) Run(ctx context.Context) ProbeResult {
+	if p.Kind == ProbeNone {
+		return ProbeResult{}
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res := ProbeResult{Kind: p.Kind, Address: p.Address()}
+	start := time.Now()
+
+	var err error
+	switch p.Kind {
+	case ProbeTCP:
+		res.Detail, err = probeTCP(ctx, p)
+	case ProbeHTTP:
+		res.Detail, err = probeHTTP(ctx, p)
+	case ProbeWebSocket:
+		res.Detail, err = probeWebSocket(ctx, p)
+	default:
+		err = fmt.Errorf("tipo de sonda desconocido %q", p.Kind)
+	}
+
+	res.Latency = time.Since(start)
+	res.At = time.Now()
+	res.Healthy = err == nil
+	if err != nil {
+		// The request URL adds nothing to Address and is kept out of reports
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		res.Err = err
+		res.Detail = probeErrorText(ctx, err)
+	}
+	return res
+}
+
+// probeErrorText turns a probe failure into a short Spanish message
+func probeErrorText(ctx context.Context, err error) string {
+	var opErr *net.OpError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "tiempo de espera agotado"
+	case errors.Is(ctx.Err(), context.Canceled):
+		return "sonda cancelada"
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return "conexión rechazada"
+	default:
+		return err.Error()
+	}
+}
+
+// ── Probe Kinds ──
+
+// probeTCP connects and hangs up
+func probeTCP(ctx context.Context, p HealthProbe) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.Address())
+	if err != nil {
+		return "", err
+	}
+	_ = conn.Close()
+	return "conexión aceptada", nil
+}
+
+// probeClient sends HTTP probes; a probe must not leave idle connections
+// open on the daemon
+var probeClient = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+// probeHTTP sends a GET and accepts any 2xx or 3xx answer
+func probeHTTP(ctx context.Context, p HealthProbe) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url(), nil)
+	if err != nil {
+		return "", err
+	}
+	p.authorize(req)
+
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", httpStatusError(resp)
+	}
+	return "HTTP " + resp.Status, nil
+}
+
+// websocketGUID is appended to the client key to compute the accept value
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// probeWebSocket performs the opening handshake, checks the server's accept
+// value and closes the connection again with a close frame
+func probeWebSocket(ctx context.Context, p HealthProbe) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.Address())
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	p.authorize(req)
+
+	if err := req.Write(conn); err != nil {
+		return "", err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return "", fmt.Errorf("respuesta no válida: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return "", httpStatusError(resp)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		return "", errors.New("handshake WebSocket no válido")
+	}
+
+	// Masked close frame with an empty payload (the mask key is all zeros)
+	_, _ = conn.Write([]byte{0x88, 0x80, 0, 0, 0, 0})
+	return resp.Status, nil
+}
+
+// websocketAccept computes the Sec-WebSocket-Accept value for a client key
+func websocketAccept(key string) string {
+	//nolint:gosec // RFC 6455 fixes SHA-1 for the handshake
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// authorize adds the probe's token as a bearer header
+func (p HealthProbe) authorize(req *http.Request) {
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+}
+
+// httpStatusError describes an unexpected HTTP answer
+func httpStatusError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("token rechazado (HTTP %s)", resp.Status)
+	default:
+		return fmt.Errorf("HTTP %s", resp.Status)
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Composite Health
+// ══════════════════════════════════════════════════════════════
+
+// Health combines the SCM status of a family's installed variant with the
+// result of its probe
+type Health int
+
+const (
+	// HealthNotInstalled means no variant is installed
+	HealthNotInstalled Health = iota
+	// HealthStopped means the installed variant is stopped
+	HealthStopped
+	// HealthPending means the variant is starting or stopping
+	HealthPending
+	// HealthHealthy means the variant is running and its probe passed
+	HealthHealthy
+	// HealthUnhealthy means the variant is running but its probe failed
+	HealthUnhealthy
+	// HealthUnprobed means the variant is running and has no probe result
+	HealthUnprobed
+	// HealthUnknown covers conflicts and unrecognized states
+	HealthUnknown
+)
+
+// Label returns the Spanish name of the health state
+func (h Health) Label() string {
+	switch h {
+	case HealthNotInstalled:
+		return "NO INSTALADO"
+	case HealthStopped:
+		return "DETENIDO"
+	case HealthPending:
+		return "EN TRANSICIÓN"
+	case HealthHealthy:
+		return "EN EJECUCIÓN Y RESPONDE"
+	case HealthUnhealthy:
+		return "EN EJECUCIÓN SIN RESPUESTA"
+	case HealthUnprobed:
+		return "EN EJECUCIÓN (SIN SONDA)"
+	default:
+		return "DESCONOCIDO"
+	}
+}
+
+// Icon returns the one-character marker used in status bars
+func (h Health) Icon() string {
+	switch h {
+	case HealthNotInstalled:
+		return "-"
+	case HealthStopped:
+		return "."
+	case HealthPending:
+		return "~"
+	case HealthHealthy, HealthUnprobed:
+		return "+"
+	case HealthUnhealthy:
+		return "!"
+	default:
+		return "?"
+	}
+}
+
+// Health returns the composite health of the family's installed variant
+func (fs FamilyStatus) Health() Health {
+	if fs.HasConflict() {
+		return HealthUnknown
+	}
+	switch fs.GetActiveStatus() {
+	case StatusNotInstalled:
+		return HealthNotInstalled
+	case StatusStopped:
+		return HealthStopped
+	case StatusStartPending, StatusStopPending:
+		return HealthPending
+	case StatusRunning:
+		switch {
+		case !fs.Probe.Probed():
+			return HealthUnprobed
+		case fs.Probe.Healthy:
+			return HealthHealthy
+		default:
+			return HealthUnhealthy
+		}
+	default:
+		return HealthUnknown
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Probe Rounds
+// ══════════════════════════════════════════════════════════════
+
+// ProbeAll probes the running variant of every family in statuses on the
+// platform registry. Families with nothing running, or whose variant has no
+// probe, are left out.
+func ProbeAll(ctx context.Context, statuses map[string]FamilyStatus) map[string]ProbeResult {
+	return probeFamilies(ctx, GetServiceRegistry(), statuses)
+}
+
+// probeFamilies probes the running variant of each family in parallel
+func probeFamilies(ctx context.Context, registry map[string][]Variant, statuses map[string]FamilyStatus) map[string]ProbeResult {
+	var families []string
+	var probes []HealthProbe
+	for _, family := range sortedFamilies(statuses) {
+		fs := statuses[family]
+		if fs.HasConflict() || fs.GetActiveStatus() != StatusRunning {
+			continue
+		}
+		installed := fs.GetInstalledVariant()
+		for _, v := range registry[family] {
+			if v.Variant == installed && v.Probe.Kind != ProbeNone {
+				families = append(families, family)
+				probes = append(probes, v.Probe)
+			}
+		}
+	}
+
+	results := make([]ProbeResult, len(probes))
+	runBounded(len(probes), queryWorkers, func(i int) {
+		results[i] = probes[i].Run(ctx)
+	})
+
+	out := make(map[string]ProbeResult, len(families))
+	for i, family := range families {
+		out[family] = results[i]
+	}
+	return out
+}
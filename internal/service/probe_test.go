@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// probeToken is the auth token the stand-in daemons expect
+const probeToken = "token-secreto"
+
+// probeAt returns a probe of the given kind dialing addr (host:port)
+func probeAt(t *testing.T, kind ProbeKind, addr string) HealthProbe {
+	t.Helper()
+	host, portText, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("dirección %q: %v", addr, err)
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		t.Fatalf("puerto %q: %v", portText, err)
+	}
+	return HealthProbe{Kind: kind, Host: host, Port: port, Path: probePath, Token: probeToken, Timeout: 2 * time.Second}
+}
+
+// serverAddr returns the host:port of a test server
+func serverAddr(srv *httptest.Server) string {
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+// checkToken fails the request unless it carries the token as a bearer
+// header and nowhere in the URL
+func checkToken(t *testing.T, r *http.Request) {
+	t.Helper()
+	if got := r.Header.Get("Authorization"); got != "Bearer "+probeToken {
+		t.Errorf("Authorization = %q, se esperaba el token como bearer", got)
+	}
+	if strings.Contains(r.URL.String(), probeToken) {
+		t.Errorf("el token viaja en la URL: %s", r.URL)
+	}
+}
+
+// assertProbe fails unless the probe came out as healthy, with a detail
+// containing detail, and without the token anywhere in its report
+func assertProbe(t *testing.T, res ProbeResult, healthy bool, detail string) {
+	t.Helper()
+	if !res.Probed() {
+		t.Fatal("la sonda no se ejecutó")
+	}
+	if res.Healthy != healthy {
+		t.Errorf("Healthy = %v, se esperaba %v (detalle: %q)", res.Healthy, healthy, res.Detail)
+	}
+	if !strings.Contains(res.Detail, detail) {
+		t.Errorf("Detail = %q, se esperaba que contuviera %q", res.Detail, detail)
+	}
+	if strings.Contains(res.Detail, probeToken) || strings.Contains(res.Summary(), probeToken) {
+		t.Errorf("el token aparece en el resultado: %q", res.Detail)
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	assertProbe(t, probeAt(t, ProbeTCP, ln.Addr().String()).Run(context.Background()), true, "conexión aceptada")
+}
+
+func TestProbeTCPRefused(t *testing.T) {
+	// A port that was just freed refuses connections
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	assertProbe(t, probeAt(t, ProbeTCP, addr).Run(context.Background()), false, "conexión rechazada")
+}
+
+func TestProbeHTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		healthy bool
+		detail  string
+	}{
+		{"200", http.StatusOK, true, "HTTP 200 OK"},
+		{"401", http.StatusUnauthorized, false, "token rechazado"},
+		{"500", http.StatusInternalServerError, false, "HTTP 500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				checkToken(t, r)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			assertProbe(t, probeAt(t, ProbeHTTP, serverAddr(srv)).Run(context.Background()), tt.healthy, tt.detail)
+		})
+	}
+}
+
+func TestProbeHTTPErrorOmitsURL(t *testing.T) {
+	// The daemon hangs up without answering
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	}))
+	defer srv.Close()
+
+	res := probeAt(t, ProbeHTTP, serverAddr(srv)).Run(context.Background())
+	assertProbe(t, res, false, "")
+	if strings.Contains(res.Detail, "http://") || strings.Contains(res.Err.Error(), "http://") {
+		t.Errorf("el error repite la URL de la petición: %q", res.Detail)
+	}
+}
+
+// websocketServer answers the opening handshake with the given accept value
+// (computed from the client key when accept is empty)
+func websocketServer(t *testing.T, accept string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checkToken(t, r)
+		if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+			t.Errorf("cabeceras de handshake incompletas: %v", r.Header)
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack: %v", err)
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		if accept == "" {
+			accept = websocketAccept(r.Header.Get("Sec-WebSocket-Key"))
+		}
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + accept + "\r\n\r\n")
+		_ = rw.Flush()
+		// Wait for the probe's close frame (or its hang-up)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _ = bufio.NewReader(rw).ReadByte()
+	}))
+}
+
+func TestProbeWebSocket(t *testing.T) {
+	srv := websocketServer(t, "")
+	defer srv.Close()
+
+	assertProbe(t, probeAt(t, ProbeWebSocket, serverAddr(srv)).Run(context.Background()), true, "101")
+}
+
+func TestProbeWebSocketBadAccept(t *testing.T) {
+	srv := websocketServer(t, "valor-incorrecto")
+	defer srv.Close()
+
+	assertProbe(t, probeAt(t, ProbeWebSocket, serverAddr(srv)).Run(context.Background()), false, "handshake WebSocket no válido")
+}
+
+func TestProbeWebSocketRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	assertProbe(t, probeAt(t, ProbeWebSocket, serverAddr(srv)).Run(context.Background()), false, "token rechazado")
+}
+
+func TestProbeTimeout(t *testing.T) {
+	// A listener that accepts but never answers
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	var mu sync.Mutex
+	var held []net.Conn
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			held = append(held, conn)
+			mu.Unlock()
+		}
+	}()
+	defer func() {
+		_ = ln.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range held {
+			_ = conn.Close()
+		}
+	}()
+
+	probe := probeAt(t, ProbeWebSocket, ln.Addr().String())
+	probe.Timeout = 200 * time.Millisecond
+	res := probe.Run(context.Background())
+	assertProbe(t, res, false, "tiempo de espera agotado")
+	if res.Latency > 2*time.Second {
+		t.Errorf("Latency = %v, la sonda no respetó su tiempo de espera", res.Latency)
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Operation Progress
+// ══════════════════════════════════════════════════════════════
+// Lifecycle operations report their steps to the callback attached to their
+// context with WithProgress. Every change publishes a full snapshot, so a
+// consumer that only keeps the latest one never misses a step.
+
+// StepState is where a step of an operation stands
+type StepState int
+
+const (
+	// StepPending is a planned step that has not started
+	StepPending StepState = iota
+	// StepRunning is the step in progress
+	StepRunning
+	// StepDone is a step that finished successfully
+	StepDone
+	// StepFailed is a step that returned an error
+	StepFailed
+)
+
+// StepProgress is the state of one step of an operation
+type StepProgress struct {
+	Name    string
+	State   StepState
+	Started time.Time     // Zero while pending
+	Elapsed time.Duration // Time spent in the step (so far, while running)
+	Detail  string        // Sub-status of the running step, e.g. "esperando DETENIDO 6/15s"
+}
+
+// Progress is a snapshot of a running operation
+type Progress struct {
+	Steps   []StepProgress
+	Current int // Index of the running step, -1 when none is running
+}
+
+// Total returns the number of steps known so far. Nested operations (a
+// variant switch, a rollback) can add steps while running.
+func (p Progress) Total() int {
+	return len(p.Steps)
+}
+
+// Completed returns the number of finished steps, failed ones included
+func (p Progress) Completed() int {
+	n := 0
+	for _, st := range p.Steps {
+		if st.State == StepDone || st.State == StepFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// Step names shared by an operation's plan and the steps that carry it out
+const (
+	stepPrecheck    = "Comprobar requisitos"
+	stepDependents  = "Detener servicios dependientes"
+	stepStop        = "Detener servicio"
+	stepWaitStopped = "Esperar detención"
+	stepForceStop   = "Forzar detención"
+	stepStart       = "Iniciar servicio"
+	stepDelete      = "Eliminar registro"
+	stepRemoveFiles = "Eliminar archivos"
+	stepRecovery    = "Configurar recuperación"
+	stepRollback    = "Revertir cambios"
+)
+
+// progressKey is the context key of the operation's tracker
+type progressKey struct{}
+
+// WithProgress returns a context whose lifecycle operations report their
+// steps to fn. fn is called synchronously from the operation's goroutines
+// and must not block.
+func WithProgress(ctx context.Context, fn func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, &progressTracker{fn: fn, current: -1})
+}
+
+// progressFrom returns the tracker of ctx, or nil. Every tracker method is
+// a no-op on nil, so operations report unconditionally.
+func progressFrom(ctx context.Context) *progressTracker {
+	t, _ := ctx.Value(progressKey{}).(*progressTracker)
+	return t
+}
+
+// progressTracker accumulates the steps of one operation
+type progressTracker struct {
+	mu      sync.Mutex
+	fn      func(Progress)
+	steps   []StepProgress
+	current int
+}
+
+// runStep runs fn as the step called name of the operation in ctx
+func runStep(ctx context.Context, name string, fn func() error) error {
+	end := progressFrom(ctx).step(name)
+	err := fn()
+	end(&err)
+	return err
+}
+
+// plan announces upcoming steps so they are listed before they start
+func (t *progressTracker) plan(names ...string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, name := range names {
+		t.steps = append(t.steps, StepProgress{Name: name})
+	}
+	t.publishLocked()
+}
+
+// step starts the step called name and returns the function that ends it
+// with the operation's error, meant for `defer t.step("...")(&err)`. A
+// planned step is reused; an unplanned one is inserted before the steps
+// still pending.
+func (t *progressTracker) step(name string) func(err *error) {
+	if t == nil {
+		return func(*error) {}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := t.pendingLocked(name)
+	if i < 0 {
+		i = t.insertLocked(name)
+	}
+	t.steps[i].State = StepRunning
+	t.steps[i].Started = time.Now()
+	t.current = i
+	t.publishLocked()
+
+	return func(err *error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		st := &t.steps[i]
+		st.Elapsed = time.Since(st.Started)
+		st.Detail = ""
+		st.State = StepDone
+		if err != nil && *err != nil {
+			st.State = StepFailed
+			st.Detail = (*err).Error()
+		}
+		t.current = t.runningLocked()
+		t.publishLocked()
+	}
+}
+
+// pendingLocked returns the first pending step called name, or -1
+func (t *progressTracker) pendingLocked(name string) int {
+	for i, st := range t.steps {
+		if st.State == StepPending && st.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// insertLocked adds an unplanned step after the last started one and
+// returns its index. Only pending steps move, so the indices held by the
+// end functions of started steps stay valid.
+func (t *progressTracker) insertLocked(name string) int {
+	i := 0
+	for j, st := range t.steps {
+		if st.State != StepPending {
+			i = j + 1
+		}
+	}
+	t.steps = append(t.steps, StepProgress{})
+	copy(t.steps[i+1:], t.steps[i:])
+	t.steps[i] = StepProgress{Name: name}
+	return i
+}
+
+// runningLocked returns the last running step, or -1
+func (t *progressTracker) runningLocked() int {
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		if t.steps[i].State == StepRunning {
+			return i
+		}
+	}
+	return -1
+}
+
+// detail sets the sub-status of the running step
+func (t *progressTracker) detail(format string, args ...any) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current < 0 {
+		return
+	}
+	t.steps[t.current].Detail = fmt.Sprintf(format, args...)
+	t.publishLocked()
+}
+
+// every calls detailFn with the elapsed time once per interval until the
+// returned stop function is called. stop waits for the last update, so none
+// lands on a later step.
+func (t *progressTracker) every(interval time.Duration, detailFn func(elapsed time.Duration) string) (stop func()) {
+	if t == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	start := time.Now()
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				t.detail("%s", detailFn(time.Since(start)))
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+		})
+	}
+}
+
+// publishLocked hands a copy of the steps to the callback
+func (t *progressTracker) publishLocked() {
+	if t.fn == nil {
+		return
+	}
+	snapshot := Progress{Steps: make([]StepProgress, len(t.steps)), Current: t.current}
+	copy(snapshot.Steps, t.steps)
+	if t.current >= 0 {
+		snapshot.Steps[t.current].Elapsed = time.Since(snapshot.Steps[t.current].Started)
+	}
+	t.fn(snapshot)
+}
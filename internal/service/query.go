@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Status Queries (Worker Pool and Cache)
+// ══════════════════════════════════════════════════════════════
+// Read-only status checks (dashboard refreshes, family menus) run on a
+// bounded worker pool and go through a short-lived cache, so screens asking
+// for the same variant within statusCacheTTL share one sc.exe round trip.
+// Lifecycle operations drop the entries of the variant they touch and query
+// the backend directly while they run.
+
+// statusCacheTTL is how long a query result answers later lookups
+const statusCacheTTL = 2 * time.Second
+
+// queryWorkers bounds the sc.exe processes a status round runs at once
+const queryWorkers = 4
+
+// QueryStats measures one status round
+type QueryStats struct {
+	Variants  int           // Variants checked
+	Queries   int           // State, config and dependents lookups answered by the backend
+	CacheHits int           // Lookups answered by the cache
+	Workers   int           // Size of the worker pool
+	Elapsed   time.Duration // Wall time of the round
+	At        time.Time     // When the round finished
+}
+
+// StatusReport is the outcome of QueryAll
+type StatusReport struct {
+	Families map[string]FamilyStatus // Indexed by family name
+	Stats    QueryStats
+}
+
+// QueryAll checks every variant of every registered family in one round on
+// the platform backend
+func QueryAll(ctx context.Context) StatusReport {
+	return queryAll(ctx, defaultController, GetServiceRegistry())
+}
+
+// variantStatus is what a status round collects for one variant
+type variantStatus struct {
+	state   ServiceState
+	cfg     ServiceConfig
+	differs bool
+}
+
+// queryAll runs a status round over the given families. The variants are
+// queried first; then, for each family, the dependency tree and dependents
+// of its installed variant. Every lookup goes through the cache. Health
+// probes are not part of the round: they open connections to the daemons
+// and run on their own schedule (ProbeAll).
+func queryAll(ctx context.Context, ctrl Controller, registry map[string][]Variant) StatusReport {
+	start := time.Now()
+	var counter queryCounter
+
+	var variants []Variant
+	for _, family := range sortedFamilies(registry) {
+		variants = append(variants, registry[family]...)
+	}
+
+	results := make([]variantStatus, len(variants))
+	runBounded(len(variants), queryWorkers, func(i int) {
+		mgr := NewManagerWithController(variants[i], ctrl)
+		r := variantStatus{state: mgr.cachedState(ctx, &counter)}
+		if r.state.Status != StatusNotInstalled {
+			r.cfg, _ = mgr.cachedConfig(ctx, &counter)
+			r.differs = mgr.BinaryDiffers()
+		}
+		results[i] = r
+	})
+
+	families := make(map[string]FamilyStatus, len(registry))
+	installed := make(map[string]Variant) // First installed variant of each family
+	for i, v := range variants {
+		fs, ok := families[v.Family]
+		if !ok {
+			fs = FamilyStatus{LocalStatus: StatusNotInstalled, RemoteStatus: StatusNotInstalled}
+		}
+		r := results[i]
+		switch v.Variant {
+		case Local:
+			fs.LocalStatus = r.state.Status
+			fs.LocalState = r.state
+			fs.LocalConfig = r.cfg
+		case Remoto:
+			fs.RemoteStatus = r.state.Status
+			fs.RemoteState = r.state
+			fs.RemoteConfig = r.cfg
+		}
+		if r.differs {
+			fs.BinaryDiffers = true
+		}
+		if _, seen := installed[v.Family]; !seen && r.state.Status != StatusNotInstalled {
+			installed[v.Family] = v
+		}
+		families[v.Family] = fs
+	}
+
+	// Dependencies only matter for the variant that is actually installed
+	depFamilies := sortedFamilies(installed)
+	trees := make([][]DependencyNode, len(depFamilies))
+	dependents := make([][]DependencyNode, len(depFamilies))
+	runBounded(len(depFamilies), queryWorkers, func(i int) {
+		mgr := NewManagerWithController(installed[depFamilies[i]], ctrl)
+		trees[i] = mgr.dependencyTree(ctx, &counter)
+		dependents[i], _ = mgr.cachedDependents(ctx, &counter)
+	})
+	for i, family := range depFamilies {
+		fs := families[family]
+		fs.Dependencies = trees[i]
+		fs.Dependents = dependents[i]
+		families[family] = fs
+	}
+
+	return StatusReport{
+		Families: families,
+		Stats: QueryStats{
+			Variants:  len(variants),
+			Queries:   int(counter.queries.Load()),
+			CacheHits: int(counter.hits.Load()),
+			Workers:   min(queryWorkers, len(variants)),
+			Elapsed:   time.Since(start),
+			At:        time.Now(),
+		},
+	}
+}
+
+// sortedFamilies returns the keys of a family-indexed map in sorted order
+func sortedFamilies[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for family := range m {
+		names = append(names, family)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runBounded calls fn for every index in [0, n) on at most workers
+// goroutines and returns once all calls have returned
+func runBounded(n, workers int, fn func(i int)) {
+	workers = min(workers, n)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// ── Cache ──
+
+// queryCounter counts the lookups of one status round; nil counts nothing
+type queryCounter struct {
+	queries atomic.Int64
+	hits    atomic.Int64
+}
+
+func (c *queryCounter) add(hit bool) {
+	switch {
+	case c == nil:
+	case hit:
+		c.hits.Add(1)
+	default:
+		c.queries.Add(1)
+	}
+}
+
+// cacheKey identifies a service on a backend (names are case-insensitive)
+type cacheKey struct {
+	backend any // See backendIdentity
+	regName string
+}
+
+func keyFor(ctrl Controller, regName string) cacheKey {
+	return cacheKey{backend: backendIdentity(ctrl), regName: strings.ToLower(regName)}
+}
+
+// backendIdentity returns a map-safe identity for ctrl. The interface value
+// itself would panic as a key if its dynamic type is not comparable; such a
+// backend is identified by its type, so its instances share cache entries.
+// Pointer backends (all of this package's) are keyed by their address.
+func backendIdentity(ctrl Controller) any {
+	if v := reflect.ValueOf(ctrl); v.IsValid() && !v.Comparable() {
+		return v.Type()
+	}
+	return ctrl
+}
+
+type cachedState struct {
+	state ServiceState
+	at    time.Time
+}
+
+type cachedConfig struct {
+	cfg ServiceConfig
+	at  time.Time
+}
+
+type cachedDependents struct {
+	names []string
+	at    time.Time
+}
+
+// statusCache holds the latest successful query results. Failures are not
+// cached, so the next lookup retries.
+type statusCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	states     map[cacheKey]cachedState
+	configs    map[cacheKey]cachedConfig
+	dependents map[cacheKey]cachedDependents
+}
+
+// queryCache is shared by every manager and watcher
+var queryCache = &statusCache{
+	ttl:        statusCacheTTL,
+	states:     make(map[cacheKey]cachedState),
+	configs:    make(map[cacheKey]cachedConfig),
+	dependents: make(map[cacheKey]cachedDependents),
+}
+
+// query returns the cached state of regName, querying the backend when the
+// entry is missing or older than the TTL
+func (c *statusCache) query(ctx context.Context, ctrl Controller, regName string, counter *queryCounter) (ServiceState, error) {
+	key := keyFor(ctrl, regName)
+	c.mu.Lock()
+	entry, ok := c.states[key]
+	c.mu.Unlock()
+	if ok && time.Since(entry.at) < c.ttl {
+		counter.add(true)
+		return entry.state, nil
+	}
+
+	counter.add(false)
+	state, err := ctrl.Query(ctx, regName)
+	if err == nil {
+		c.storeState(ctrl, regName, state)
+	}
+	return state, err
+}
+
+// queryConfig is query for the registered configuration
+func (c *statusCache) queryConfig(ctx context.Context, ctrl Controller, regName string, counter *queryCounter) (ServiceConfig, error) {
+	key := keyFor(ctrl, regName)
+	c.mu.Lock()
+	entry, ok := c.configs[key]
+	c.mu.Unlock()
+	if ok && time.Since(entry.at) < c.ttl {
+		counter.add(true)
+		return entry.cfg, nil
+	}
+
+	counter.add(false)
+	cfg, err := ctrl.QueryConfig(ctx, regName)
+	if err == nil {
+		c.mu.Lock()
+		c.configs[key] = cachedConfig{cfg: cfg, at: time.Now()}
+		c.mu.Unlock()
+	}
+	return cfg, err
+}
+
+// queryDependents is query for the names of the dependent services
+func (c *statusCache) queryDependents(ctx context.Context, ctrl Controller, regName string, counter *queryCounter) ([]string, error) {
+	key := keyFor(ctrl, regName)
+	c.mu.Lock()
+	entry, ok := c.dependents[key]
+	c.mu.Unlock()
+	if ok && time.Since(entry.at) < c.ttl {
+		counter.add(true)
+		return entry.names, nil
+	}
+
+	counter.add(false)
+	names, err := ctrl.Dependents(ctx, regName)
+	if err == nil {
+		c.mu.Lock()
+		c.dependents[key] = cachedDependents{names: names, at: time.Now()}
+		c.mu.Unlock()
+	}
+	return names, err
+}
+
+// storeState records a fresh state observed elsewhere (the watcher's polls)
+func (c *statusCache) storeState(ctrl Controller, regName string, state ServiceState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[keyFor(ctrl, regName)] = cachedState{state: state, at: time.Now()}
+}
+
+// invalidate drops every entry of regName
+func (c *statusCache) invalidate(ctrl Controller, regName string) {
+	key := keyFor(ctrl, regName)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.states, key)
+	delete(c.configs, key)
+	delete(c.dependents, key)
+}
+
+// cachedState returns the state of this manager's variant through the cache
+func (m *Manager) cachedState(ctx context.Context, counter *queryCounter) ServiceState {
+	state, _ := queryCache.query(ctx, m.ctrl, m.variant.RegistryName, counter)
+	return state
+}
+
+// cachedConfig returns the configuration of this manager's variant through
+// the cache
+func (m *Manager) cachedConfig(ctx context.Context, counter *queryCounter) (ServiceConfig, error) {
+	return queryCache.queryConfig(ctx, m.ctrl, m.variant.RegistryName, counter)
+}
+
+// invalidateStatus drops the cached state and configuration of this
+// manager's variant; lifecycle operations call it when they finish
+func (m *Manager) invalidateStatus() {
+	queryCache.invalidate(m.ctrl, m.variant.RegistryName)
+}
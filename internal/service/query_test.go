@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueryAllCachesDependencies(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	dep := newDependentManager(t, m, ctrl)
+	depVariant := dep.variant
+	depVariant.Family = "dependiente"
+	registry := map[string][]Variant{
+		m.variant.Family:  {m.variant},
+		depVariant.Family: {depVariant},
+	}
+
+	first := queryAll(ctx, ctrl, registry)
+	fs := first.Families[m.variant.Family]
+	if len(fs.Dependents) != 1 || fs.Dependents[0].Name != depVariant.RegistryName {
+		t.Fatalf("Dependents = %+v, se esperaba %s", fs.Dependents, depVariant.RegistryName)
+	}
+	deps := first.Families[depVariant.Family].Dependencies
+	if len(deps) != 1 || deps[0].Name != m.variant.RegistryName || deps[0].Status != StatusRunning {
+		t.Fatalf("Dependencies = %+v, se esperaba %s en ejecución", deps, m.variant.RegistryName)
+	}
+
+	// Within the TTL a second round is answered entirely by the cache
+	second := queryAll(ctx, ctrl, registry)
+	if second.Stats.Queries != 0 || second.Stats.CacheHits == 0 {
+		t.Errorf("segunda ronda: %d consultas, %d aciertos; se esperaba todo desde la caché",
+			second.Stats.Queries, second.Stats.CacheHits)
+	}
+}
+
+// tracedController is a backend whose dynamic type is not comparable
+type tracedController struct {
+	*MemoryController
+	trace []string
+}
+
+func TestQueryCacheNonComparableBackend(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	if err := m.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+	traced := tracedController{MemoryController: ctrl}
+
+	// Used as a map key, the interface value would panic here
+	report := queryAll(ctx, traced, map[string][]Variant{m.variant.Family: {m.variant}})
+	if got := report.Families[m.variant.Family].LocalStatus; got != StatusRunning {
+		t.Errorf("LocalStatus = %v, se esperaba %v", got, StatusRunning)
+	}
+	queryCache.invalidate(traced, m.variant.RegistryName)
+}
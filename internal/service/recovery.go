@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Recovery Policy
+// ══════════════════════════════════════════════════════════════
+// The recovery policy is what the SCM does when the service process dies
+// (`sc failure` / `sc failureflag`). Each variant carries one; it is applied
+// at install and can be changed afterwards from the TUI.
+
+// RecoveryActionType is the action the SCM takes after a failure
+type RecoveryActionType string
+
+const (
+	// RecoveryNone takes no action
+	RecoveryNone RecoveryActionType = "none"
+	// RecoveryRestart restarts the service
+	RecoveryRestart RecoveryActionType = "restart"
+	// RecoveryRun runs RecoveryPolicy.Command
+	RecoveryRun RecoveryActionType = "run"
+	// RecoveryReboot reboots the computer
+	RecoveryReboot RecoveryActionType = "reboot"
+)
+
+// RecoveryActionTypes lists the action types in the order the TUI cycles them
+var RecoveryActionTypes = []RecoveryActionType{RecoveryNone, RecoveryRestart, RecoveryRun, RecoveryReboot}
+
+// MaxRecoveryActions is the number of failure actions the policy holds
+// (first, second and subsequent failures, as in services.msc)
+const MaxRecoveryActions = 3
+
+// maxRecoveryDelay and maxResetPeriod bound the values accepted by Validate
+const (
+	maxRecoveryDelay = 24 * time.Hour
+	maxResetPeriod   = 30 * 24 * time.Hour
+)
+
+// Label returns the Spanish name of the action for display
+func (t RecoveryActionType) Label() string {
+	switch t {
+	case RecoveryRestart:
+		return "Reiniciar servicio"
+	case RecoveryRun:
+		return "Ejecutar comando"
+	case RecoveryReboot:
+		return "Reiniciar equipo"
+	default:
+		return "Ninguna acción"
+	}
+}
+
+// RecoveryAction is one entry of the failure action list
+type RecoveryAction struct {
+	Type  RecoveryActionType
+	Delay time.Duration // Wait before the action is taken
+}
+
+// RecoveryPolicy describes how the SCM reacts to service failures
+type RecoveryPolicy struct {
+	// ResetPeriod is the failure-free time after which the failure count resets
+	ResetPeriod time.Duration
+	// Actions are taken on the first, second, ... failure; the last one
+	// repeats for every subsequent failure
+	Actions []RecoveryAction
+	// Command is run by RecoveryRun actions
+	Command string
+	// NonCrashFailures also triggers the actions when the service stops
+	// itself with a non-zero exit code (sc failureflag)
+	NonCrashFailures bool
+}
+
+// DefaultRecoveryPolicy returns the policy installed for a service family.
+// The scale daemon holds a serial port, so it backs off to give the device
+// time to be released; the ticket daemon restarts at a steady pace.
+func DefaultRecoveryPolicy(family string) RecoveryPolicy {
+	switch family {
+	case "scale":
+		return RecoveryPolicy{
+			ResetPeriod: 24 * time.Hour,
+			Actions: []RecoveryAction{
+				{Type: RecoveryRestart, Delay: 5 * time.Second},
+				{Type: RecoveryRestart, Delay: 15 * time.Second},
+				{Type: RecoveryRestart, Delay: time.Minute},
+			},
+			NonCrashFailures: true,
+		}
+	default:
+		return RecoveryPolicy{
+			ResetPeriod: 24 * time.Hour,
+			Actions: []RecoveryAction{
+				{Type: RecoveryRestart, Delay: 5 * time.Second},
+				{Type: RecoveryRestart, Delay: 5 * time.Second},
+				{Type: RecoveryRestart, Delay: 5 * time.Second},
+			},
+			NonCrashFailures: true,
+		}
+	}
+}
+
+// Validate checks the policy before it is handed to the backend
+func (p RecoveryPolicy) Validate() error {
+	if p.ResetPeriod < 0 || p.ResetPeriod > maxResetPeriod {
+		return fmt.Errorf("período de restablecimiento fuera de rango (0 a %s)", FormatDuration(maxResetPeriod))
+	}
+	if p.ResetPeriod%time.Second != 0 {
+		return fmt.Errorf("el período de restablecimiento debe expresarse en segundos")
+	}
+	if len(p.Actions) > MaxRecoveryActions {
+		return fmt.Errorf("máximo %d acciones de recuperación", MaxRecoveryActions)
+	}
+
+	runs := false
+	for i, a := range p.Actions {
+		switch a.Type {
+		case RecoveryNone, RecoveryRestart, RecoveryReboot:
+		case RecoveryRun:
+			runs = true
+		default:
+			return fmt.Errorf("acción %d: tipo desconocido %q", i+1, a.Type)
+		}
+		if a.Delay < 0 || a.Delay > maxRecoveryDelay {
+			return fmt.Errorf("acción %d: espera fuera de rango (0 a %s)", i+1, FormatDuration(maxRecoveryDelay))
+		}
+		if a.Delay%time.Millisecond != 0 {
+			return fmt.Errorf("acción %d: la espera debe expresarse en milisegundos", i+1)
+		}
+	}
+
+	cmd := strings.TrimSpace(p.Command)
+	switch {
+	case runs && cmd == "":
+		return fmt.Errorf("la acción 'Ejecutar comando' requiere un comando")
+	case !runs && cmd != "":
+		return fmt.Errorf("hay un comando definido pero ninguna acción 'Ejecutar comando'")
+	case strings.ContainsAny(p.Command, "\r\n\x00"):
+		return fmt.Errorf("el comando contiene caracteres no permitidos")
+	case len(p.Command) > 1024:
+		return fmt.Errorf("el comando excede 1024 caracteres")
+	}
+	return nil
+}
+
+// Equal reports whether two policies apply the same settings
+func (p RecoveryPolicy) Equal(o RecoveryPolicy) bool {
+	pa, oa := p.trimmedActions(), o.trimmedActions()
+	if p.ResetPeriod != o.ResetPeriod || p.NonCrashFailures != o.NonCrashFailures ||
+		strings.TrimSpace(p.Command) != strings.TrimSpace(o.Command) || len(pa) != len(oa) {
+		return false
+	}
+	for i := range pa {
+		if pa[i] != oa[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// trimmedActions drops trailing no-op actions, which the SCM does not report back
+func (p RecoveryPolicy) trimmedActions() []RecoveryAction {
+	actions := p.Actions
+	for len(actions) > 0 {
+		last := actions[len(actions)-1]
+		if last.Type != RecoveryNone || last.Delay != 0 {
+			break
+		}
+		actions = actions[:len(actions)-1]
+	}
+	return actions
+}
+
+// scActions renders the action list in `sc failure actions=` syntax
+// ("restart/5000/run/60000"); an empty list clears all actions.
+func (p RecoveryPolicy) scActions() string {
+	parts := make([]string, 0, 2*len(p.Actions))
+	for _, a := range p.Actions {
+		t := a.Type
+		if t == RecoveryNone {
+			t = "" // sc spells "no action" as an empty action name
+		}
+		parts = append(parts, string(t), fmt.Sprint(a.Delay.Milliseconds()))
+	}
+	return strings.Join(parts, "/")
+}
+
+// Summary renders the policy on one line for status displays
+func (p RecoveryPolicy) Summary() string {
+	actions := p.trimmedActions()
+	if len(actions) == 0 {
+		return "Sin acciones de recuperación"
+	}
+	steps := make([]string, 0, len(actions))
+	for _, a := range actions {
+		steps = append(steps, fmt.Sprintf("%s (%s)", a.Type.Label(), FormatDuration(a.Delay)))
+	}
+	return fmt.Sprintf("%s · restablecer tras %s", strings.Join(steps, " → "), FormatDuration(p.ResetPeriod))
+}
+
+// FormatDuration renders a duration in the largest whole unit that fits
+// ("5s", "15min", "1d")
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "0s"
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dmin", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	default:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Manager Operations
+// ══════════════════════════════════════════════════════════════
+
+// QueryRecovery reads back the recovery policy applied to the installed
+// service (`sc qfailure` / `sc qfailureflag`)
+func (m *Manager) QueryRecovery(ctx context.Context) (RecoveryPolicy, error) {
+	if m.cachedState(ctx, nil).Status == StatusNotInstalled {
+		return RecoveryPolicy{}, m.opError("qfailure", CategoryNotInstalled, nil, "el servicio no está instalado")
+	}
+	p, err := m.ctrl.QueryFailure(ctx, m.variant.RegistryName)
+	if err != nil {
+		return RecoveryPolicy{}, m.opError("qfailure", CategoryUnknown, err, "leer política de recuperación: %v", err)
+	}
+	return p, nil
+}
+
+// RecoveryPolicy returns the policy this manager applies at install
+func (m *Manager) RecoveryPolicy() RecoveryPolicy {
+	return m.variant.Recovery
+}
+
+// SetRecovery validates and applies a new recovery policy to the installed
+// service. The policy is kept for later reinstalls from this session.
+func (m *Manager) SetRecovery(ctx context.Context, p RecoveryPolicy) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditRecovery)
+	defer end(&err)
+	defer progressFrom(ctx).step(stepRecovery)(&err)
+	defer m.invalidateStatus()
+
+	if err := p.Validate(); err != nil {
+		return m.opError("failure", CategoryInvalid, err, "política de recuperación: %v", err)
+	}
+	if m.status(ctx) == StatusNotInstalled {
+		return m.opError("failure", CategoryNotInstalled, nil, "el servicio no está instalado")
+	}
+
+	if output, err := m.ctrl.ConfigureFailure(ctx, m.variant.RegistryName, p); err != nil {
+		if cErr := m.cancelled(ctx, "failure"); cErr != nil {
+			return cErr
+		}
+		svcErr := m.toolError("failure", output, err)
+		svcErr.Msg = "configurar recuperación: " + svcErr.Error()
+		return svcErr
+	}
+	m.variant.Recovery = p
+	return nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"github.com/adcondev/poster-tuis/internal/assets"
 	"github.com/adcondev/poster-tuis/internal/config"
+	"github.com/adcondev/poster-tuis/internal/manifest"
 )
 
 // ══════════════════════════════════════════════════════════════
@@ -16,8 +17,31 @@ type Variant struct {
 	Variant      string // Variant type: "Local", "Remoto"
 	RegistryName string // Windows service registry name
 	DisplayName  string // Human-readable display name
+	Description  string // Description shown in services.msc (optional)
 	ExeName      string // Binary filename on disk
 	Binary       []byte // Embedded binary data
+
+	// StartType is how the service starts at boot (sc start=)
+	StartType StartType
+
+	// Account is the account the service logs on as (sc obj=)
+	Account ServiceAccount
+
+	// Dependencies must be running before the service starts (sc depend=)
+	Dependencies []Dependency
+
+	// Recovery is the failure recovery policy applied at install
+	Recovery RecoveryPolicy
+
+	// Listen is where the daemon accepts connections
+	Listen ListenAddress
+
+	// Probe checks that the running daemon answers on its port
+	Probe HealthProbe
+
+	// Manifest is the build manifest entry for Binary (zero value if the
+	// manifest has no entry for ExeName)
+	Manifest manifest.Entry
 }
 
 // ══════════════════════════════════════════════════════════════
@@ -30,8 +54,9 @@ type Variant struct {
 func GetServiceRegistry() map[string][]Variant {
 	// Helper to generate Display Name and Exe Name
 	// We use the ID from config as the filename base to ensure consistency
-	makeVariant := func(id, family, variantStr, registryID, displayName string, binary []byte) Variant {
+	makeVariant := func(id, family, variantStr, registryID, displayName, description string, binary []byte) Variant {
 		exeName := registryID + ".exe"
+		entry, _ := embeddedManifest.Lookup(exeName)
 
 		return Variant{
 			ID:           id,
@@ -39,20 +64,32 @@ func GetServiceRegistry() map[string][]Variant {
 			Variant:      variantStr,
 			RegistryName: registryID, // <--- DIRECTLY FROM TASKFILE
 			DisplayName:  displayName,
+			Description:  description,
 			ExeName:      exeName,
 			Binary:       binary,
+			StartType:    StartAuto,
+			Account:      DefaultAccount(variantStr),
+			Dependencies: DefaultDependencies(family, variantStr),
+			Recovery:     DefaultRecoveryPolicy(family),
+			Listen:       DefaultListenAddress(family, variantStr),
+			Probe:        DefaultHealthProbe(family),
+			Manifest:     entry,
 		}
 	}
 
 	return map[string][]Variant{
 		"scale": {
-			makeVariant("scale-local", "scale", "Local", config.ScaleIDLocal, config.ScaleDisplayLocal, assets.BasculaLocalBinary),
+			makeVariant("scale-local", "scale", "Local", config.ScaleIDLocal, config.ScaleDisplayLocal,
+				config.ScaleDescLocal, assets.BasculaLocalBinary),
 			// ID is "scale-remoto" so UI lookup matches "Remoto"
-			makeVariant("scale-remoto", "scale", "Remoto", config.ScaleIDRemote, config.ScaleDisplayRemote, assets.BasculaRemoteBinary),
+			makeVariant("scale-remoto", "scale", "Remoto", config.ScaleIDRemote, config.ScaleDisplayRemote,
+				config.ScaleDescRemote, assets.BasculaRemoteBinary),
 		},
 		"ticket": {
-			makeVariant("ticket-local", "ticket", "Local", config.TicketIDLocal, config.TicketDisplayLocal, assets.TicketLocalBinary),
-			makeVariant("ticket-remoto", "ticket", "Remoto", config.TicketIDRemote, config.TicketDisplayRemote, assets.TicketRemoteBinary),
+			makeVariant("ticket-local", "ticket", "Local", config.TicketIDLocal, config.TicketDisplayLocal,
+				config.TicketDescLocal, assets.TicketLocalBinary),
+			makeVariant("ticket-remoto", "ticket", "Remoto", config.TicketIDRemote, config.TicketDisplayRemote,
+				config.TicketDescRemote, assets.TicketRemoteBinary),
 		},
 	}
 }
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Parsed sc.exe Output
+// ══════════════════════════════════════════════════════════════
+
+// ServiceState is the parsed result of `sc queryex`
+type ServiceState struct {
+	Name            string
+	Type            string
+	Status          Status
+	StateCode       int // Raw SERVICE_STATUS dwCurrentState (1-7)
+	PID             int
+	Win32ExitCode   int
+	ServiceExitCode int
+	CheckPoint      int
+	WaitHint        time.Duration
+}
+
+// ServiceConfig is the parsed result of `sc qc`
+type ServiceConfig struct {
+	Name         string
+	Type         string
+	StartType    string // AUTO_START, DEMAND_START, DISABLED, ...
+	DelayedStart bool   // "(DELAYED)" suffix on AUTO_START
+	ErrorControl string
+	BinaryPath   string
+	DisplayName  string
+	Description  string // From `sc qdescription`; empty if not set
+	Dependencies []string
+	Account      string // SERVICE_START_NAME
+}
+
+// ══════════════════════════════════════════════════════════════
+// Field Labels (English / Spanish)
+// ══════════════════════════════════════════════════════════════
+
+// scFieldLabels maps each canonical field to the labels sc.exe prints on
+// English and Spanish Windows. Labels are compared after normalizeSCKey,
+// so accented characters survive both UTF-8 and OEM code page output.
+var scFieldLabels = map[string][]string{
+	"SERVICE_NAME":       {"SERVICE_NAME", "NOMBRE_SERVICIO"},
+	"DISPLAY_NAME":       {"DISPLAY_NAME", "NOMBRE_MOSTRAR"},
+	"TYPE":               {"TYPE", "TIPO"},
+	"STATE":              {"STATE", "ESTADO"},
+	"WIN32_EXIT_CODE":    {"WIN32_EXIT_CODE", "CÓD_SALIDA_WIN32"},
+	"SERVICE_EXIT_CODE":  {"SERVICE_EXIT_CODE", "CÓD_SALIDA_SERVICIO"},
+	"CHECKPOINT":         {"CHECKPOINT", "PUNTO_COMPROB."},
+	"WAIT_HINT":          {"WAIT_HINT", "INDICACIÓN_ESPERA"},
+	"PID":                {"PID"},
+	"FLAGS":              {"FLAGS", "MARCAS"},
+	"START_TYPE":         {"START_TYPE", "TIPO_INICIO"},
+	"ERROR_CONTROL":      {"ERROR_CONTROL", "CONTROL_ERROR"},
+	"BINARY_PATH_NAME":   {"BINARY_PATH_NAME", "NOMBRE_RUTA_BINARIO"},
+	"LOAD_ORDER_GROUP":   {"LOAD_ORDER_GROUP", "GRUPO_ORDEN_CARGA"},
+	"TAG":                {"TAG", "ETIQUETA"},
+	"DEPENDENCIES":       {"DEPENDENCIES", "DEPENDENCIAS"},
+	"SERVICE_START_NAME": {"SERVICE_START_NAME", "NOMBRE_INICIO_SERVICIO"},
+	"DESCRIPTION":        {"DESCRIPTION", "DESCRIPCIÓN"},
+	"RESET_PERIOD":       {"RESET_PERIOD", "PERÍODO_RESTABLECIMIENTO"},
+	"REBOOT_MESSAGE":     {"REBOOT_MESSAGE", "MENSAJE_REINICIO"},
+	"COMMAND_LINE":       {"COMMAND_LINE", "LÍNEA_COMANDOS"},
+	"FAILURE_ACTIONS":    {"FAILURE_ACTIONS", "ACCIONES_ERROR"},
+	"NONCRASH_FAILURES":  {"FAILURE_ACTIONS_ON_NONCRASH_FAILURES", "ACCIONES_ERROR_EN_ERRORES_SIN_BLOQUEO"},
+}
+
+// scLabelIndex resolves a normalized label to its canonical field
+var scLabelIndex = func() map[string]string {
+	index := make(map[string]string)
+	for field, labels := range scFieldLabels {
+		for _, label := range labels {
+			index[normalizeSCKey(label)] = field
+		}
+	}
+	return index
+}()
+
+// normalizeSCKey uppercases a label and drops non-ASCII bytes, trailing
+// dots and a parenthesized unit ("RESET_PERIOD (in seconds)"), so
+// "CÓD_SALIDA_WIN32" matches whatever code page sc.exe used.
+func normalizeSCKey(s string) string {
+	if i := strings.Index(s, "("); i > 0 {
+		s = s[:i]
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x80 {
+			b.WriteByte(s[i])
+		}
+	}
+	return strings.TrimRight(strings.ToUpper(strings.TrimSpace(b.String())), ".")
+}
+
+// scFailureActionPattern matches one `sc qfailure` action line
+// ("RESTART -- Delay = 5000 milliseconds." / "REINICIAR -- Retraso = 5000 milisegundos.")
+var scFailureActionPattern = regexp.MustCompile(`(?m)([A-Za-z][A-Za-z ]*?)\s*--\s*[^=\n]*=\s*(\d+)`)
+
+// scActionLabels maps the action names sc qfailure prints (English /
+// Spanish) to action types
+var scActionLabels = map[string]RecoveryActionType{
+	"RESTART":          RecoveryRestart,
+	"REINICIAR":        RecoveryRestart,
+	"RUN PROCESS":      RecoveryRun,
+	"EJECUTAR PROCESO": RecoveryRun,
+	"REBOOT":           RecoveryReboot,
+	"REINICIAR EQUIPO": RecoveryReboot,
+	"NONE":             RecoveryNone,
+	"NINGUNA":          RecoveryNone,
+}
+
+// scErrorPattern matches "[SC] OpenService FAILED 1060:" and the Spanish
+// "[SC] OpenService ERROR 1060:" variant
+var scErrorPattern = regexp.MustCompile(`\[SC\][^\n]*?\s(\d{1,5}):`)
+
+// ══════════════════════════════════════════════════════════════
+// Parsers
+// ══════════════════════════════════════════════════════════════
+
+// scFields splits sc.exe output into canonical field → values. Continuation
+// lines with an empty label (e.g. additional DEPENDENCIES) are appended to
+// the previous field.
+func scFields(output []byte) map[string][]string {
+	fields := make(map[string][]string)
+	last := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		label, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		key := normalizeSCKey(label)
+		if key == "" {
+			if last != "" && value != "" {
+				fields[last] = append(fields[last], value)
+			}
+			continue
+		}
+
+		field, known := scLabelIndex[key]
+		if !known {
+			last = ""
+			continue
+		}
+		if value != "" {
+			fields[field] = append(fields[field], value)
+		}
+		last = field
+	}
+	return fields
+}
+
+// firstField returns the first value recorded for a field
+func firstField(fields map[string][]string, field string) string {
+	if values := fields[field]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// leadingInt parses the first whitespace-separated token of a value as an
+// integer, accepting decimal ("4  RUNNING") and hex ("0x7d0") forms.
+func leadingInt(value string) int {
+	tokens := strings.Fields(value)
+	if len(tokens) == 0 {
+		return 0
+	}
+	n, err := strconv.ParseInt(tokens[0], 0, 64)
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// trailingToken returns the value without its leading numeric code
+// ("2   AUTO_START  (DELAYED)" → "AUTO_START  (DELAYED)")
+func trailingToken(value string) string {
+	tokens := strings.Fields(value)
+	if len(tokens) > 1 {
+		if _, err := strconv.ParseInt(tokens[0], 0, 64); err == nil {
+			return strings.Join(tokens[1:], " ")
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// statusFromStateCode maps dwCurrentState to the Status enum. The numeric
+// code is used because the trailing state name may be localized.
+func statusFromStateCode(code int, name string) Status {
+	switch code {
+	case 1:
+		return StatusStopped
+	case 2, 5: // START_PENDING, CONTINUE_PENDING
+		return StatusStartPending
+	case 3, 6: // STOP_PENDING, PAUSE_PENDING
+		return StatusStopPending
+	case 4:
+		return StatusRunning
+	}
+
+	switch {
+	case strings.Contains(name, "STOP_PENDING"):
+		return StatusStopPending
+	case strings.Contains(name, "START_PENDING"):
+		return StatusStartPending
+	case strings.Contains(name, "RUNNING"):
+		return StatusRunning
+	case strings.Contains(name, "STOPPED"):
+		return StatusStopped
+	default:
+		return StatusUnknown
+	}
+}
+
+// stateCodeOf returns the SERVICE_STATUS dwCurrentState for a Status
+func stateCodeOf(status Status) int {
+	switch status {
+	case StatusStopped:
+		return 1
+	case StatusStartPending:
+		return 2
+	case StatusStopPending:
+		return 3
+	case StatusRunning:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// ParseSCError extracts the Win32 error code from a failed sc.exe call
+func ParseSCError(output []byte) (int, bool) {
+	match := scErrorPattern.FindSubmatch(output)
+	if match == nil {
+		return 0, false
+	}
+	code, err := strconv.Atoi(string(match[1]))
+	if err != nil {
+		return 0, false
+	}
+	return code, true
+}
+
+// ParseQueryEx parses `sc query` or `sc queryex` output. A missing service
+// (Win32 1060) yields StatusNotInstalled; output without a STATE field
+// yields StatusUnknown.
+func ParseQueryEx(output []byte) ServiceState {
+	if code, ok := ParseSCError(output); ok {
+		if code == win32ServiceDoesNotExist {
+			return ServiceState{Status: StatusNotInstalled}
+		}
+		return ServiceState{Status: StatusUnknown}
+	}
+
+	fields := scFields(output)
+	stateValue := firstField(fields, "STATE")
+	if stateValue == "" {
+		return ServiceState{Name: firstField(fields, "SERVICE_NAME"), Status: StatusUnknown}
+	}
+
+	stateCode := leadingInt(stateValue)
+	return ServiceState{
+		Name:            firstField(fields, "SERVICE_NAME"),
+		Type:            trailingToken(firstField(fields, "TYPE")),
+		Status:          statusFromStateCode(stateCode, strings.ToUpper(stateValue)),
+		StateCode:       stateCode,
+		PID:             leadingInt(firstField(fields, "PID")),
+		Win32ExitCode:   leadingInt(firstField(fields, "WIN32_EXIT_CODE")),
+		ServiceExitCode: leadingInt(firstField(fields, "SERVICE_EXIT_CODE")),
+		CheckPoint:      leadingInt(firstField(fields, "CHECKPOINT")),
+		WaitHint:        time.Duration(leadingInt(firstField(fields, "WAIT_HINT"))) * time.Millisecond,
+	}
+}
+
+// ParseQC parses `sc qc` output
+func ParseQC(output []byte) ServiceConfig {
+	fields := scFields(output)
+
+	startType := strings.ToUpper(trailingToken(firstField(fields, "START_TYPE")))
+	delayed := strings.Contains(startType, "DELAYED")
+	if tokens := strings.Fields(startType); len(tokens) > 0 {
+		startType = tokens[0]
+	}
+
+	return ServiceConfig{
+		Name:         firstField(fields, "SERVICE_NAME"),
+		Type:         trailingToken(firstField(fields, "TYPE")),
+		StartType:    startType,
+		DelayedStart: delayed,
+		ErrorControl: trailingToken(firstField(fields, "ERROR_CONTROL")),
+		BinaryPath:   firstField(fields, "BINARY_PATH_NAME"),
+		DisplayName:  firstField(fields, "DISPLAY_NAME"),
+		Dependencies: fields["DEPENDENCIES"],
+		Account:      firstField(fields, "SERVICE_START_NAME"),
+	}
+}
+
+// ParseEnumDepend parses `sc enumdepend` output into the dependent service names
+func ParseEnumDepend(output []byte) []string {
+	return scFields(output)["SERVICE_NAME"]
+}
+
+// ParseQDescription parses `sc qdescription` output
+func ParseQDescription(output []byte) string {
+	return firstField(scFields(output), "DESCRIPTION")
+}
+
+// ParseQFailure parses `sc qfailure` output. Action lines after
+// FAILURE_ACTIONS carry no label, so they are matched by pattern rather than
+// through scFields.
+func ParseQFailure(output []byte) RecoveryPolicy {
+	fields := scFields(output)
+	policy := RecoveryPolicy{
+		ResetPeriod: time.Duration(leadingInt(firstField(fields, "RESET_PERIOD"))) * time.Second,
+		Command:     firstField(fields, "COMMAND_LINE"),
+	}
+
+	text := string(output)
+	for _, label := range scFieldLabels["FAILURE_ACTIONS"] {
+		if i := strings.Index(text, label); i >= 0 {
+			text = text[i+len(label):]
+			break
+		}
+	}
+	for _, match := range scFailureActionPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.ToUpper(strings.Join(strings.Fields(match[1]), " "))
+		actionType, ok := scActionLabels[name]
+		if !ok {
+			actionType = RecoveryNone
+		}
+		ms, _ := strconv.Atoi(match[2])
+		policy.Actions = append(policy.Actions, RecoveryAction{
+			Type:  actionType,
+			Delay: time.Duration(ms) * time.Millisecond,
+		})
+	}
+	return policy
+}
+
+// ParseQFailureFlag parses `sc qfailureflag` output
+func ParseQFailureFlag(output []byte) bool {
+	value := strings.ToUpper(firstField(scFields(output), "NONCRASH_FAILURES"))
+	return strings.HasPrefix(value, "TRUE") || strings.HasPrefix(value, "VERDADERO")
+}
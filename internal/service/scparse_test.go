@@ -0,0 +1,181 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scLocales are the Windows display languages of the captured sc.exe
+// fixtures under testdata/sc. Both captures describe the same service.
+var scLocales = []string{"en-US", "es-MX"}
+
+// scFixture reads a captured sc.exe output
+func scFixture(t *testing.T, locale, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "sc", locale, name+".txt"))
+	if err != nil {
+		t.Fatalf("leer fixture: %v", err)
+	}
+	return data
+}
+
+// toCodePage850 re-encodes the accented capitals sc.exe prints in its labels
+// as a console on the OEM code page would
+func toCodePage850(data []byte) []byte {
+	return []byte(strings.NewReplacer("Ó", "\xe0", "Í", "\xd6", "á", "\xa0").Replace(string(data)))
+}
+
+// forEachLocale runs fn once per captured locale
+func forEachLocale(t *testing.T, fn func(t *testing.T, locale string)) {
+	for _, locale := range scLocales {
+		t.Run(locale, func(t *testing.T) { fn(t, locale) })
+	}
+}
+
+func TestParseQueryEx(t *testing.T) {
+	forEachLocale(t, func(t *testing.T, locale string) {
+		want := ServiceState{
+			Name:      "R2k_BasculaServicio_Local",
+			Type:      "WIN32_OWN_PROCESS",
+			Status:    StatusRunning,
+			StateCode: 4,
+			PID:       2212,
+		}
+		if got := ParseQueryEx(scFixture(t, locale, "queryex")); !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseQueryEx = %+v\nse esperaba      %+v", got, want)
+		}
+
+		want.Status, want.StateCode = StatusStartPending, 2
+		want.CheckPoint, want.WaitHint = 1, 2*time.Second
+		if got := ParseQueryEx(scFixture(t, locale, "queryex-pending")); !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseQueryEx pendiente = %+v\nse esperaba      %+v", got, want)
+		}
+	})
+}
+
+func TestParseQueryExNotInstalled(t *testing.T) {
+	forEachLocale(t, func(t *testing.T, locale string) {
+		got := ParseQueryEx(scFixture(t, locale, "error-1060"))
+		if got != (ServiceState{Status: StatusNotInstalled}) {
+			t.Errorf("ParseQueryEx = %+v, se esperaba NotInstalled", got)
+		}
+	})
+}
+
+func TestParseQC(t *testing.T) {
+	forEachLocale(t, func(t *testing.T, locale string) {
+		want := ServiceConfig{
+			Name:         "R2k_BasculaServicio_Local",
+			Type:         "WIN32_OWN_PROCESS",
+			StartType:    "AUTO_START",
+			DelayedStart: true,
+			ErrorControl: "NORMAL",
+			BinaryPath:   `"C:\Program Files\R2k_BasculaServicio_Local\R2k_BasculaServicio_Local.exe"`,
+			DisplayName:  "Servicio de Báscula (Local)",
+			Dependencies: []string{"Spooler", "Tcpip"},
+			Account:      `NT AUTHORITY\LocalService`,
+		}
+		if got := ParseQC(scFixture(t, locale, "qc")); !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseQC = %+v\nse esperaba %+v", got, want)
+		}
+	})
+}
+
+func TestParseQFailure(t *testing.T) {
+	forEachLocale(t, func(t *testing.T, locale string) {
+		want := RecoveryPolicy{
+			ResetPeriod: 24 * time.Hour,
+			Actions: []RecoveryAction{
+				{Type: RecoveryRestart, Delay: 5 * time.Second},
+				{Type: RecoveryRestart, Delay: 30 * time.Second},
+				{Type: RecoveryReboot, Delay: time.Minute},
+			},
+		}
+		if got := ParseQFailure(scFixture(t, locale, "qfailure")); !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseQFailure = %+v\nse esperaba      %+v", got, want)
+		}
+	})
+}
+
+func TestParseQFailureFlag(t *testing.T) {
+	forEachLocale(t, func(t *testing.T, locale string) {
+		if !ParseQFailureFlag(scFixture(t, locale, "qfailureflag")) {
+			t.Error("ParseQFailureFlag = false, se esperaba true")
+		}
+		// A query that printed no flag reports it unset
+		if ParseQFailureFlag(scFixture(t, locale, "qfailure")) {
+			t.Error("ParseQFailureFlag sin la marca = true")
+		}
+	})
+}
+
+func TestParseEnumDepend(t *testing.T) {
+	forEachLocale(t, func(t *testing.T, locale string) {
+		want := []string{"Fax", "PrintNotify"}
+		if got := ParseEnumDepend(scFixture(t, locale, "enumdepend")); !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseEnumDepend = %q, se esperaba %q", got, want)
+		}
+	})
+}
+
+func TestParseQDescription(t *testing.T) {
+	forEachLocale(t, func(t *testing.T, locale string) {
+		want := "Lee el peso de la báscula: puerto COM3, 9600 baudios."
+		if got := ParseQDescription(scFixture(t, locale, "qdescription")); got != want {
+			t.Errorf("ParseQDescription = %q, se esperaba %q", got, want)
+		}
+	})
+}
+
+func TestParseSCError(t *testing.T) {
+	forEachLocale(t, func(t *testing.T, locale string) {
+		for _, code := range []int{win32AccessDenied, win32ServiceDoesNotExist, win32ServiceMarkedDelete, win32ServiceExists} {
+			name := "error-" + strconv.Itoa(code)
+			if got, ok := ParseSCError(scFixture(t, locale, name)); !ok || got != code {
+				t.Errorf("ParseSCError(%s) = %d, %v; se esperaba %d", name, got, ok, code)
+			}
+		}
+		// Successful output carries no error code
+		if got, ok := ParseSCError(scFixture(t, locale, "qc")); ok {
+			t.Errorf("ParseSCError(qc) = %d, se esperaba sin código", got)
+		}
+	})
+}
+
+func TestParseCodePage850(t *testing.T) {
+	// The Spanish labels CÓD_SALIDA_WIN32, INDICACIÓN_ESPERA and
+	// PERÍODO_RESTABLECIMIENTO must match whatever code page sc.exe used
+	state := ParseQueryEx(toCodePage850(scFixture(t, "es-MX", "queryex-pending")))
+	if state.Status != StatusStartPending || state.WaitHint != 2*time.Second {
+		t.Errorf("ParseQueryEx (850) = %+v", state)
+	}
+	exited := ParseQueryEx(toCodePage850(scFixture(t, "es-MX", "enumdepend")))
+	if exited.Win32ExitCode != 1077 {
+		t.Errorf("CÓD_SALIDA_WIN32 (850) = %d, se esperaba 1077", exited.Win32ExitCode)
+	}
+	policy := ParseQFailure(toCodePage850(scFixture(t, "es-MX", "qfailure")))
+	if policy.ResetPeriod != 24*time.Hour || len(policy.Actions) != 3 {
+		t.Errorf("ParseQFailure (850) = %+v", policy)
+	}
+}
+
+func TestNormalizeSCKey(t *testing.T) {
+	tests := map[string]string{
+		"        STATE              ":   "STATE",
+		"CÓD_SALIDA_WIN32":              "CD_SALIDA_WIN32",
+		"C\xe0D_SALIDA_WIN32":           "CD_SALIDA_WIN32",
+		"PUNTO_COMPROB.     ":           "PUNTO_COMPROB",
+		"RESET_PERIOD (in seconds)    ": "RESET_PERIOD",
+		"service_name":                  "SERVICE_NAME",
+	}
+	for in, want := range tests {
+		if got := normalizeSCKey(in); got != want {
+			t.Errorf("normalizeSCKey(%q) = %q, se esperaba %q", in, got, want)
+		}
+	}
+}
@@ -15,14 +15,70 @@ import (
 // Service Manager
 // ══════════════════════════════════════════════════════════════
 
-// Manager handles Windows service lifecycle operations for a specific variant
+// Manager handles Windows service lifecycle operations for a specific variant.
+// Lifecycle methods take a context: cancelling it aborts the wait loops and
+// kills any sc.exe/systemctl process still running.
 type Manager struct {
 	variant Variant
+	ctrl    Controller
+	opts    ManagerOptions
+}
+
+// ManagerOptions holds how long each lifecycle step may wait for the
+// service. Zero fields take the value from DefaultManagerOptions.
+type ManagerOptions struct {
+	StartTimeout  time.Duration // Wait for RUNNING after a start
+	StopTimeout   time.Duration // Wait for STOPPED after a stop (then force-kill)
+	KillTimeout   time.Duration // Wait for STOPPED after a force-kill
+	DeleteTimeout time.Duration // Wait for a service marked for deletion to disappear
+}
+
+// DefaultManagerOptions returns the timeouts used unless overridden
+func DefaultManagerOptions() ManagerOptions {
+	return ManagerOptions{
+		StartTimeout:  15 * time.Second,
+		StopTimeout:   15 * time.Second,
+		KillTimeout:   5 * time.Second,
+		DeleteTimeout: 10 * time.Second,
+	}
+}
+
+// withDefaults fills the zero fields of o from DefaultManagerOptions
+func (o ManagerOptions) withDefaults() ManagerOptions {
+	d := DefaultManagerOptions()
+	if o.StartTimeout <= 0 {
+		o.StartTimeout = d.StartTimeout
+	}
+	if o.StopTimeout <= 0 {
+		o.StopTimeout = d.StopTimeout
+	}
+	if o.KillTimeout <= 0 {
+		o.KillTimeout = d.KillTimeout
+	}
+	if o.DeleteTimeout <= 0 {
+		o.DeleteTimeout = d.DeleteTimeout
+	}
+	return o
 }
 
 // NewManager creates a manager for a specific service variant
 func NewManager(variant Variant) *Manager {
-	return &Manager{variant: variant}
+	return NewManagerWithController(variant, defaultController)
+}
+
+// NewManagerWithController creates a manager that drives the given backend
+func NewManagerWithController(variant Variant, ctrl Controller) *Manager {
+	return &Manager{variant: variant, ctrl: ctrl, opts: DefaultManagerOptions()}
+}
+
+// Options returns the timeouts this manager applies
+func (m *Manager) Options() ManagerOptions {
+	return m.opts
+}
+
+// SetOptions changes the timeouts this manager applies
+func (m *Manager) SetOptions(opts ManagerOptions) {
+	m.opts = opts.withDefaults()
 }
 
 // validateServiceVariantFields checks that ServiceVariant fields are safe
@@ -36,10 +92,30 @@ func validateServiceVariantFields(variant Variant) error {
 	if !isValidDisplayName(variant.DisplayName) {
 		return fmt.Errorf("invalid DisplayName: contains unsafe characters")
 	}
+	// Check Description
+	if !isValidDescription(variant.Description) {
+		return fmt.Errorf("invalid Description: contains unsafe characters or is too long")
+	}
 	// Check ExeName
 	if !isValidFileName(variant.ExeName) {
 		return fmt.Errorf("invalid ExeName: contains unsafe characters")
 	}
+	// Check service account
+	if err := variant.Account.Validate(); err != nil {
+		return fmt.Errorf("invalid Account: %w", err)
+	}
+	// Check dependencies
+	if err := validateDependencies(variant); err != nil {
+		return fmt.Errorf("invalid Dependencies: %w", err)
+	}
+	// Check start type
+	if err := variant.StartType.Validate(); err != nil {
+		return fmt.Errorf("invalid StartType: %w", err)
+	}
+	// Check recovery policy
+	if err := variant.Recovery.Validate(); err != nil {
+		return fmt.Errorf("invalid Recovery: %w", err)
+	}
 	return nil
 }
 
@@ -81,6 +157,25 @@ func isValidDisplayName(name string) bool {
 	return true
 }
 
+// maxDescriptionLen bounds the service description (the SCM accepts more,
+// but services.msc only shows the first lines)
+const maxDescriptionLen = 1024
+
+// isValidDescription validates a service description. Unlike the display
+// name it may be empty (no description is set).
+func isValidDescription(desc string) bool {
+	if len(desc) > maxDescriptionLen {
+		return false
+	}
+	// Same blocklist as the display name: the value travels as an sc argument
+	for _, c := range desc {
+		if c == '"' || c == '\'' || c == '`' || c == '$' || c == '&' || c == '|' || c == ';' || c == '\n' || c == '\r' {
+			return false
+		}
+	}
+	return true
+}
+
 // isValidFileName validates that a file name is safe
 func isValidFileName(name string) bool {
 	// Bloqueamos barras y path traversal solo para el nombre de archivo (ExeName)
@@ -105,8 +200,17 @@ func hasPathTraversal(s string) bool {
 // Install / Uninstall
 // ══════════════════════════════════════════════════════════════
 
+// Upper bounds for a single tool process. They only guard against a hung
+// sc.exe/systemctl when the caller's context has no deadline of its own;
+// operation timeouts live in ManagerOptions.
+const (
+	toolTimeout   = 10 * time.Second
+	createTimeout = 15 * time.Second // sc create also validates the account
+	killTimeout   = 5 * time.Second
+)
+
 // secureScRun runs an sc action (start/stop/delete/etc.) with a validated service name.
-func secureScRun(action, regName string, extraArgs ...string) ([]byte, error) {
+func secureScRun(ctx context.Context, action, regName string, extraArgs ...string) ([]byte, error) {
 	if !isValidServiceName(regName) {
 		return nil, fmt.Errorf("invalid RegistryName")
 	}
@@ -116,8 +220,8 @@ func secureScRun(action, regName string, extraArgs ...string) ([]byte, error) {
 	}
 	args := append([]string{action, regName}, extraArgs...)
 
-	// Use a context with timeout to avoid hanging subprocesses
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Bounded even without a caller deadline; cancelling ctx kills sc.exe
+	ctx, cancel := context.WithTimeout(ctx, toolTimeout)
 	defer cancel()
 
 	//nolint:gosec // inputs validated and sc resolved via LookPath
@@ -127,7 +231,7 @@ func secureScRun(action, regName string, extraArgs ...string) ([]byte, error) {
 
 // secureTaskKillByService runs taskkill to force kill processes by service filter.
 // Validates service name and resolves executable via LookPath.
-func secureTaskKillByService(regName string) error {
+func secureTaskKillByService(ctx context.Context, regName string) error {
 	if !isValidServiceName(regName) {
 		return fmt.Errorf("invalid RegistryName")
 	}
@@ -139,7 +243,7 @@ func secureTaskKillByService(regName string) error {
 	args := []string{"/F", "/FI", filter}
 
 	// Use a short timeout for taskkill
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, killTimeout)
 	defer cancel()
 
 	//nolint:gosec // inputs validated and taskkill resolved via LookPath
@@ -172,35 +276,41 @@ func resolveAndEnsure(base, target string) (absBase, absTarget string, err error
 	return
 }
 
-// secureScFailure validates the service name and runs the failure config
-func secureScFailure(regName string) error {
+// secureScFailure validates the service name and policy and runs the failure config
+func secureScFailure(ctx context.Context, regName string, policy RecoveryPolicy) ([]byte, error) {
 	if !isValidServiceName(regName) {
-		return fmt.Errorf("invalid RegistryName")
+		return nil, fmt.Errorf("invalid RegistryName")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid recovery policy: %w", err)
 	}
 
 	scPath, err := exec.LookPath("sc")
 	if err != nil {
-		return fmt.Errorf("sc executable not found: %w", err)
+		return nil, fmt.Errorf("sc executable not found: %w", err)
 	}
 
 	// Separamos llaves de valores
 	args := []string{
 		"failure",
 		regName,
-		"reset=", "86400",
-		"actions=", "restart/5000/restart/5000/restart/5000",
+		"reset=", fmt.Sprint(int64(policy.ResetPeriod / time.Second)),
+		"actions=", policy.scActions(),
+	}
+	if policy.Command != "" {
+		args = append(args, "command=", policy.Command)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, toolTimeout)
 	defer cancel()
 
 	//nolint:gosec // inputs validated
 	cmd := exec.CommandContext(ctx, scPath, args...)
-	return cmd.Run()
+	return cmd.CombinedOutput()
 }
 
 // secureScCreate validates inputs and runs `sc create` safely without cmd.exe
-func secureScCreate(regName, binPath, displayName string) ([]byte, error) {
+func secureScCreate(ctx context.Context, regName, binPath, displayName string, opts CreateOptions) ([]byte, error) {
 	if !isValidServiceName(regName) {
 		return nil, fmt.Errorf("invalid RegistryName")
 	}
@@ -210,6 +320,17 @@ func secureScCreate(regName, binPath, displayName string) ([]byte, error) {
 	if hasPathTraversal(binPath) {
 		return nil, fmt.Errorf("invalid binPath")
 	}
+	if err := opts.StartType.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid StartType: %w", err)
+	}
+	if err := opts.Account.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid Account: %w", err)
+	}
+	for _, dep := range opts.Dependencies {
+		if !isValidServiceName(dep) {
+			return nil, fmt.Errorf("invalid dependency %q", dep)
+		}
+	}
 
 	scPath, err := exec.LookPath("sc")
 	if err != nil {
@@ -222,11 +343,16 @@ func secureScCreate(regName, binPath, displayName string) ([]byte, error) {
 		"create",
 		regName,
 		"binPath=", binPath,
-		"start=", "auto",
+		"start=", string(opts.StartType),
 		"DisplayName=", displayName,
 	}
+	if len(opts.Dependencies) > 0 {
+		args = append(args, "depend=", strings.Join(opts.Dependencies, "/"))
+	}
+	// The password travels only as an argument to sc.exe; it is never logged
+	args = append(args, opts.Account.scArgs()...)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
 	defer cancel()
 
 	//nolint:gosec // inputs are strictly validated above, safe from command injection
@@ -234,124 +360,196 @@ func secureScCreate(regName, binPath, displayName string) ([]byte, error) {
 	return cmd.CombinedOutput()
 }
 
-// Install creates the Windows service: writes the embedded binary to disk
-// and registers it with the service control manager.
-func (m *Manager) Install() error {
-	// Validate ServiceVariant fields before proceeding
-	if err := validateServiceVariantFields(m.variant); err != nil {
-		return fmt.Errorf("validación de campos: %w", err)
-	}
-
-	// Pre-check: fail fast if already registered
-	currentStatus := m.CheckStatus()
-	if currentStatus != StatusNotInstalled {
-		return fmt.Errorf("el servicio ya está registrado (estado: %s) — desinstale primero", currentStatus)
-	}
-
-	// Prepare safe absolute paths and validate file name
-	programFiles := os.Getenv("ProgramFiles")
-	if programFiles == "" {
-		return fmt.Errorf("environment variable `ProgramFiles` is empty")
+// installPaths returns the validated absolute install directory and binary
+// path for this variant: {InstallRoot}\{RegistryName}\{ExeName}
+func (m *Manager) installPaths() (absTargetDir, absTargetPath string, err error) {
+	root, err := m.ctrl.InstallRoot()
+	if err != nil {
+		return "", "", err
 	}
 
-	targetDir := filepath.Join(programFiles, m.variant.RegistryName)
+	targetDir := filepath.Join(root, m.variant.RegistryName)
 	targetPath := filepath.Join(targetDir, m.variant.ExeName)
 
 	// Ensure ExeName doesn't contain path separators (extra safety)
 	if strings.ContainsAny(m.variant.ExeName, `\/`) {
-		return fmt.Errorf("invalid ExeName: contains path separator")
+		return "", "", fmt.Errorf("invalid ExeName: contains path separator")
 	}
 
-	// Resolve and ensure `targetDir` is inside `ProgramFiles`
-	_, absTargetDir, err := resolveAndEnsure(programFiles, targetDir)
+	// Resolve and ensure `targetDir` is inside the install root
+	_, absTargetDir, err = resolveAndEnsure(root, targetDir)
 	if err != nil {
-		return fmt.Errorf("invalid target directory: %w", err)
+		return "", "", fmt.Errorf("invalid target directory: %w", err)
 	}
 
 	// Resolve and ensure `targetPath` is inside the resolved `targetDir`
-	_, absTargetPath, err := resolveAndEnsure(absTargetDir, targetPath)
+	_, absTargetPath, err = resolveAndEnsure(absTargetDir, targetPath)
 	if err != nil {
-		return fmt.Errorf("invalid target path: %w", err)
+		return "", "", fmt.Errorf("invalid target path: %w", err)
 	}
+	return absTargetDir, absTargetPath, nil
+}
 
-	// 1. Create target directory (using validated absolute path)
-	//nolint:gosec // We have validated the path, so this is not vulnerable to injection
-	if err := os.MkdirAll(absTargetDir, 0750); err != nil {
-		return fmt.Errorf("crear directorio: %w", err)
+// Install creates the Windows service: writes the embedded binary to disk
+// and registers it with the service control manager. The steps run as a
+// journaled transaction; any failure rolls the machine back to its prior state.
+func (m *Manager) Install(ctx context.Context) error {
+	return m.install(ctx, false)
+}
+
+// InstallAndStart installs the service and starts it as part of the same
+// transaction: if the service does not reach RUNNING the install is undone.
+func (m *Manager) InstallAndStart(ctx context.Context) error {
+	return m.install(ctx, true)
+}
+
+// install validates the variant and runs the journaled install steps
+func (m *Manager) install(ctx context.Context, withStart bool) (err error) {
+	action := AuditInstall
+	if withStart {
+		action = AuditInstallStart
+	}
+	ctx, end := m.beginAudit(ctx, action)
+	defer end(&err)
+	defer m.invalidateStatus()
+
+	var (
+		deps                        []string
+		absTargetDir, absTargetPath string
+	)
+	err = runStep(ctx, stepPrecheck, func() (err error) {
+		deps, absTargetDir, absTargetPath, err = m.prepareInstall(ctx)
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	// 2. Write embedded binary to disk (using validated absolute path)
-	//nolint:gosec // We have validated the path, so this is not vulnerable to injection
-	if err := os.WriteFile(absTargetPath, m.variant.Binary, 0600); err != nil {
-		return fmt.Errorf("extraer binario: %w", err)
+	return m.runTransaction(ctx, "install", m.installSteps(absTargetDir, absTargetPath, deps, withStart))
+}
+
+// prepareInstall runs the checks that precede any change to the machine and
+// returns the resolved dependencies and the validated install paths
+func (m *Manager) prepareInstall(ctx context.Context) (deps []string, absTargetDir, absTargetPath string, err error) {
+	// Validate ServiceVariant fields before proceeding
+	if err := validateServiceVariantFields(m.variant); err != nil {
+		return nil, "", "", m.opError("install", CategoryInvalid, err, "validación de campos: %v", err)
+	}
+
+	// Refuse to ship a binary that is not the build recorded in the manifest
+	if err := m.variant.CheckEmbedded(); err != nil {
+		return nil, "", "", m.opError("install", CategoryInvalid, err, "binario embebido: %v", err)
+	}
+
+	if cErr := m.cancelled(ctx, "install"); cErr != nil {
+		return nil, "", "", cErr
+	}
+
+	// Pre-check: fail fast if already registered
+	currentStatus := m.status(ctx)
+	if currentStatus != StatusNotInstalled {
+		return nil, "", "", m.opError("install", CategoryAlreadyExists, nil,
+			"el servicio ya está registrado (estado: %s) — desinstale primero", currentStatus)
 	}
 
-	// 3. Register service with sc.exe using the validated absolute binary path
-	output, err := secureScCreate(m.variant.RegistryName, absTargetPath, m.variant.DisplayName)
+	// Dependencies must already be registered, or the service would never start
+	deps, err = m.resolveDependencies(ctx)
 	if err != nil {
-		outputStr := strings.TrimSpace(string(output))
-		//nolint:gosec // We have validated the path, so this is not vulnerable to injection
-		_ = os.RemoveAll(absTargetDir) // clean up using validated absolute dir
-		if strings.Contains(outputStr, "1073") {
-			return fmt.Errorf("el servicio ya existe en el registro de Windows (use Desinstalar primero)")
-		}
-		// CLAVE: Mostramos el error de Go (%v) para no volar a ciegas
-		return fmt.Errorf("fallo del sistema (%w) - Salida sc: '%s'", err, outputStr)
+		return nil, "", "", err
 	}
 
-	// 4. Configure failure recovery (restart on failure)
-	_ = secureScFailure(m.variant.RegistryName)
+	// A daemon whose port is taken dies on bind and loops through its restarts
+	if err := m.checkPort(ctx, "install"); err != nil {
+		return nil, "", "", err
+	}
 
-	return nil
+	// Prepare safe absolute paths and validate file name
+	absTargetDir, absTargetPath, err = m.installPaths()
+	if err != nil {
+		return nil, "", "", m.opError("install", CategoryInvalid, err, "%v", err)
+	}
+	return deps, absTargetDir, absTargetPath, nil
 }
 
 // Uninstall stops the service, removes it from the registry,
-// and deletes the binary files from disk.
-func (m *Manager) Uninstall() error {
-	// Step 1: Attempt to stop the service
-	_, stopErr := secureScRun("stop", m.variant.RegistryName)
-
-	// Step 2: Wait for STOPPED state with proper timeout
-	if stopErr == nil {
-		stopped := m.WaitForStatus(StatusStopped, 15*time.Second)
-		if !stopped {
+// and deletes the binary files from disk. Running dependents are not
+// stopped behind the caller's back: the uninstall is refused
+// (CategoryDependentsRunning) unless UninstallWithDependents is used.
+func (m *Manager) Uninstall(ctx context.Context) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditUninstall)
+	defer end(&err)
+	defer m.invalidateStatus()
+
+	// Step 0: Running dependents would keep the stop from completing
+	if running := m.runningDependents(ctx); len(running) > 0 {
+		names := make([]string, len(running))
+		for i, dep := range running {
+			names[i] = dep.Name
+		}
+		return m.opError("uninstall", CategoryDependentsRunning, nil,
+			"hay servicios dependientes en ejecución: %s — deténgalos antes de desinstalar", strings.Join(names, ", "))
+	}
+	progressFrom(ctx).plan(stepStop, stepDelete, stepRemoveFiles)
+
+	// Steps 1-2: Stop the service and wait for STOPPED, force-killing it if it hangs
+	err = runStep(ctx, stepStop, func() error {
+		if _, stopErr := m.ctrl.Stop(ctx, m.variant.RegistryName); stopErr != nil {
+			// Already stopped (or gone); Delete reports anything worse
+			if cErr := m.cancelled(ctx, "uninstall"); cErr != nil {
+				return cErr
+			}
+			return nil
+		}
+		if err := m.WaitForStatus(ctx, StatusStopped, m.opts.StopTimeout); err != nil {
+			if cErr := m.cancelled(ctx, "uninstall"); cErr != nil {
+				return cErr
+			}
 			// Force-kill the service process as a last resort
-			_ = secureTaskKillByService(m.variant.RegistryName)
+			progressFrom(ctx).detail("forzando el cierre del proceso")
+			_ = m.ctrl.Kill(ctx, m.variant.RegistryName)
 			// Wait again briefly after force-kill
-			m.WaitForStatus(StatusStopped, 5*time.Second)
+			_ = m.WaitForStatus(ctx, StatusStopped, m.opts.KillTimeout)
+		}
+		if cErr := m.cancelled(ctx, "uninstall"); cErr != nil {
+			return cErr
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Step 3: Delete service from registry
-	output, err := secureScRun("delete", m.variant.RegistryName)
-	if err != nil {
-		outputStr := string(output)
-		if strings.Contains(outputStr, "1060") {
-			return fmt.Errorf("el servicio no está instalado")
-		}
-		if strings.Contains(outputStr, "1072") {
-			// Service marked for deletion — will complete after process exits
-			// This is not a hard failure; inform the user
-			return fmt.Errorf("servicio marcado para eliminación (se completará al cerrar el proceso)")
+	err = runStep(ctx, stepDelete, func() error {
+		output, err := m.ctrl.Delete(ctx, m.variant.RegistryName)
+		if err != nil {
+			// ErrMarkedForDeletion is not a hard failure: deletion completes
+			// once the process exits, callers inform the user
+			return m.toolError("uninstall", output, err)
 		}
-		return fmt.Errorf("sc delete: %s", strings.TrimSpace(outputStr))
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Step 4: Remove binary files from disk (validate and resolve ProgramFiles path)
-	programFiles := os.Getenv("ProgramFiles")
-	if programFiles == "" {
-		return fmt.Errorf("environment variable `ProgramFiles` is empty")
-	}
-	targetDir := filepath.Join(programFiles, m.variant.RegistryName)
-	_, absTargetDir, err := resolveAndEnsure(programFiles, targetDir)
+	// Step 4: Remove binary files from disk
+	return runStep(ctx, stepRemoveFiles, func() error {
+		return m.removeFiles("uninstall")
+	})
+}
+
+// removeFiles deletes the install directory (validating and resolving the path)
+func (m *Manager) removeFiles(op string) error {
+	absTargetDir, _, err := m.installPaths()
 	if err != nil {
-		return fmt.Errorf("invalid target directory: %w", err)
+		return m.opError(op, CategoryInvalid, err, "%v", err)
 	}
 	//nolint:gosec // We have validated the path, so this is not vulnerable to injection
 	if err := os.RemoveAll(absTargetDir); err != nil {
-		return fmt.Errorf("no se pudieron eliminar los archivos: %w (puede que el proceso aún esté activo)", err)
+		return m.opError(op, CategoryFilesystem, err,
+			"no se pudieron eliminar los archivos: %v (puede que el proceso aún esté activo)", err)
 	}
-
 	return nil
 }
 
@@ -360,47 +558,110 @@ func (m *Manager) Uninstall() error {
 // ══════════════════════════════════════════════════════════════
 
 // Start starts the Windows service
-func (m *Manager) Start() error {
-	output, err := secureScRun("start", m.variant.RegistryName)
+func (m *Manager) Start(ctx context.Context) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditStart)
+	defer end(&err)
+	defer progressFrom(ctx).step(stepStart)(&err)
+	defer m.invalidateStatus()
+
+	if err := m.checkPort(ctx, "start"); err != nil {
+		return err
+	}
+
+	output, err := m.ctrl.Start(ctx, m.variant.RegistryName)
 	if err != nil {
-		outputStr := string(output)
-		if strings.Contains(outputStr, "1056") {
-			return fmt.Errorf("el servicio ya está en ejecución")
-		} else if strings.Contains(outputStr, "1060") {
-			return fmt.Errorf("el servicio no está instalado")
+		if cErr := m.cancelled(ctx, "start"); cErr != nil {
+			return cErr
 		}
-		return fmt.Errorf("sc start: %s", strings.TrimSpace(outputStr))
+		return m.toolError("start", output, err)
 	}
 	return nil
 }
 
 // Stop stops the Windows service
-func (m *Manager) Stop() error {
-	output, err := secureScRun("stop", m.variant.RegistryName)
+func (m *Manager) Stop(ctx context.Context) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditStop)
+	defer end(&err)
+	defer progressFrom(ctx).step(stepStop)(&err)
+	defer m.invalidateStatus()
+
+	output, err := m.ctrl.Stop(ctx, m.variant.RegistryName)
 	if err != nil {
-		outputStr := string(output)
-		if strings.Contains(outputStr, "1062") {
-			return fmt.Errorf("el servicio '%s' no está en ejecución", m.variant.DisplayName)
+		if cErr := m.cancelled(ctx, "stop"); cErr != nil {
+			return cErr
+		}
+		svcErr := m.toolError("stop", output, err)
+		switch svcErr.Category {
+		case CategoryNotRunning:
+			svcErr.Msg = fmt.Sprintf("el servicio '%s' no está en ejecución", m.variant.DisplayName)
+		case CategoryUnknown:
+			svcErr.Msg = fmt.Sprintf("no se pudo detener '%s': %s", m.variant.DisplayName, svcErr.Output)
+		default:
+			// Category sentinel message is descriptive enough
 		}
-		return fmt.Errorf("no se pudo detener '%s': %s", m.variant.DisplayName, strings.TrimSpace(outputStr))
+		return svcErr
 	}
 	return nil
 }
 
+// ForceStop terminates the service process without waiting for it to honour
+// a stop request, for a service stuck in a transition or that stopped
+// answering the SCM
+func (m *Manager) ForceStop(ctx context.Context) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditForceStop)
+	defer end(&err)
+	defer progressFrom(ctx).step(stepForceStop)(&err)
+	defer m.invalidateStatus()
+
+	switch m.status(ctx) {
+	case StatusNotInstalled:
+		return m.opError("stop", CategoryNotInstalled, nil, "el servicio '%s' no está instalado", m.variant.DisplayName)
+	case StatusStopped:
+		return m.opError("stop", CategoryNotRunning, nil, "el servicio '%s' no está en ejecución", m.variant.DisplayName)
+	default:
+	}
+	return m.killAndWait(ctx, "stop")
+}
+
 // Restart restarts the Windows service. It first checks the current status and only attempts to stop if it's running or in a pending start state.
-func (m *Manager) Restart() error {
-	currentStatus := m.CheckStatus()
+func (m *Manager) Restart(ctx context.Context) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditRestart)
+	defer end(&err)
+
+	currentStatus := m.status(ctx)
+	running := currentStatus == StatusRunning || currentStatus == StatusStartPending
+
+	// Refuse before stopping anything: a running service holds its own port
+	if err := m.checkPort(ctx, "restart"); err != nil {
+		return err
+	}
+
+	progress := progressFrom(ctx)
+	if running {
+		progress.plan(stepStop)
+	}
+	progress.plan(stepWaitStopped, stepStart)
 
 	// Only try to stop if actually running or in a running-like state
-	if currentStatus == StatusRunning || currentStatus == StatusStartPending {
-		if err := m.Stop(); err != nil {
-			return fmt.Errorf("no se pudo detener el servicio para reiniciar: %w", err)
+	if running {
+		if err := m.Stop(ctx); err != nil {
+			return m.opError("restart", CategoryOf(err), err,
+				"no se pudo detener el servicio para reiniciar: %v", err)
 		}
 	}
 
-	if !m.WaitForStatus(StatusStopped, 15*time.Second) {
-		return fmt.Errorf("el servicio no se detuvo a tiempo para reiniciar")
+	err = runStep(ctx, stepWaitStopped, func() error {
+		if err := m.WaitForStatus(ctx, StatusStopped, m.opts.StopTimeout); err != nil {
+			if cErr := m.cancelled(ctx, "restart"); cErr != nil {
+				return cErr
+			}
+			return m.opError("restart", CategoryTimeout, nil, "el servicio no se detuvo a tiempo para reiniciar")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return m.Start()
+	return m.Start(ctx)
 }
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adcondev/poster-tuis/internal/manifest"
+)
+
+// testVariant returns a self-contained variant whose embedded binary matches
+// its manifest entry, independent of the build's ldflags and assets
+func testVariant(id, regName string) Variant {
+	binary := []byte("binario de prueba " + id)
+	return Variant{
+		ID:           id,
+		Family:       "test",
+		Variant:      Local,
+		RegistryName: regName,
+		DisplayName:  "Servicio de Prueba " + id,
+		Description:  "Servicio de prueba",
+		ExeName:      regName + ".exe",
+		Binary:       binary,
+		StartType:    StartAuto,
+		Account:      DefaultAccount(Local),
+		Recovery:     DefaultRecoveryPolicy("scale"),
+		Manifest:     manifest.NewEntry(binary, "test", "2026-01-01"),
+	}
+}
+
+// newTestManager returns a manager over a fresh MemoryController rooted in
+// a temporary directory, with short timeouts
+func newTestManager(t *testing.T) (*Manager, *MemoryController) {
+	t.Helper()
+	ctrl := NewMemoryController(t.TempDir())
+	m := NewManagerWithController(testVariant("test-local", "Test_Servicio_Local"), ctrl)
+	m.SetOptions(ManagerOptions{
+		StartTimeout:  2 * time.Second,
+		StopTimeout:   2 * time.Second,
+		KillTimeout:   2 * time.Second,
+		DeleteTimeout: 2 * time.Second,
+	})
+	return m, ctrl
+}
+
+// binaryPath returns where the manager writes its binary
+func binaryPath(t *testing.T, m *Manager) string {
+	t.Helper()
+	_, path, err := m.installPaths()
+	if err != nil {
+		t.Fatalf("installPaths: %v", err)
+	}
+	return path
+}
+
+// assertCategory fails unless err is a service error of the given category
+func assertCategory(t *testing.T, err error, want ErrorCategory) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("se esperaba un error de categoría %v, no hubo error", want)
+	}
+	if got := CategoryOf(err); got != want {
+		t.Fatalf("categoría = %v, se esperaba %v (error: %v)", got, want, err)
+	}
+}
+
+// assertRolledBack fails if the install left a registration, binary or journal behind
+func assertRolledBack(t *testing.T, m *Manager, ctrl *MemoryController) {
+	t.Helper()
+	if registered, _ := ctrl.Installed(m.variant.RegistryName); registered {
+		t.Error("el servicio quedó registrado tras la reversión")
+	}
+	if _, err := os.Stat(binaryPath(t, m)); !os.IsNotExist(err) {
+		t.Errorf("el binario quedó en disco tras la reversión (stat: %v)", err)
+	}
+	if j, err := m.PendingJournal(); err != nil || j != nil {
+		t.Errorf("bitácora pendiente tras la reversión: %v, %v", j, err)
+	}
+}
+
+func TestManagerInstall(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	registered, failureConfigured := ctrl.Installed(m.variant.RegistryName)
+	if !registered || !failureConfigured {
+		t.Fatalf("Installed = %v, %v; se esperaba registrado con recuperación", registered, failureConfigured)
+	}
+	data, err := os.ReadFile(binaryPath(t, m))
+	if err != nil {
+		t.Fatalf("leer binario: %v", err)
+	}
+	if string(data) != string(m.variant.Binary) {
+		t.Error("el binario en disco no coincide con el embebido")
+	}
+
+	cfg, err := ctrl.QueryConfig(ctx, m.variant.RegistryName)
+	if err != nil {
+		t.Fatalf("QueryConfig: %v", err)
+	}
+	if !samePath(cfg.BinaryPath, binaryPath(t, m)) {
+		t.Errorf("BinaryPath = %q, se esperaba %q", cfg.BinaryPath, binaryPath(t, m))
+	}
+	if cfg.Description != m.variant.Description {
+		t.Errorf("Description = %q, se esperaba %q", cfg.Description, m.variant.Description)
+	}
+	if got := m.status(ctx); got != StatusStopped {
+		t.Errorf("estado tras Install = %v, se esperaba %v", got, StatusStopped)
+	}
+	if j, err := m.PendingJournal(); err != nil || j != nil {
+		t.Errorf("bitácora pendiente tras Install: %v, %v", j, err)
+	}
+}
+
+func TestManagerInstallModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows no usa bits de permiso")
+	}
+	m, _ := newTestManager(t)
+	path := binaryPath(t, m)
+	// A directory left behind by an earlier, more restrictive install
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("crear directorio: %v", err)
+	}
+
+	if err := m.Install(context.Background()); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	// The service account must traverse the directory and execute the binary
+	for _, p := range []string{filepath.Dir(path), path} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("stat: %v", err)
+		}
+		if got := info.Mode().Perm(); got != 0755 {
+			t.Errorf("permisos de %s = %v, se esperaba -rwxr-xr-x", filepath.Base(p), got)
+		}
+	}
+}
+
+func TestManagerInstallAndStart(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+
+	if err := m.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+	if got := m.status(ctx); got != StatusRunning {
+		t.Errorf("estado = %v, se esperaba %v", got, StatusRunning)
+	}
+}
+
+func TestManagerInstallAlreadyRegistered(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctrl.AddService(m.variant.RegistryName, false)
+
+	err := m.Install(context.Background())
+	assertCategory(t, err, CategoryAlreadyExists)
+	if _, statErr := os.Stat(binaryPath(t, m)); !os.IsNotExist(statErr) {
+		t.Error("el binario se escribió aunque el servicio ya existía")
+	}
+}
+
+func TestManagerInstallCreateFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		want ErrorCategory
+		is   error
+	}{
+		{"1073 ya existe", win32ServiceExists, CategoryAlreadyExists, ErrAlreadyExists},
+		{"1072 marcado para eliminación", win32ServiceMarkedDelete, CategoryMarkedForDeletion, ErrMarkedForDeletion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ctrl := newTestManager(t)
+			ctrl.FailNext("create", tt.code)
+
+			err := m.Install(context.Background())
+			assertCategory(t, err, tt.want)
+			if !errors.Is(err, tt.is) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.is)
+			}
+			assertRolledBack(t, m, ctrl)
+		})
+	}
+}
+
+func TestManagerInstallAndStartTimeout(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctrl.PendingQueries = 1 << 30 // START_PENDING never settles
+	m.SetOptions(ManagerOptions{StartTimeout: time.Second, StopTimeout: time.Second, KillTimeout: time.Second})
+
+	err := m.InstallAndStart(context.Background())
+	assertCategory(t, err, CategoryTimeout)
+	assertRolledBack(t, m, ctrl)
+}
+
+func TestManagerUninstall(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	if err := m.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+
+	if err := m.Uninstall(ctx); err != nil {
+		t.Fatalf("Uninstall: %v", err)
+	}
+	if registered, _ := ctrl.Installed(m.variant.RegistryName); registered {
+		t.Error("el servicio sigue registrado")
+	}
+	dir := filepath.Dir(binaryPath(t, m))
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("el directorio de instalación sigue en disco (stat: %v)", err)
+	}
+}
+
+// newDependentManager installs and starts m and a second service that
+// depends on it, sharing the same controller
+func newDependentManager(t *testing.T, m *Manager, ctrl *MemoryController) *Manager {
+	t.Helper()
+	ctx := context.Background()
+	v := testVariant("test-dependiente", "Test_Servicio_Dependiente")
+	v.Dependencies = []Dependency{{Service: m.variant.RegistryName}}
+	dep := NewManagerWithController(v, ctrl)
+	dep.SetOptions(m.opts)
+	if err := m.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+	if err := dep.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart dependiente: %v", err)
+	}
+	return dep
+}
+
+func TestManagerUninstallRefusesRunningDependents(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	dep := newDependentManager(t, m, ctrl)
+
+	err := m.Uninstall(ctx)
+	assertCategory(t, err, CategoryDependentsRunning)
+	if registered, _ := ctrl.Installed(m.variant.RegistryName); !registered {
+		t.Error("el servicio se desinstaló pese a los dependientes en ejecución")
+	}
+	if got := dep.status(ctx); got != StatusRunning {
+		t.Errorf("estado del dependiente = %v, se esperaba %v", got, StatusRunning)
+	}
+}
+
+func TestManagerUninstallWithDependents(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	dep := newDependentManager(t, m, ctrl)
+
+	if err := m.UninstallWithDependents(ctx); err != nil {
+		t.Fatalf("UninstallWithDependents: %v", err)
+	}
+	if registered, _ := ctrl.Installed(m.variant.RegistryName); registered {
+		t.Error("el servicio sigue registrado")
+	}
+	if got := dep.status(ctx); got != StatusStopped {
+		t.Errorf("estado del dependiente = %v, se esperaba %v", got, StatusStopped)
+	}
+}
+
+func TestManagerUninstallNotInstalled(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	err := m.Uninstall(context.Background())
+	assertCategory(t, err, CategoryNotInstalled)
+}
+
+func TestManagerUninstallMarkedForDeletion(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	ctrl.FailNext("delete", win32ServiceMarkedDelete)
+
+	err := m.Uninstall(ctx)
+	assertCategory(t, err, CategoryMarkedForDeletion)
+}
+
+func TestManagerUninstallHungService(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	if err := m.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+	ctrl.Hang(m.variant.RegistryName)
+	m.SetOptions(ManagerOptions{StopTimeout: time.Second, KillTimeout: 2 * time.Second})
+
+	// The stop times out, the process is force-killed and the uninstall completes
+	if err := m.Uninstall(ctx); err != nil {
+		t.Fatalf("Uninstall: %v", err)
+	}
+	if registered, _ := ctrl.Installed(m.variant.RegistryName); registered {
+		t.Error("el servicio sigue registrado")
+	}
+}
+
+func TestManagerRestart(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	if err := m.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+	before, _ := ctrl.Query(ctx, m.variant.RegistryName)
+
+	if err := m.Restart(ctx); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	if err := m.WaitForStatus(ctx, StatusRunning, 2*time.Second); err != nil {
+		t.Fatalf("el servicio no volvió a EN EJECUCIÓN: %v", err)
+	}
+	after, _ := ctrl.Query(ctx, m.variant.RegistryName)
+	if after.PID == before.PID {
+		t.Errorf("PID = %d tras reiniciar, se esperaba un proceso nuevo", after.PID)
+	}
+}
+
+func TestManagerRestartStopped(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	if err := m.Restart(ctx); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	if err := m.WaitForStatus(ctx, StatusRunning, 2*time.Second); err != nil {
+		t.Fatalf("el servicio no arrancó: %v", err)
+	}
+}
+
+func TestManagerRestartTimeout(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	if err := m.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+	ctrl.Hang(m.variant.RegistryName)
+	m.SetOptions(ManagerOptions{StopTimeout: time.Second})
+
+	err := m.Restart(ctx)
+	assertCategory(t, err, CategoryTimeout)
+}
+
+func TestManagerStartFailures(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(m *Manager, ctrl *MemoryController)
+		want  ErrorCategory
+	}{
+		{"no instalado", func(*Manager, *MemoryController) {}, CategoryNotInstalled},
+		{"cuenta sin derecho de inicio de sesión", func(m *Manager, ctrl *MemoryController) {
+			ctrl.DenyServiceLogon(m.variant.Account.Name)
+			if err := m.Install(context.Background()); err != nil {
+				t.Fatalf("Install: %v", err)
+			}
+		}, CategoryLogonFailed},
+		{"1072 marcado para eliminación", func(m *Manager, ctrl *MemoryController) {
+			if err := m.Install(context.Background()); err != nil {
+				t.Fatalf("Install: %v", err)
+			}
+			ctrl.FailNext("start", win32ServiceMarkedDelete)
+		}, CategoryMarkedForDeletion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ctrl := newTestManager(t)
+			tt.setup(m, ctrl)
+
+			err := m.Start(context.Background())
+			assertCategory(t, err, tt.want)
+		})
+	}
+}
+
+func TestManagerCancelled(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctrl.PendingQueries = 1 << 30
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(300*time.Millisecond, cancel)
+
+	err := m.InstallAndStart(ctx)
+	assertCategory(t, err, CategoryCancelled)
+	assertRolledBack(t, m, ctrl)
+}
+
+func TestManagerStopNotRunning(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t)
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	assertCategory(t, m.Stop(ctx), CategoryNotRunning)
+}
+
+func TestManagerForceStop(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	assertCategory(t, m.ForceStop(ctx), CategoryNotInstalled)
+	if err := m.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+	// A stop request that never completes leaves the service in STOP_PENDING
+	ctrl.Hang(m.variant.RegistryName)
+	if _, err := ctrl.Stop(ctx, m.variant.RegistryName); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	if err := m.ForceStop(ctx); err != nil {
+		t.Fatalf("ForceStop: %v", err)
+	}
+	if st := m.status(ctx); st != StatusStopped {
+		t.Errorf("estado = %s, se esperaba %s", st, StatusStopped)
+	}
+	assertCategory(t, m.ForceStop(ctx), CategoryNotRunning)
+}
+
+func TestMemoryControllerStateMachine(t *testing.T) {
+	ctx := context.Background()
+	ctrl := NewMemoryController(t.TempDir())
+	const name = "Test_Servicio"
+
+	if st, _ := ctrl.Query(ctx, name); st.Status != StatusNotInstalled {
+		t.Fatalf("estado inicial = %s, se esperaba NOT_INSTALLED", st.Status)
+	}
+	if _, err := ctrl.Create(ctx, name, `C:\x\x.exe`, "Prueba", CreateOptions{StartType: StartAuto, Account: DefaultAccount(Local)}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	cfg, err := ctrl.QueryConfig(ctx, name)
+	if err != nil || cfg.BinaryPath != `C:\x\x.exe` || cfg.DisplayName != "Prueba" {
+		t.Fatalf("QueryConfig = %+v, %v", cfg, err)
+	}
+	if out, err := ctrl.Create(ctx, name, `C:\x\x.exe`, "Prueba", CreateOptions{StartType: StartAuto, Account: DefaultAccount(Local)}); err == nil || !strings.Contains(string(out), "1073") {
+		t.Fatalf("Create duplicado = %q, %v; se esperaba FAILED 1073", out, err)
+	}
+
+	if _, err := ctrl.Start(ctx, name); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if st, _ := ctrl.Query(ctx, name); st.Status != StatusStartPending {
+		t.Fatalf("estado tras Start = %s, se esperaba START_PENDING", st.Status)
+	}
+	if st, _ := ctrl.Query(ctx, name); st.Status != StatusRunning {
+		t.Fatalf("estado = %s, se esperaba RUNNING", st.Status)
+	}
+	if out, err := ctrl.Start(ctx, name); err == nil || !strings.Contains(string(out), "1056") {
+		t.Fatalf("Start repetido = %q, %v; se esperaba FAILED 1056", out, err)
+	}
+
+	// Deleting a running service only marks it; it goes away once stopped
+	if _, err := ctrl.Delete(ctx, name); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if out, err := ctrl.Delete(ctx, name); err == nil || !strings.Contains(string(out), "1072") {
+		t.Fatalf("Delete repetido = %q, %v; se esperaba FAILED 1072", out, err)
+	}
+	if _, err := ctrl.Stop(ctx, name); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	_, _ = ctrl.Query(ctx, name)
+	if st, _ := ctrl.Query(ctx, name); st.Status != StatusNotInstalled {
+		t.Fatalf("estado tras detener = %s, se esperaba NOT_INSTALLED", st.Status)
+	}
+}
+
+func TestMemoryControllerFailNext(t *testing.T) {
+	ctx := context.Background()
+	ctrl := NewMemoryController(t.TempDir())
+	ctrl.FailNext("create", win32ServiceExists)
+
+	out, err := ctrl.Create(ctx, "Test_Servicio", `C:\x\x.exe`, "Prueba", CreateOptions{StartType: StartAuto, Account: DefaultAccount(Local)})
+	if err == nil || !strings.Contains(string(out), "FAILED 1073") {
+		t.Fatalf("Create = %q, %v; se esperaba el fallo inyectado", out, err)
+	}
+	if _, err := ctrl.Create(ctx, "Test_Servicio", `C:\x\x.exe`, "Prueba", CreateOptions{StartType: StartAuto, Account: DefaultAccount(Local)}); err != nil {
+		t.Fatalf("el fallo inyectado debía consumirse: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Start Type
+// ══════════════════════════════════════════════════════════════
+
+// StartType is how the service is started at boot. Values are the
+// `sc create/config start=` arguments.
+type StartType string
+
+const (
+	// StartAuto starts the service at boot
+	StartAuto StartType = "auto"
+	// StartDelayedAuto starts the service shortly after the other automatic
+	// services (e.g. once the print spooler is up)
+	StartDelayedAuto StartType = "delayed-auto"
+	// StartManual only starts the service on request
+	StartManual StartType = "demand"
+	// StartDisabled prevents the service from starting
+	StartDisabled StartType = "disabled"
+)
+
+// StartTypes lists the start types in the order the TUI offers them
+var StartTypes = []StartType{StartAuto, StartDelayedAuto, StartManual, StartDisabled}
+
+// CreateOptions carries the registration settings beyond name, binary and
+// display name
+type CreateOptions struct {
+	StartType    StartType
+	Account      ServiceAccount
+	Dependencies []string // Registry names (sc depend=)
+}
+
+// Label returns the Spanish name of the start type, as services.msc shows it
+func (t StartType) Label() string {
+	switch t {
+	case StartAuto:
+		return "Automático"
+	case StartDelayedAuto:
+		return "Automático (inicio retrasado)"
+	case StartManual:
+		return "Manual"
+	case StartDisabled:
+		return "Deshabilitado"
+	default:
+		return "Desconocido"
+	}
+}
+
+// Validate checks that t is one of the supported start types
+func (t StartType) Validate() error {
+	for _, known := range StartTypes {
+		if t == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("tipo de inicio desconocido: %q", t)
+}
+
+// StartTypeOf maps a parsed `sc qc` configuration to its start type.
+// Returns "" for start types this installer does not manage (boot/system).
+func StartTypeOf(cfg ServiceConfig) StartType {
+	switch cfg.StartType {
+	case "AUTO_START":
+		if cfg.DelayedStart {
+			return StartDelayedAuto
+		}
+		return StartAuto
+	case "DEMAND_START":
+		return StartManual
+	case "DISABLED":
+		return StartDisabled
+	default:
+		return ""
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Manager Operations
+// ══════════════════════════════════════════════════════════════
+
+// StartType returns the start type used for the next install
+func (m *Manager) StartType() StartType {
+	return m.variant.StartType
+}
+
+// SetInstallStartType chooses the start type used by the next Install
+func (m *Manager) SetInstallStartType(t StartType) error {
+	if err := t.Validate(); err != nil {
+		return m.opError("install", CategoryInvalid, err, "%v", err)
+	}
+	m.variant.StartType = t
+	return nil
+}
+
+// ChangeStartType changes the start type of the installed service
+// (`sc config start=`)
+func (m *Manager) ChangeStartType(ctx context.Context, t StartType) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditStartType)
+	defer end(&err)
+	defer progressFrom(ctx).step("Cambiar tipo de inicio")(&err)
+	defer m.invalidateStatus()
+
+	if err := t.Validate(); err != nil {
+		return m.opError("config", CategoryInvalid, err, "%v", err)
+	}
+	if m.status(ctx) == StatusNotInstalled {
+		return m.opError("config", CategoryNotInstalled, nil, "el servicio no está instalado")
+	}
+	if output, err := m.ctrl.SetStartType(ctx, m.variant.RegistryName, t); err != nil {
+		if cErr := m.cancelled(ctx, "config"); cErr != nil {
+			return cErr
+		}
+		svcErr := m.toolError("config", output, err)
+		svcErr.Msg = "cambiar tipo de inicio: " + svcErr.Error()
+		return svcErr
+	}
+	m.variant.StartType = t
+	return nil
+}
@@ -1,7 +1,8 @@
 package service
 
 import (
-	"strings"
+	"context"
+	"fmt"
 	"time"
 )
 
@@ -14,6 +15,9 @@ const (
 	Local = "Local"
 	// Remoto is the identifier for the "Remoto" variant of a service family
 	Remoto = "Remoto"
+	// Conflict is reported by GetInstalledVariant when both variants are
+	// registered at once, which breaks mutual exclusivity and needs repair
+	Conflict = "Conflicto"
 )
 
 // Status represents the current state of a Windows service
@@ -52,6 +56,30 @@ func (s Status) String() string {
 	}
 }
 
+// Label returns the bare state name, as used in progress messages
+func (s Status) Label() string {
+	switch s {
+	case StatusStopPending:
+		return "DETENIÉNDOSE"
+	case StatusStartPending:
+		return "INICIÁNDOSE"
+	case StatusRunning:
+		return "EN EJECUCIÓN"
+	case StatusStopped:
+		return "DETENIDO"
+	case StatusNotInstalled:
+		return "NO INSTALADO"
+	default:
+		return "DESCONOCIDO"
+	}
+}
+
+// MarshalText encodes a status by its label, so JSON reports (the support
+// bundle) read "DETENIDO" rather than a number
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.Label()), nil
+}
+
 // ══════════════════════════════════════════════════════════════
 // Family Status (Mutual Exclusivity Tracking)
 // ══════════════════════════════════════════════════════════════
@@ -62,18 +90,50 @@ func (s Status) String() string {
 type FamilyStatus struct {
 	LocalStatus  Status
 	RemoteStatus Status
+
+	// Parsed query details (PID, exit codes) for each variant
+	LocalState  ServiceState
+	RemoteState ServiceState
+
+	// Registered configuration (sc qc) of each installed variant
+	LocalConfig  ServiceConfig
+	RemoteConfig ServiceConfig
+
+	// BinaryDiffers is set when the installed exe is not the embedded build
+	BinaryDiffers bool
+
+	// Dependency tree and dependent services of the installed variant
+	Dependencies []DependencyNode
+	Dependents   []DependencyNode
+
+	// Probe is the last health probe of the installed variant, if it is
+	// running and declares one
+	Probe ProbeResult
+}
+
+// RunningDependents returns the dependents that stopping the installed
+// variant would also stop
+func (fs FamilyStatus) RunningDependents() []DependencyNode {
+	var running []DependencyNode
+	for _, d := range fs.Dependents {
+		if d.Status != StatusStopped && d.Status != StatusNotInstalled {
+			running = append(running, d)
+		}
+	}
+	return running
 }
 
 // GetInstalledVariant returns which variant is currently installed.
-// Returns: "Local", "Remoto", or "" if neither is installed.
+// Returns: "Local", "Remoto", Conflict if both are registered, or "" if
+// neither is installed.
 // CRITICAL: Used by the UI to determine which menu options to show.
 func (fs FamilyStatus) GetInstalledVariant() string {
 	localInstalled := fs.LocalStatus != StatusNotInstalled
 	remoteInstalled := fs.RemoteStatus != StatusNotInstalled
 
 	if localInstalled && remoteInstalled {
-		// Both installed — shouldn't happen. Return "Local" but this is a conflict.
-		return "Conflict" // or return "CONFLICT" for special handling
+		// Both installed — mutual exclusivity is broken, see ResolveConflict
+		return Conflict
 	}
 	if localInstalled {
 		return Local
@@ -84,6 +144,11 @@ func (fs FamilyStatus) GetInstalledVariant() string {
 	return ""
 }
 
+// HasConflict reports whether both variants are registered at once
+func (fs FamilyStatus) HasConflict() bool {
+	return fs.LocalStatus != StatusNotInstalled && fs.RemoteStatus != StatusNotInstalled
+}
+
 // GetActiveStatus returns the status of the currently installed variant.
 // Returns StatusNotInstalled if no variant is installed.
 func (fs FamilyStatus) GetActiveStatus() Status {
@@ -96,87 +161,89 @@ func (fs FamilyStatus) GetActiveStatus() Status {
 	return StatusNotInstalled
 }
 
+// GetActiveState returns the parsed query details of the installed variant.
+func (fs FamilyStatus) GetActiveState() ServiceState {
+	if fs.LocalStatus != StatusNotInstalled {
+		return fs.LocalState
+	}
+	if fs.RemoteStatus != StatusNotInstalled {
+		return fs.RemoteState
+	}
+	return ServiceState{Status: StatusNotInstalled}
+}
+
+// GetActiveConfig returns the registered configuration of the installed variant.
+func (fs FamilyStatus) GetActiveConfig() ServiceConfig {
+	if fs.LocalStatus != StatusNotInstalled {
+		return fs.LocalConfig
+	}
+	if fs.RemoteStatus != StatusNotInstalled {
+		return fs.RemoteConfig
+	}
+	return ServiceConfig{}
+}
+
 // ══════════════════════════════════════════════════════════════
 // Status Checking
 // ══════════════════════════════════════════════════════════════
 
 // CheckStatus queries the Windows service control manager for the
-// current state of this manager's service variant.
-func (m *Manager) CheckStatus() Status {
-	output, err := secureScRun("query", m.variant.RegistryName)
-	outStr := string(output)
-
-	if err != nil {
-		// If sc indicates the service doesn't exist, treat as not installed.
-		if strings.Contains(outStr, "1060") ||
-			strings.Contains(strings.ToLower(outStr), "does not exist") ||
-			strings.Contains(strings.ToLower(outStr), "not found") {
-			return StatusNotInstalled
-		}
-		// Unexpected error from sc; return unknown so callers can handle/log if needed.
-		return StatusUnknown
-	}
+// current state of this manager's service variant. The answer may come from
+// the status cache (see QueryAll).
+func (m *Manager) CheckStatus(ctx context.Context) Status {
+	return m.QueryState(ctx).Status
+}
 
-	switch {
-	case strings.Contains(outStr, "RUNNING"):
-		return StatusRunning
-	case strings.Contains(outStr, "STOPPED"):
-		return StatusStopped
-	case strings.Contains(outStr, "STOP_PENDING"):
-		return StatusStopPending
-	case strings.Contains(outStr, "START_PENDING"):
-		return StatusStartPending
-	default:
-		return StatusUnknown
-	}
+// status queries the backend directly, bypassing the cache: a running
+// operation must see the effect of its own steps
+func (m *Manager) status(ctx context.Context) Status {
+	state, _ := m.ctrl.Query(ctx, m.variant.RegistryName)
+	return state.Status
+}
+
+// QueryState returns the full parsed state (PID, exit codes, checkpoint)
+// of this manager's service variant.
+func (m *Manager) QueryState(ctx context.Context) ServiceState {
+	return m.cachedState(ctx, nil)
+}
+
+// QueryConfig returns the registered configuration (start type, binary
+// path, dependencies, account) of this manager's service variant.
+func (m *Manager) QueryConfig(ctx context.Context) (ServiceConfig, error) {
+	return m.cachedConfig(ctx, nil)
 }
 
 // CheckFamilyStatus checks the status of both variants in a family
 // and returns their combined status. This is used for mutual exclusivity
 // enforcement in the UI layer.
-func CheckFamilyStatus(variants []Variant) FamilyStatus {
-	fs := FamilyStatus{
-		LocalStatus:  StatusNotInstalled,
-		RemoteStatus: StatusNotInstalled,
+func CheckFamilyStatus(ctx context.Context, variants []Variant) FamilyStatus {
+	if len(variants) == 0 {
+		return FamilyStatus{LocalStatus: StatusNotInstalled, RemoteStatus: StatusNotInstalled}
 	}
+	family := variants[0].Family
+	report := queryAll(ctx, defaultController, map[string][]Variant{family: variants})
+	return report.Families[family]
+}
 
-	for _, v := range variants {
-		mgr := NewManager(v)
-		status := mgr.CheckStatus()
+// WaitForStatus waits on the backend's status watcher until the service
+// reaches the expected state. Returns nil once it does, or the context
+// error when timeout elapses or ctx is cancelled first.
+func (m *Manager) WaitForStatus(ctx context.Context, expectedStatus Status, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-		switch v.Variant {
-		case Local:
-			fs.LocalStatus = status
-		case Remoto:
-			fs.RemoteStatus = status
-		}
-	}
+	stop := progressFrom(ctx).every(time.Second, func(elapsed time.Duration) string {
+		return fmt.Sprintf("esperando %s %d/%ds", expectedStatus.Label(), int(elapsed.Seconds()), int(timeout.Seconds()))
+	})
+	defer stop()
 
-	return fs
+	return m.watcher().WaitFor(ctx, m.variant.ID, expectedStatus)
 }
 
-// WaitForStatus polls the service status until it reaches the expected state
-// or times out. Returns true if the expected status was reached.
-func (m *Manager) WaitForStatus(expectedStatus Status, timeout time.Duration) bool {
-	// Poll less aggressively — 500ms is plenty for service state changes
-	const pollInterval = 500 * time.Millisecond
-
-	deadline := time.Now().Add(timeout)
-
-	for time.Now().Before(deadline) {
-		currentStatus := m.CheckStatus()
-		if currentStatus == expectedStatus {
-			return true
-		}
-		// If status is not a transitional state and not the expected one, bail early
-		if currentStatus != StatusStopPending && currentStatus != StatusStartPending &&
-			currentStatus != expectedStatus {
-			// e.g., waiting for STOPPED but service is NOT_INSTALLED — that's fine too
-			if expectedStatus == StatusStopped && currentStatus == StatusNotInstalled {
-				return true
-			}
-		}
-		time.Sleep(pollInterval)
-	}
-	return false
+// watcher returns the shared watcher of this manager's backend, tracking
+// this manager's variant
+func (m *Manager) watcher() *Watcher {
+	w := watcherFor(m.ctrl)
+	w.Track(m.variant)
+	return w
 }
@@ -0,0 +1,479 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adcondev/poster-tuis/internal/config"
+	"github.com/adcondev/poster-tuis/internal/manifest"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Support Bundle
+// ══════════════════════════════════════════════════════════════
+// A support bundle is one zip with everything support asks a store for:
+//
+//	manifest.json          what the bundle holds (SupportManifest)
+//	build.json             installer build and embedded binary manifest
+//	status.json            FamilyStatus of every family
+//	services/{name}.json   parsed sc queryex / sc qc of every variant
+//	binaries.json          installed vs embedded binary hashes
+//	environment.json       relevant environment variables, secrets redacted
+//	logs/{name}/…          the newest service logs, tail-limited
+//	installer/…            audit log, configuration and pending journals
+//
+// A section that cannot be collected is listed in the manifest's errors and
+// the rest of the bundle is still written.
+
+// supportFormat is the version of the bundle layout
+const supportFormat = 1
+
+// Bundle size limits
+const (
+	supportTailBytes = 1 << 20 // Bytes kept from the end of each log or installer file
+	supportLogFiles  = 5       // Newest plain log files copied per service
+)
+
+// Step names of a support bundle run
+const (
+	stepCollectStatus = "Consultar servicios"
+	stepHashBinaries  = "Calcular hashes"
+	stepCopyLogs      = "Copiar logs"
+	stepWriteBundle   = "Escribir paquete"
+)
+
+// supportEnvVars are copied into the bundle when set, besides any variable
+// starting with one of supportEnvPrefixes
+var (
+	supportEnvVars = []string{
+		"COMPUTERNAME", "USERDOMAIN", "USERNAME", "OS", "PROCESSOR_ARCHITECTURE", "NUMBER_OF_PROCESSORS",
+		"PROGRAMDATA", "PROGRAMFILES", "SYSTEMROOT", "TEMP", "PATH", "TZ", "LANG",
+	}
+	supportEnvPrefixes = []string{"R2K_", "POS_"}
+	// Variables whose name contains one of these are redacted
+	secretMarkers = []string{"PASS", "PWD", "SECRET", "TOKEN", "KEY", "CREDENTIAL", "AUTH"}
+)
+
+// SupportManifest is manifest.json, the index of a support bundle
+type SupportManifest struct {
+	Format    int           `json:"format"`
+	Created   time.Time     `json:"created"`
+	Machine   string        `json:"machine"`
+	User      string        `json:"user"`
+	Platform  string        `json:"platform"` // GOOS/GOARCH
+	BuildDate string        `json:"build_date"`
+	BuildTime string        `json:"build_time"`
+	Files     []SupportFile `json:"files"`
+	Errors    []string      `json:"errors,omitempty"` // Sections that could not be collected
+}
+
+// SupportFile is one entry of a support bundle
+type SupportFile struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	Truncated bool   `json:"truncated,omitempty"` // Only the end of the source file was included
+}
+
+// supportService is services/{name}.json
+type supportService struct {
+	Variant     string        `json:"variant"`
+	Name        string        `json:"name"`
+	QueryEx     ServiceState  `json:"queryex"`
+	QueryError  string        `json:"queryex_error,omitempty"`
+	QC          ServiceConfig `json:"qc"`
+	ConfigError string        `json:"qc_error,omitempty"`
+}
+
+// supportBinary is one entry of binaries.json
+type supportBinary struct {
+	Variant         string         `json:"variant"`
+	Exe             string         `json:"exe"`
+	Manifest        manifest.Entry `json:"manifest"`        // Build record of the embedded binary
+	EmbeddedSHA256  string         `json:"embedded_sha256"` // Hash of the bytes in this installer
+	Path            string         `json:"path,omitempty"`
+	InstalledSHA256 string         `json:"installed_sha256,omitempty"`
+	InstalledSize   int64          `json:"installed_size,omitempty"`
+	Outcome         string         `json:"outcome"` // match, mismatch, missing or error
+	Error           string         `json:"error,omitempty"`
+}
+
+// WriteSupportBundle collects the support bundle of the platform backend
+// into a timestamped zip in dir and returns its path
+func WriteSupportBundle(ctx context.Context, dir string) (string, error) {
+	return writeSupportBundle(ctx, defaultController, GetServiceRegistry(), dir, time.Now())
+}
+
+// DefaultSupportDir returns where support bundles go unless another
+// directory is chosen: the desktop, else the home directory
+func DefaultSupportDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.TempDir()
+	}
+	desktop := filepath.Join(home, "Desktop")
+	if info, err := os.Stat(desktop); err == nil && info.IsDir() {
+		return desktop
+	}
+	return home
+}
+
+// writeSupportBundle implements WriteSupportBundle. The zip is written under
+// a temporary name and renamed once complete, so a cancelled or failed run
+// leaves nothing behind.
+func writeSupportBundle(ctx context.Context, ctrl Controller, registry map[string][]Variant, dir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("crear directorio de destino: %w", err)
+	}
+	name := fmt.Sprintf("R2k_Soporte_%s_%s.zip", bundleSafeName(machineName()), now.Format("20060102-150405"))
+	target := filepath.Join(dir, name)
+	tmp := target + ".tmp"
+
+	//nolint:gosec // the directory is chosen by the administrator running the installer
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("crear paquete: %w", err)
+	}
+	b := &bundleWriter{
+		zw: zip.NewWriter(f),
+		manifest: SupportManifest{
+			Format:    supportFormat,
+			Created:   now,
+			Machine:   machineName(),
+			User:      osUserName(),
+			Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+			BuildDate: config.BuildDate,
+			BuildTime: config.BuildTime,
+		},
+	}
+
+	err = b.collect(ctx, ctrl, registry)
+	if closeErr := b.zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, target)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return target, nil
+}
+
+// bundleWriter adds files to the zip and records them in the manifest
+type bundleWriter struct {
+	zw       *zip.Writer
+	manifest SupportManifest
+}
+
+// collect writes every section, then the manifest
+func (b *bundleWriter) collect(ctx context.Context, ctrl Controller, registry map[string][]Variant) error {
+	families := sortedFamilies(registry)
+	progressFrom(ctx).plan(stepCollectStatus, stepHashBinaries, stepCopyLogs, stepWriteBundle)
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{stepCollectStatus, func() error {
+			if err := b.addJSON("build.json", map[string]any{
+				"build_date": config.BuildDate,
+				"build_time": config.BuildTime,
+				"go_version": runtime.Version(),
+				"manifest":   embeddedManifest,
+			}); err != nil {
+				return err
+			}
+			statuses := queryAll(ctx, ctrl, registry).Families
+			for family, res := range probeFamilies(ctx, registry, statuses) {
+				fs := statuses[family]
+				fs.Probe = res
+				statuses[family] = fs
+			}
+			if err := b.addJSON("status.json", statuses); err != nil {
+				return err
+			}
+			for _, family := range families {
+				for _, v := range registry[family] {
+					if err := b.addJSON("services/"+v.RegistryName+".json", collectService(ctx, ctrl, v)); err != nil {
+						return err
+					}
+				}
+			}
+			return b.addJSON("environment.json", supportEnvironment(os.Environ()))
+		}},
+		{stepHashBinaries, func() error {
+			var binaries []supportBinary
+			for _, family := range families {
+				for _, v := range registry[family] {
+					binaries = append(binaries, collectBinary(NewManagerWithController(v, ctrl)))
+				}
+			}
+			return b.addJSON("binaries.json", binaries)
+		}},
+		{stepCopyLogs, func() error {
+			for _, family := range families {
+				for _, v := range registry[family] {
+					mgr := NewManagerWithController(v, ctrl)
+					progressFrom(ctx).detail("copiando logs de '%s'", v.RegistryName)
+					if err := b.addLogs(ctx, mgr.GetLogDir(), "logs/"+v.RegistryName); err != nil {
+						return err
+					}
+				}
+			}
+			return b.addInstallerFiles(auditDir(ctrl))
+		}},
+		{stepWriteBundle, func() error {
+			return b.addJSON("manifest.json", b.manifest)
+		}},
+	}
+
+	for _, st := range steps {
+		if err := runStep(ctx, st.name, func() error {
+			if err := ctx.Err(); err != nil {
+				return &Error{Op: "support", Category: CategoryCancelled, Msg: "paquete de soporte cancelado", Err: err}
+			}
+			return st.fn()
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add writes one file and records its size and hash
+func (b *bundleWriter) add(name string, data []byte, truncated bool) error {
+	w, err := b.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: b.manifest.Created})
+	if err != nil {
+		return fmt.Errorf("escribir %s en el paquete: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("escribir %s en el paquete: %w", name, err)
+	}
+	if name != "manifest.json" {
+		b.manifest.Files = append(b.manifest.Files, SupportFile{
+			Name: name, Size: int64(len(data)), SHA256: manifest.Hash(data), Truncated: truncated,
+		})
+	}
+	return nil
+}
+
+// addJSON writes v as indented JSON
+func (b *bundleWriter) addJSON(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("serializar %s: %w", name, err)
+	}
+	return b.add(name, data, false)
+}
+
+// fail records a section that could not be collected
+func (b *bundleWriter) fail(format string, args ...any) {
+	b.manifest.Errors = append(b.manifest.Errors, fmt.Sprintf(format, args...))
+}
+
+// addLogs copies the newest plain log files of dir under prefix. Compressed
+// archives are left out; a missing directory (a variant that never ran)
+// adds nothing.
+func (b *bundleWriter) addLogs(ctx context.Context, dir, prefix string) error {
+	files, err := logFiles(dir)
+	if err != nil {
+		b.fail("%s: %v", prefix, err)
+		return nil
+	}
+	var logs []os.FileInfo
+	for _, f := range files {
+		if !isLogArchive(f.Name()) {
+			logs = append(logs, f)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].ModTime().After(logs[j].ModTime())
+	})
+
+	for _, f := range logs[:min(len(logs), supportLogFiles)] {
+		if ctx.Err() != nil {
+			return nil // collect reports the cancellation at the next step
+		}
+		if err := b.addTail(filepath.Join(dir, f.Name()), path.Join(prefix, f.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addInstallerFiles copies the installer's data directory: audit log and
+// its backups, configuration, journals of interrupted operations
+func (b *bundleWriter) addInstallerFiles(dir string) error {
+	files, err := logFiles(dir)
+	if err != nil {
+		b.fail("installer: %v", err)
+		return nil
+	}
+	for _, f := range files {
+		if err := b.addTail(filepath.Join(dir, f.Name()), "installer/"+f.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTail copies the last supportTailBytes of a file. A file that cannot be
+// read is recorded as an error of the bundle, not of the run.
+func (b *bundleWriter) addTail(src, name string) error {
+	data, truncated, err := readTail(src, supportTailBytes)
+	if err != nil {
+		b.fail("%s: %v", name, err)
+		return nil
+	}
+	return b.add(name, data, truncated)
+}
+
+// readTail returns up to n bytes from the end of a file, starting at a line
+// boundary when the start is cut
+func readTail(src string, n int64) ([]byte, bool, error) {
+	//nolint:gosec // files listed from the log and installer directories
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	start := max(info.Size()-n, 0)
+	data := make([]byte, info.Size()-start)
+	read, err := f.ReadAt(data, start)
+	if err != nil && err != io.EOF {
+		return nil, false, err
+	}
+	data = data[:read]
+	if start > 0 {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return data, start > 0, nil
+}
+
+// collectService queries the variant directly, bypassing the status cache
+func collectService(ctx context.Context, ctrl Controller, v Variant) supportService {
+	s := supportService{Variant: v.ID, Name: v.RegistryName}
+	state, err := ctrl.Query(ctx, v.RegistryName)
+	s.QueryEx = state
+	if err != nil {
+		s.QueryError = err.Error()
+		return s // Not installed: there is no configuration either
+	}
+	cfg, err := ctrl.QueryConfig(ctx, v.RegistryName)
+	s.QC = cfg
+	if err != nil {
+		s.ConfigError = err.Error()
+	}
+	return s
+}
+
+// collectBinary hashes the installed binary of a variant against the bytes
+// embedded in this installer
+func collectBinary(m *Manager) supportBinary {
+	v := m.variant
+	entry := supportBinary{
+		Variant:        v.ID,
+		Exe:            v.ExeName,
+		Manifest:       v.Manifest,
+		EmbeddedSHA256: manifest.Hash(v.Binary),
+	}
+	_, absPath, err := m.installPaths()
+	if err != nil {
+		entry.Outcome, entry.Error = "error", err.Error()
+		return entry
+	}
+	entry.Path = absPath
+
+	sum, size, err := manifest.HashFile(absPath)
+	switch {
+	case os.IsNotExist(err):
+		entry.Outcome = "missing"
+	case err != nil:
+		entry.Outcome, entry.Error = "error", err.Error()
+	case sum == entry.EmbeddedSHA256:
+		entry.InstalledSHA256, entry.InstalledSize, entry.Outcome = sum, size, "match"
+	default:
+		entry.InstalledSHA256, entry.InstalledSize, entry.Outcome = sum, size, "mismatch"
+	}
+	return entry
+}
+
+// supportEnvironment picks the relevant variables out of environ ("KEY=value"
+// pairs) and redacts the values of those that look like secrets
+func supportEnvironment(environ []string) map[string]string {
+	env := make(map[string]string)
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			continue // Windows keeps per-drive directories as "=C:=C:\…"
+		}
+		upper := strings.ToUpper(key)
+		if !supportEnvWanted(upper) {
+			continue
+		}
+		for _, marker := range secretMarkers {
+			if strings.Contains(upper, marker) {
+				value = "[REDACTADO]"
+				break
+			}
+		}
+		env[key] = value
+	}
+	return env
+}
+
+// supportEnvWanted reports whether an upper-cased variable name belongs in
+// the bundle
+func supportEnvWanted(upper string) bool {
+	for _, name := range supportEnvVars {
+		if upper == name {
+			return true
+		}
+	}
+	for _, prefix := range supportEnvPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bundleSafeName keeps letters, digits, '-' and '_' of a host name for use
+// in a file name
+func bundleSafeName(s string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return -1
+		}
+	}, s)
+	if safe == "" {
+		return "equipo"
+	}
+	return safe
+}
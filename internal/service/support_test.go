@@ -0,0 +1,124 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adcondev/poster-tuis/internal/manifest"
+)
+
+func TestSupportEnvironment(t *testing.T) {
+	got := supportEnvironment([]string{
+		"R2K_AUTH_TOKEN=abc123",
+		"r2k_api_token=def456",
+		"POS_DB_PASSWORD=secreto",
+		"R2K_PRINTER=EPSON TM-T20",
+		"PATH=C:\\Windows",
+		"HOME=C:\\Users\\caja",
+		"AWS_SECRET_ACCESS_KEY=fuera",
+		"=C:=C:\\",
+	})
+	want := map[string]string{
+		"R2K_AUTH_TOKEN":  "[REDACTADO]",
+		"r2k_api_token":   "[REDACTADO]",
+		"POS_DB_PASSWORD": "[REDACTADO]",
+		"R2K_PRINTER":     "EPSON TM-T20",
+		"PATH":            "C:\\Windows",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("supportEnvironment = %v, se esperaba %v", got, want)
+	}
+}
+
+// readBundle returns the files of a support bundle by name
+func readBundle(t *testing.T, path string) map[string][]byte {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("abrir paquete: %v", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("abrir %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("leer %s: %v", f.Name, err)
+		}
+		files[f.Name] = data
+	}
+	return files
+}
+
+func TestWriteSupportBundle(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	t.Setenv("R2K_TEST_TOKEN", "no-debe-salir")
+	t.Setenv("R2K_TEST_MODE", "prueba")
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	active := filepath.Base(m.GetLogPath())
+	writeLog(t, m.GetLogDir(), active, 200, now)
+	// Longer than the tail kept per file
+	writeLog(t, m.GetLogDir(), active+".1", supportTailBytes+4096, now.Add(-time.Hour))
+
+	path, err := writeSupportBundle(ctx, ctrl, map[string][]Variant{m.variant.Family: {m.variant}}, t.TempDir(), now)
+	if err != nil {
+		t.Fatalf("writeSupportBundle: %v", err)
+	}
+	files := readBundle(t, path)
+
+	var sm SupportManifest
+	if err := json.Unmarshal(files["manifest.json"], &sm); err != nil {
+		t.Fatalf("manifest.json: %v", err)
+	}
+	if len(sm.Files) != len(files)-1 {
+		t.Errorf("el manifiesto lista %d archivos, el paquete tiene %d además del manifiesto", len(sm.Files), len(files)-1)
+	}
+	logPrefix := "logs/" + m.variant.RegistryName + "/"
+	for _, f := range sm.Files {
+		data, ok := files[f.Name]
+		if !ok {
+			t.Errorf("%s está en el manifiesto pero no en el paquete", f.Name)
+			continue
+		}
+		if f.SHA256 != manifest.Hash(data) || f.Size != int64(len(data)) {
+			t.Errorf("%s: manifiesto %s/%d, contenido %s/%d", f.Name, f.SHA256, f.Size, manifest.Hash(data), len(data))
+		}
+		if want := f.Name == logPrefix+active+".1"; f.Truncated != want {
+			t.Errorf("%s: Truncated = %v, se esperaba %v", f.Name, f.Truncated, want)
+		}
+	}
+	for _, name := range []string{logPrefix + active, logPrefix + active + ".1", "environment.json", "status.json",
+		"services/" + m.variant.RegistryName + ".json", "binaries.json", "installer/" + auditFileName} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("falta %s en el paquete", name)
+		}
+	}
+
+	var env map[string]string
+	if err := json.Unmarshal(files["environment.json"], &env); err != nil {
+		t.Fatalf("environment.json: %v", err)
+	}
+	if env["R2K_TEST_TOKEN"] != "[REDACTADO]" || env["R2K_TEST_MODE"] != "prueba" {
+		t.Errorf("environment.json = %v", env)
+	}
+	if strings.Contains(string(files["environment.json"]), "no-debe-salir") {
+		t.Error("el secreto aparece en environment.json")
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Variant Switch
+// ══════════════════════════════════════════════════════════════
+
+// SwitchVariant replaces the installed variant of a family with target
+// ("Local" or "Remoto"). The new variant is installed only once the old one
+// is cleanly removed; if the new variant fails to install or start, the
+// original is reinstalled with its registered account, start type and
+// recovery policy (and restarted if it was running) so the terminal is never
+// left without a service. A variant with running dependents is left
+// untouched (CategoryDependentsRunning). Cancelling ctx aborts the new install;
+// the restore of the original variant still runs to completion.
+func SwitchVariant(ctx context.Context, family, target string) error {
+	return switchVariant(ctx, defaultController, family, GetServiceRegistry()[family], target)
+}
+
+// switchVariant implements SwitchVariant against an explicit backend and
+// the family's variants
+func switchVariant(ctx context.Context, ctrl Controller, family string, variants []Variant, target string) (err error) {
+	ctx, end := beginAudit(ctx, ctrl, familyVariant(family, variants, target), AuditSwitch)
+	defer end(&err)
+
+	if len(variants) == 0 {
+		return &Error{Op: "switch", Category: CategoryInvalid, Msg: fmt.Sprintf("familia desconocida: %q", family)}
+	}
+
+	var from, to *Manager
+	for _, v := range variants {
+		mgr := NewManagerWithController(v, ctrl)
+		if v.Variant == target {
+			to = mgr
+		} else if mgr.status(ctx) != StatusNotInstalled {
+			from = mgr
+		}
+	}
+	if to == nil {
+		return &Error{Op: "switch", Category: CategoryInvalid, Msg: fmt.Sprintf("variante desconocida: %q", target)}
+	}
+	if to.status(ctx) != StatusNotInstalled {
+		if from != nil {
+			return to.opError("switch", CategoryBusy, nil,
+				"ambas variantes están registradas — resuelva el conflicto antes de cambiar")
+		}
+		return to.opError("switch", CategoryAlreadyExists, nil, "la versión %s ya está instalada", target)
+	}
+	if from == nil {
+		return to.opError("switch", CategoryNotInstalled, nil, "no hay una versión instalada que cambiar — use Instalar")
+	}
+
+	wasRunning := from.status(ctx) == StatusRunning
+	// The target takes over the port the current variant holds; only other
+	// programs on it stop the switch before anything is removed
+	if err := to.checkPort(replacingVariant(ctx, from.variant.ID), "switch"); err != nil {
+		return err
+	}
+	// The restore puts back a daemon that held its port moments ago
+	restoreCtx := AllowPortConflicts(context.WithoutCancel(ctx))
+	accountKept := from.adoptRegistration(ctx)
+
+	// 1. Remove the current variant; abort untouched if it cannot be removed
+	if err := from.removeRegistration(ctx); err != nil {
+		if wasRunning {
+			_ = from.startAndWait(restoreCtx, "rollback")
+		}
+		return to.opError("switch", CategoryOf(err), err,
+			"no se pudo desinstalar %s: %v — no se instaló %s", from.variant.Variant, err, target)
+	}
+
+	// 2. Install and start the target variant as one transaction
+	installErr := to.InstallAndStart(ctx)
+	if installErr == nil {
+		return nil
+	}
+
+	// 3. Put the original variant back the way it was
+	restore := from.Install
+	if wasRunning {
+		restore = from.InstallAndStart
+	}
+	if err := restore(restoreCtx); err != nil {
+		return to.opError("switch", CategoryOf(installErr), errors.Join(installErr, err),
+			"no se pudo instalar %s: %v — y la reinstalación de %s también falló: %v",
+			target, installErr, from.variant.Variant, err)
+	}
+	if !accountKept {
+		return to.opError("switch", CategoryOf(installErr), installErr,
+			"no se pudo instalar %s: %v — se restauró la versión %s con la cuenta %s (la contraseña de la cuenta anterior no se conserva)",
+			target, installErr, from.variant.Variant, from.variant.Account)
+	}
+	return to.opError("switch", CategoryOf(installErr), installErr,
+		"no se pudo instalar %s: %v — se restauró la versión %s", target, installErr, from.variant.Variant)
+}
+
+// adoptRegistration makes the next Install reproduce the installed
+// registration: its account, start type and recovery policy. The
+// password of a custom account cannot be read back, so such an account is
+// only kept if this manager already holds it; otherwise the variant's
+// default account is used and false is returned.
+func (m *Manager) adoptRegistration(ctx context.Context) (accountKept bool) {
+	cfg, err := m.ctrl.QueryConfig(ctx, m.variant.RegistryName)
+	if err != nil {
+		return true
+	}
+	if t := StartTypeOf(cfg); t != "" {
+		m.variant.StartType = t
+	}
+	if p, err := m.ctrl.QueryFailure(ctx, m.variant.RegistryName); err == nil && p.Validate() == nil {
+		m.variant.Recovery = p
+	}
+
+	switch {
+	case cfg.Account == "":
+		return true
+	case isBuiltinAccount(cfg.Account):
+		m.variant.Account = ServiceAccount{Name: builtinAccountName(cfg.Account)}
+		return true
+	case strings.EqualFold(cfg.Account, m.variant.Account.Name):
+		return true
+	default:
+		m.variant.Account = DefaultAccount(m.variant.Variant)
+		return false
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Conflict Repair
+// ══════════════════════════════════════════════════════════════
+
+// ResolveConflict repairs a family where both variants are registered by
+// keeping the variant named keep ("Local" or "Remoto") and uninstalling the
+// other one.
+func ResolveConflict(ctx context.Context, family, keep string) error {
+	return resolveConflict(ctx, defaultController, family, GetServiceRegistry()[family], keep)
+}
+
+// resolveConflict implements ResolveConflict against an explicit backend
+// and the family's variants
+func resolveConflict(ctx context.Context, ctrl Controller, family string, variants []Variant, keep string) (err error) {
+	ctx, end := beginAudit(ctx, ctrl, familyVariant(family, variants, keep), AuditResolveConflict)
+	defer end(&err)
+
+	if len(variants) == 0 {
+		return &Error{Op: "resolve", Category: CategoryInvalid, Msg: fmt.Sprintf("familia desconocida: %q", family)}
+	}
+
+	var kept, removed *Manager
+	for _, v := range variants {
+		if v.Variant == keep {
+			kept = NewManagerWithController(v, ctrl)
+		} else {
+			removed = NewManagerWithController(v, ctrl)
+		}
+	}
+	if kept == nil || removed == nil {
+		return &Error{Op: "resolve", Category: CategoryInvalid, Msg: fmt.Sprintf("variante desconocida: %q", keep)}
+	}
+	if kept.status(ctx) == StatusNotInstalled || removed.status(ctx) == StatusNotInstalled {
+		return kept.opError("resolve", CategoryInvalid, nil, "no hay conflicto: solo una variante está registrada")
+	}
+
+	if err := removed.removeRegistration(ctx); err != nil {
+		return removed.opError("resolve", CategoryOf(err), err,
+			"no se pudo desinstalar %s: %v", removed.variant.Variant, err)
+	}
+	return nil
+}
+
+// removeRegistration uninstalls a variant for a switch or a conflict repair.
+// What matters is that the registration is gone: a service marked for
+// deletion is waited out, and files left in the old variant's directory do
+// not block the new install.
+func (m *Manager) removeRegistration(ctx context.Context) error {
+	err := m.Uninstall(ctx)
+	if err == nil {
+		return nil
+	}
+	if CategoryOf(err) == CategoryMarkedForDeletion {
+		if m.WaitForStatus(ctx, StatusNotInstalled, m.opts.DeleteTimeout) != nil {
+			return err
+		}
+		// Finish the cleanup Uninstall skipped
+		_ = m.removeFiles("uninstall")
+		return nil
+	}
+	if m.status(ctx) == StatusNotInstalled {
+		return nil
+	}
+	return err
+}
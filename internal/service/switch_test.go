@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAdoptRegistration(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	policy := RecoveryPolicy{
+		ResetPeriod: time.Hour,
+		Actions:     []RecoveryAction{{Type: RecoveryRestart, Delay: 10 * time.Second}},
+	}
+	if err := m.SetInstallStartType(StartManual); err != nil {
+		t.Fatalf("SetInstallStartType: %v", err)
+	}
+	if err := m.SetInstallAccount(ServiceAccount{Name: AccountNetworkService}); err != nil {
+		t.Fatalf("SetInstallAccount: %v", err)
+	}
+	m.variant.Recovery = policy
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	// A fresh manager, as the switch builds it, starts from the defaults
+	restored := NewManagerWithController(m.variant, ctrl)
+	restored.variant.StartType = StartAuto
+	restored.variant.Account = DefaultAccount(Local)
+	restored.variant.Recovery = DefaultRecoveryPolicy("scale")
+
+	if !restored.adoptRegistration(ctx) {
+		t.Error("adoptRegistration = false, se esperaba conservar la cuenta integrada")
+	}
+	if got := restored.StartType(); got != StartManual {
+		t.Errorf("StartType = %v, se esperaba %v", got, StartManual)
+	}
+	if got := restored.Account(); got != (ServiceAccount{Name: AccountNetworkService}) {
+		t.Errorf("Account = %v, se esperaba %v", got, AccountNetworkService)
+	}
+	if got := restored.RecoveryPolicy(); !got.Equal(policy) {
+		t.Errorf("RecoveryPolicy = %+v, se esperaba %+v", got, policy)
+	}
+}
+
+func TestAdoptRegistrationCustomAccount(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	custom := ServiceAccount{Name: `.\bascula`, Password: "secreto"}
+	if err := m.SetInstallAccount(custom); err != nil {
+		t.Fatalf("SetInstallAccount: %v", err)
+	}
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	// The manager that installed it still holds the password
+	if !m.adoptRegistration(ctx) || m.Account() != custom {
+		t.Errorf("Account = %v, se esperaba conservar %v", m.Account(), custom)
+	}
+
+	// Without the password the default account is used instead
+	restored := NewManagerWithController(m.variant, ctrl)
+	restored.variant.Account = DefaultAccount(Local)
+	if restored.adoptRegistration(ctx) {
+		t.Error("adoptRegistration = true sin la contraseña de la cuenta personalizada")
+	}
+	if got := restored.Account(); got != DefaultAccount(Local) {
+		t.Errorf("Account = %v, se esperaba %v", got, DefaultAccount(Local))
+	}
+}
+
+// testFamily returns the two variants of a test family over one backend
+func testFamily(t *testing.T) (*MemoryController, []Variant) {
+	t.Helper()
+	remote := testVariant("test-remoto", "Test_Servicio_Remoto")
+	remote.Variant = Remoto
+	remote.Account = DefaultAccount(Remoto)
+	return NewMemoryController(t.TempDir()), []Variant{testVariant("test-local", "Test_Servicio_Local"), remote}
+}
+
+func TestSwitchVariant(t *testing.T) {
+	ctrl, variants := testFamily(t)
+	ctx := context.Background()
+	if err := NewManagerWithController(variants[0], ctrl).InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+
+	if err := switchVariant(ctx, ctrl, "test", variants, Remoto); err != nil {
+		t.Fatalf("switchVariant: %v", err)
+	}
+	if got := NewManagerWithController(variants[0], ctrl).status(ctx); got != StatusNotInstalled {
+		t.Errorf("estado de Local = %v, se esperaba %v", got, StatusNotInstalled)
+	}
+	if got := NewManagerWithController(variants[1], ctrl).status(ctx); got != StatusRunning {
+		t.Errorf("estado de Remoto = %v, se esperaba %v", got, StatusRunning)
+	}
+}
+
+func TestSwitchVariantRestoresOriginal(t *testing.T) {
+	tests := []struct {
+		name string
+		op   string // MemoryController operation of the target that fails
+		code int
+		want ErrorCategory
+	}{
+		{"falla la instalación", "create", win32AccessDenied, CategoryAccessDenied},
+		{"falla el inicio", "start", win32ServiceLogonFailed, CategoryLogonFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, variants := testFamily(t)
+			ctx := context.Background()
+			original := NewManagerWithController(variants[0], ctrl)
+			if err := original.SetInstallStartType(StartManual); err != nil {
+				t.Fatalf("SetInstallStartType: %v", err)
+			}
+			if err := original.SetInstallAccount(ServiceAccount{Name: AccountNetworkService}); err != nil {
+				t.Fatalf("SetInstallAccount: %v", err)
+			}
+			if err := original.InstallAndStart(ctx); err != nil {
+				t.Fatalf("InstallAndStart: %v", err)
+			}
+			ctrl.FailNext(tt.op, tt.code)
+
+			assertCategory(t, switchVariant(ctx, ctrl, "test", variants, Remoto), tt.want)
+
+			if got := original.status(ctx); got != StatusRunning {
+				t.Errorf("estado de Local = %v, se esperaba %v", got, StatusRunning)
+			}
+			cfg, err := ctrl.QueryConfig(ctx, variants[0].RegistryName)
+			if err != nil {
+				t.Fatalf("QueryConfig: %v", err)
+			}
+			if got := StartTypeOf(cfg); got != StartManual {
+				t.Errorf("StartType = %v, se esperaba conservar %v", got, StartManual)
+			}
+			if builtinAccountName(cfg.Account) != AccountNetworkService {
+				t.Errorf("Account = %q, se esperaba conservar %s", cfg.Account, AccountNetworkService)
+			}
+			if registered, _ := ctrl.Installed(variants[1].RegistryName); registered {
+				t.Error("Remoto quedó registrado tras la restauración")
+			}
+		})
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	ctrl, variants := testFamily(t)
+	ctx := context.Background()
+	for _, v := range variants {
+		if err := NewManagerWithController(v, ctrl).Install(ctx); err != nil {
+			t.Fatalf("Install %s: %v", v.Variant, err)
+		}
+	}
+
+	assertCategory(t, resolveConflict(ctx, ctrl, "test", variants, "Otra"), CategoryInvalid)
+	if err := resolveConflict(ctx, ctrl, "test", variants, Local); err != nil {
+		t.Fatalf("resolveConflict: %v", err)
+	}
+	if registered, _ := ctrl.Installed(variants[0].RegistryName); !registered {
+		t.Error("se desinstaló la variante conservada")
+	}
+	if registered, _ := ctrl.Installed(variants[1].RegistryName); registered {
+		t.Error("la otra variante sigue registrada")
+	}
+	// With a single variant left there is nothing to repair
+	assertCategory(t, resolveConflict(ctx, ctrl, "test", variants, Local), CategoryInvalid)
+	assertCategory(t, resolveConflict(ctx, ctrl, "desconocida", nil, Local), CategoryInvalid)
+}
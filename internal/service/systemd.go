@@ -0,0 +1,752 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// systemd Backend
+// ══════════════════════════════════════════════════════════════
+
+// SystemdController manages services as systemd units on Linux terminals.
+// Each variant gets /etc/systemd/system/{RegistryName}.service, its binary
+// under /opt/{RegistryName} and its logs under /var/log/{RegistryName}.
+//
+// Failures are translated into the Win32 codes sc.exe would report so that
+// Manager applies the same policy on both platforms.
+type SystemdController struct {
+	// Root is prepended to every filesystem path ("/" in production)
+	Root string
+	// Systemctl is the systemctl executable (resolved via LookPath if bare)
+	Systemctl string
+}
+
+// NewSystemdController creates a backend for the running system
+func NewSystemdController() *SystemdController {
+	return &SystemdController{Root: "/", Systemctl: "systemctl"}
+}
+
+// systemdEquivalents maps the Windows services variants depend on to the
+// units that play the same role on Linux
+var systemdEquivalents = map[string]string{
+	DependencySpooler: "cups.service",
+	DependencyTcpip:   "network-online.target",
+}
+
+// unitName returns the systemd unit name for a registry name
+func unitName(regName string) string {
+	if unit, ok := systemdEquivalents[regName]; ok {
+		return unit
+	}
+	return regName + ".service"
+}
+
+// unitPath returns the unit file location for a registry name
+func (c *SystemdController) unitPath(regName string) string {
+	return filepath.Join(c.Root, "etc", "systemd", "system", unitName(regName))
+}
+
+// dropInDir returns the drop-in directory for a registry name
+func (c *SystemdController) dropInDir(regName string) string {
+	return c.unitPath(regName) + ".d"
+}
+
+// systemctl runs systemctl under ctx and returns its combined output
+func (c *SystemdController) systemctl(ctx context.Context, args ...string) ([]byte, error) {
+	exe, err := exec.LookPath(c.Systemctl)
+	if err != nil {
+		return nil, fmt.Errorf("systemctl executable not found: %w", err)
+	}
+
+	// Bounded even without a caller deadline; cancelling ctx kills systemctl
+	ctx, cancel := context.WithTimeout(ctx, toolTimeout)
+	defer cancel()
+
+	//nolint:gosec // unit names validated by callers and systemctl resolved via LookPath
+	cmd := exec.CommandContext(ctx, exe, args...)
+	return cmd.CombinedOutput()
+}
+
+// escapeUnitValue escapes systemd specifiers in a unit file value
+func escapeUnitValue(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// Create writes the unit file, reloads systemd and applies the start type
+func (c *SystemdController) Create(ctx context.Context, regName, binPath, displayName string, opts CreateOptions) ([]byte, error) {
+	if !isValidServiceName(regName) {
+		return nil, fmt.Errorf("invalid RegistryName")
+	}
+	if !isValidDisplayName(displayName) {
+		return nil, fmt.Errorf("invalid DisplayName")
+	}
+	if hasPathTraversal(binPath) || strings.ContainsAny(binPath, "\"\n\r") {
+		return nil, fmt.Errorf("invalid binPath")
+	}
+	if err := opts.StartType.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid StartType: %w", err)
+	}
+	if err := opts.Account.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid Account: %w", err)
+	}
+	for _, dep := range opts.Dependencies {
+		if !isValidServiceName(dep) {
+			return nil, fmt.Errorf("invalid dependency %q", dep)
+		}
+	}
+
+	unitPath := c.unitPath(regName)
+	if _, err := os.Stat(unitPath); err == nil {
+		return scFailure("CreateService", win32ServiceExists)
+	}
+
+	// LogsDirectory= has systemd create the log directory owned by the
+	// account the unit runs as (DynamicUser= or User=, see applyAccount)
+	unit := fmt.Sprintf(`[Unit]
+Description=%s
+After=network.target
+
+[Service]
+Type=simple
+ExecStart="%s"
+WorkingDirectory=%s
+LogsDirectory=%s
+
+[Install]
+WantedBy=multi-user.target
+`, escapeUnitValue(displayName), escapeUnitValue(binPath), escapeUnitValue(filepath.Dir(binPath)), regName)
+
+	if err := os.MkdirAll(filepath.Dir(unitPath), 0750); err != nil {
+		return nil, fmt.Errorf("crear directorio de unidades: %w", err)
+	}
+	//nolint:gosec // unit files must be world-readable for systemd
+	if err := os.WriteFile(unitPath, []byte(unit), 0644); err != nil {
+		return nil, fmt.Errorf("escribir unidad: %w", err)
+	}
+
+	// From here on a failure leaves nothing behind, as a failed sc create
+	// registers nothing
+	if output, err := c.systemctl(ctx, "daemon-reload"); err != nil {
+		c.discardUnit(ctx, regName)
+		return output, err
+	}
+	if err := c.writeDependencies(regName, opts.Dependencies); err != nil {
+		c.discardUnit(ctx, regName)
+		return nil, err
+	}
+	if output, err := c.applyAccount(ctx, regName, opts.Account); err != nil {
+		c.discardUnit(ctx, regName)
+		return output, err
+	}
+	output, err := c.applyStartType(ctx, regName, opts.StartType)
+	if err != nil {
+		c.discardUnit(ctx, regName)
+	}
+	return output, err
+}
+
+// discardUnit removes a half-created unit and its drop-ins and reloads
+// systemd so it forgets them
+func (c *SystemdController) discardUnit(ctx context.Context, regName string) {
+	_ = os.RemoveAll(c.dropInDir(regName))
+	_ = os.Remove(c.unitPath(regName))
+	_, _ = c.systemctl(ctx, "daemon-reload")
+}
+
+// descriptionDropIn holds the service description. systemd's Description=
+// is the display name, so the text is kept in an X- key, which systemd
+// ignores, and read back from the file.
+const descriptionDropIn = "description.conf"
+
+// SetDescription writes the description drop-in (removing it when empty)
+func (c *SystemdController) SetDescription(ctx context.Context, regName, description string) ([]byte, error) {
+	if !isValidServiceName(regName) {
+		return nil, fmt.Errorf("invalid RegistryName")
+	}
+	if !isValidDescription(description) {
+		return nil, fmt.Errorf("invalid Description")
+	}
+	if _, err := os.Stat(c.unitPath(regName)); os.IsNotExist(err) {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+
+	path := filepath.Join(c.dropInDir(regName), descriptionDropIn)
+	if description == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("eliminar descripción: %w", err)
+		}
+		return nil, nil
+	}
+	if err := os.MkdirAll(c.dropInDir(regName), 0750); err != nil {
+		return nil, fmt.Errorf("crear directorio drop-in: %w", err)
+	}
+	content := "[Unit]\nX-Description=" + escapeUnitValue(description) + "\n"
+	//nolint:gosec // drop-ins must be world-readable for systemd
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return nil, fmt.Errorf("escribir descripción: %w", err)
+	}
+	return nil, nil
+}
+
+// dropInDescription reads the description drop-in, or "" if there is none
+func (c *SystemdController) dropInDescription(regName string) string {
+	data, err := os.ReadFile(filepath.Join(c.dropInDir(regName), descriptionDropIn))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "X-Description="); ok {
+			return strings.ReplaceAll(value, "%%", "%")
+		}
+	}
+	return ""
+}
+
+// dependenciesDropIn holds Requires=/After= for the declared dependencies
+const dependenciesDropIn = "dependencies.conf"
+
+// dependMarker prefixes the first line of the dependencies drop-in with the
+// Windows-style names ("# depend=Spooler/Tcpip")
+const dependMarker = "# depend="
+
+// writeDependencies writes the dependencies drop-in (nothing when deps is
+// empty). systemd is reloaded by the caller.
+func (c *SystemdController) writeDependencies(regName string, deps []string) error {
+	if len(deps) == 0 {
+		return nil
+	}
+	units := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		units = append(units, unitName(dep))
+	}
+	content := fmt.Sprintf("%s%s\n[Unit]\nRequires=%s\nAfter=%s\n",
+		dependMarker, strings.Join(deps, "/"), strings.Join(units, " "), strings.Join(units, " "))
+
+	if err := os.MkdirAll(c.dropInDir(regName), 0750); err != nil {
+		return fmt.Errorf("crear directorio drop-in: %w", err)
+	}
+	//nolint:gosec // drop-ins must be world-readable for systemd
+	if err := os.WriteFile(filepath.Join(c.dropInDir(regName), dependenciesDropIn), []byte(content), 0644); err != nil {
+		return fmt.Errorf("escribir dependencias: %w", err)
+	}
+	return nil
+}
+
+// dropInDependencies reads the names recorded in the dependencies drop-in;
+// ok is false when the unit has none
+func (c *SystemdController) dropInDependencies(regName string) (deps []string, ok bool) {
+	data, err := os.ReadFile(filepath.Join(c.dropInDir(regName), dependenciesDropIn))
+	if err != nil {
+		return nil, false
+	}
+	first, _, _ := strings.Cut(string(data), "\n")
+	value := strings.TrimPrefix(first, dependMarker)
+	if value == "" {
+		return nil, true
+	}
+	return strings.Split(value, "/"), true
+}
+
+// Dependents lists the service units that require this one (RequiredBy=),
+// as registry names
+func (c *SystemdController) Dependents(ctx context.Context, regName string) ([]string, error) {
+	if !isValidServiceName(regName) {
+		return nil, fmt.Errorf("invalid RegistryName")
+	}
+	output, err := c.systemctl(ctx, "show", unitName(regName), "--property=RequiredBy")
+	if err != nil {
+		return nil, fmt.Errorf("systemctl show: %w", err)
+	}
+	var names []string
+	for _, unit := range strings.Fields(parseSystemdShow(output)["RequiredBy"]) {
+		if name, ok := strings.CutSuffix(unit, ".service"); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// accountDropIn holds the account settings. The built-in unprivileged
+// accounts map to a dynamic user, LocalSystem to root (no drop-in) and a
+// custom account to User= with its domain stripped; systemd has no use for
+// the password.
+const accountDropIn = "account.conf"
+
+// accountMarker prefixes the first line of the account drop-in so the
+// Windows-style account name can be read back
+const accountMarker = "# account="
+
+// SetAccount rewrites the account drop-in
+func (c *SystemdController) SetAccount(ctx context.Context, regName string, account ServiceAccount) ([]byte, error) {
+	if !isValidServiceName(regName) {
+		return nil, fmt.Errorf("invalid RegistryName")
+	}
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(c.unitPath(regName)); os.IsNotExist(err) {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+	return c.applyAccount(ctx, regName, account)
+}
+
+// applyAccount writes the account drop-in and reloads systemd
+func (c *SystemdController) applyAccount(ctx context.Context, regName string, account ServiceAccount) ([]byte, error) {
+	var body string
+	switch builtinAccountName(account.Name) {
+	case AccountLocalSystem:
+	case AccountLocalService, AccountNetworkService:
+		body = "[Service]\nDynamicUser=yes\n"
+	default:
+		user := account.Name
+		if i := strings.LastIndex(user, `\`); i >= 0 {
+			user = user[i+1:]
+		}
+		user, _, _ = strings.Cut(user, "@")
+		body = "[Service]\nUser=" + escapeUnitValue(user) + "\n"
+	}
+
+	path := filepath.Join(c.dropInDir(regName), accountDropIn)
+	if body == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("eliminar drop-in de cuenta: %w", err)
+		}
+	} else {
+		if err := os.MkdirAll(c.dropInDir(regName), 0750); err != nil {
+			return nil, fmt.Errorf("crear directorio drop-in: %w", err)
+		}
+		content := accountMarker + account.Name + "\n" + body
+		//nolint:gosec // drop-ins must be world-readable for systemd
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return nil, fmt.Errorf("escribir drop-in de cuenta: %w", err)
+		}
+	}
+	return c.systemctl(ctx, "daemon-reload")
+}
+
+// dropInAccount reads the account recorded in the account drop-in, or ""
+func (c *SystemdController) dropInAccount(regName string) string {
+	data, err := os.ReadFile(filepath.Join(c.dropInDir(regName), accountDropIn))
+	if err != nil {
+		return ""
+	}
+	first, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimPrefix(first, accountMarker)
+}
+
+// startTypeDropIn is the drop-in that holds the start type settings
+const startTypeDropIn = "start-type.conf"
+
+// startTypeMarker prefixes the first line of the start-type drop-in so the
+// chosen type can be read back
+const startTypeMarker = "# start="
+
+// SetStartType enables or disables the unit and rewrites its start-type
+// drop-in. Delayed automatic start orders the unit after the print spooler
+// and the network being online; disabled refuses manual starts as well.
+func (c *SystemdController) SetStartType(ctx context.Context, regName string, startType StartType) ([]byte, error) {
+	if !isValidServiceName(regName) {
+		return nil, fmt.Errorf("invalid RegistryName")
+	}
+	if err := startType.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(c.unitPath(regName)); os.IsNotExist(err) {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+	return c.applyStartType(ctx, regName, startType)
+}
+
+// applyStartType writes the start-type drop-in and runs enable/disable
+func (c *SystemdController) applyStartType(ctx context.Context, regName string, startType StartType) ([]byte, error) {
+	var body string
+	switch startType {
+	case StartDelayedAuto:
+		body = "[Unit]\nWants=network-online.target\nAfter=network-online.target cups.service\n"
+	case StartDisabled:
+		body = "[Unit]\nRefuseManualStart=yes\n"
+	}
+
+	dropIn := filepath.Join(c.dropInDir(regName), startTypeDropIn)
+	if body == "" {
+		if err := os.Remove(dropIn); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("eliminar drop-in de inicio: %w", err)
+		}
+	} else {
+		if err := os.MkdirAll(c.dropInDir(regName), 0750); err != nil {
+			return nil, fmt.Errorf("crear directorio drop-in: %w", err)
+		}
+		content := startTypeMarker + string(startType) + "\n" + body
+		//nolint:gosec // drop-ins must be world-readable for systemd
+		if err := os.WriteFile(dropIn, []byte(content), 0644); err != nil {
+			return nil, fmt.Errorf("escribir drop-in de inicio: %w", err)
+		}
+	}
+	if output, err := c.systemctl(ctx, "daemon-reload"); err != nil {
+		return output, err
+	}
+
+	if startType == StartAuto || startType == StartDelayedAuto {
+		return c.systemctl(ctx, "enable", unitName(regName))
+	}
+	return c.systemctl(ctx, "disable", unitName(regName))
+}
+
+// dropInStartType reads the start type recorded in the start-type drop-in,
+// or "" if there is none
+func (c *SystemdController) dropInStartType(regName string) StartType {
+	data, err := os.ReadFile(filepath.Join(c.dropInDir(regName), startTypeDropIn))
+	if err != nil {
+		return ""
+	}
+	first, _, _ := strings.Cut(string(data), "\n")
+	return StartType(strings.TrimPrefix(first, startTypeMarker))
+}
+
+// Delete disables the unit and removes its unit file and drop-ins
+func (c *SystemdController) Delete(ctx context.Context, regName string) ([]byte, error) {
+	if !isValidServiceName(regName) {
+		return nil, fmt.Errorf("invalid RegistryName")
+	}
+	unitPath := c.unitPath(regName)
+	if _, err := os.Stat(unitPath); os.IsNotExist(err) {
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	}
+
+	output, err := c.systemctl(ctx, "disable", unitName(regName))
+	if err != nil {
+		return output, err
+	}
+	if err := os.RemoveAll(c.dropInDir(regName)); err != nil {
+		return output, fmt.Errorf("eliminar drop-ins: %w", err)
+	}
+	if err := os.Remove(unitPath); err != nil {
+		return output, fmt.Errorf("eliminar unidad: %w", err)
+	}
+	return c.systemctl(ctx, "daemon-reload")
+}
+
+// Start queues a start job without waiting, matching `sc start` semantics
+func (c *SystemdController) Start(ctx context.Context, regName string) ([]byte, error) {
+	state, err := c.Query(ctx, regName)
+	if err != nil {
+		return nil, err
+	}
+	switch state.Status {
+	case StatusNotInstalled:
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	case StatusRunning, StatusStartPending:
+		return scFailure("StartService", win32ServiceAlreadyRunning)
+	case StatusStopPending:
+		return scFailure("StartService", win32ServiceCannotAccept)
+	}
+	if c.dropInStartType(regName) == StartDisabled {
+		return scFailure("StartService", win32ServiceDisabled)
+	}
+	return c.systemctl(ctx, "start", "--no-block", unitName(regName))
+}
+
+// Stop queues a stop job without waiting, matching `sc stop` semantics
+func (c *SystemdController) Stop(ctx context.Context, regName string) ([]byte, error) {
+	state, err := c.Query(ctx, regName)
+	if err != nil {
+		return nil, err
+	}
+	switch state.Status {
+	case StatusNotInstalled:
+		return scFailure("OpenService", win32ServiceDoesNotExist)
+	case StatusStopped:
+		return scFailure("ControlService", win32ServiceNotActive)
+	case StatusStopPending:
+		return scFailure("ControlService", win32ServiceCannotAccept)
+	}
+	return c.systemctl(ctx, "stop", "--no-block", unitName(regName))
+}
+
+// Query maps `systemctl show` ActiveState/SubState onto a ServiceState
+func (c *SystemdController) Query(ctx context.Context, regName string) (ServiceState, error) {
+	if !isValidServiceName(regName) {
+		return ServiceState{Status: StatusUnknown}, fmt.Errorf("invalid RegistryName")
+	}
+	output, err := c.systemctl(ctx, "show", unitName(regName),
+		"--property=LoadState", "--property=ActiveState", "--property=SubState",
+		"--property=MainPID", "--property=ExecMainStatus")
+	if err != nil {
+		return ServiceState{Status: StatusUnknown}, fmt.Errorf("systemctl show: %w", err)
+	}
+	props := parseSystemdShow(output)
+	status := systemdStatus(props["LoadState"], props["ActiveState"], props["SubState"])
+	if status == StatusNotInstalled {
+		return ServiceState{Status: status}, nil
+	}
+	return ServiceState{
+		Name:          regName,
+		Status:        status,
+		StateCode:     stateCodeOf(status),
+		PID:           leadingInt(props["MainPID"]),
+		Win32ExitCode: leadingInt(props["ExecMainStatus"]),
+	}, nil
+}
+
+// QueryConfig maps unit properties onto a ServiceConfig
+func (c *SystemdController) QueryConfig(ctx context.Context, regName string) (ServiceConfig, error) {
+	if !isValidServiceName(regName) {
+		return ServiceConfig{}, fmt.Errorf("invalid RegistryName")
+	}
+	output, err := c.systemctl(ctx, "show", unitName(regName),
+		"--property=LoadState", "--property=Description", "--property=ExecStart",
+		"--property=UnitFileState", "--property=Requires", "--property=User")
+	if err != nil {
+		return ServiceConfig{}, fmt.Errorf("systemctl show: %w", err)
+	}
+	props := parseSystemdShow(output)
+	if props["LoadState"] == "not-found" {
+		_, err := scFailure("OpenService", win32ServiceDoesNotExist)
+		return ServiceConfig{}, err
+	}
+
+	startType := "DEMAND_START"
+	switch props["UnitFileState"] {
+	case "enabled", "enabled-runtime":
+		startType = "AUTO_START"
+	case "masked":
+		startType = "DISABLED"
+	}
+	dropIn := c.dropInStartType(regName)
+	if dropIn == StartDisabled {
+		startType = "DISABLED"
+	}
+
+	account := c.dropInAccount(regName)
+	if account == "" {
+		account = props["User"]
+	}
+	if account == "" {
+		account = "root"
+	}
+
+	dependencies := strings.Fields(props["Requires"])
+	if deps, ok := c.dropInDependencies(regName); ok {
+		dependencies = deps
+	}
+
+	binaryPath := ""
+	if match := execStartPath.FindStringSubmatch(props["ExecStart"]); match != nil {
+		binaryPath = match[1]
+	}
+
+	return ServiceConfig{
+		Name:         regName,
+		Type:         "simple",
+		StartType:    startType,
+		DelayedStart: startType == "AUTO_START" && dropIn == StartDelayedAuto,
+		BinaryPath:   binaryPath,
+		DisplayName:  props["Description"],
+		Description:  c.dropInDescription(regName),
+		Dependencies: dependencies,
+		Account:      account,
+	}, nil
+}
+
+// execStartPath extracts path= from the ExecStart property
+// ("{ path=/opt/x/x ; argv[]=/opt/x/x ; ... }")
+var execStartPath = regexp.MustCompile(`path=([^;]+?)\s*;`)
+
+// parseSystemdShow parses Key=Value lines printed by `systemctl show`
+func parseSystemdShow(output []byte) map[string]string {
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			props[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return props
+}
+
+// systemdStatus translates unit states into the SCM-style Status enum
+func systemdStatus(loadState, activeState, subState string) Status {
+	if loadState == "not-found" || loadState == "" {
+		return StatusNotInstalled
+	}
+
+	switch activeState {
+	case "active", "reloading":
+		if subState == "exited" {
+			return StatusStopped
+		}
+		return StatusRunning
+	case "activating":
+		return StatusStartPending
+	case "deactivating":
+		return StatusStopPending
+	case "inactive", "failed":
+		return StatusStopped
+	default:
+		return StatusUnknown
+	}
+}
+
+// ConfigureFailure writes a drop-in that approximates the sc failure policy.
+// systemd has a single restart delay and no per-failure action list, so the
+// policy maps to: Restart= (on-failure, or on-abnormal without the non-crash
+// flag), RestartSec= from the first restart action, StartLimitBurst= the
+// number of restart actions within StartLimitIntervalSec= the reset period,
+// and StartLimitAction=reboot when a reboot action follows them. Running a
+// command is not supported.
+func (c *SystemdController) ConfigureFailure(ctx context.Context, regName string, policy RecoveryPolicy) ([]byte, error) {
+	if !isValidServiceName(regName) {
+		return nil, fmt.Errorf("invalid RegistryName")
+	}
+
+	restarts, restartDelay, reboot := 0, time.Duration(0), false
+	for _, a := range policy.Actions {
+		switch a.Type {
+		case RecoveryRestart:
+			if restarts == 0 {
+				restartDelay = a.Delay
+			}
+			restarts++
+		case RecoveryReboot:
+			reboot = true
+		case RecoveryRun:
+			return nil, fmt.Errorf("systemd: la acción 'Ejecutar comando' no está soportada")
+		}
+	}
+
+	restart := "no"
+	if restarts > 0 {
+		restart = "on-abnormal"
+		if policy.NonCrashFailures {
+			restart = "on-failure"
+		}
+	}
+	limitAction := "none"
+	if reboot {
+		limitAction = "reboot"
+	}
+
+	dropIn := fmt.Sprintf(`[Unit]
+StartLimitIntervalSec=%d
+StartLimitBurst=%d
+StartLimitAction=%s
+
+[Service]
+Restart=%s
+RestartSec=%dms
+`, int64(policy.ResetPeriod/time.Second), max(restarts, 1), limitAction, restart, restartDelay.Milliseconds())
+
+	dir := c.dropInDir(regName)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("crear directorio drop-in: %w", err)
+	}
+	//nolint:gosec // drop-ins must be world-readable for systemd
+	if err := os.WriteFile(filepath.Join(dir, "recovery.conf"), []byte(dropIn), 0644); err != nil {
+		return nil, fmt.Errorf("escribir política de recuperación: %w", err)
+	}
+	return c.systemctl(ctx, "daemon-reload")
+}
+
+// QueryFailure rebuilds the recovery policy from the unit's effective
+// Restart= and StartLimit*= properties (the inverse of ConfigureFailure)
+func (c *SystemdController) QueryFailure(ctx context.Context, regName string) (RecoveryPolicy, error) {
+	if !isValidServiceName(regName) {
+		return RecoveryPolicy{}, fmt.Errorf("invalid RegistryName")
+	}
+	output, err := c.systemctl(ctx, "show", unitName(regName),
+		"--property=LoadState", "--property=Restart", "--property=RestartUSec",
+		"--property=StartLimitIntervalUSec", "--property=StartLimitBurst", "--property=StartLimitAction")
+	if err != nil {
+		return RecoveryPolicy{}, fmt.Errorf("systemctl show: %w", err)
+	}
+	props := parseSystemdShow(output)
+	if props["LoadState"] == "not-found" {
+		_, err := scFailure("OpenService", win32ServiceDoesNotExist)
+		return RecoveryPolicy{}, err
+	}
+
+	policy := RecoveryPolicy{
+		ResetPeriod:      parseSystemdTimespan(props["StartLimitIntervalUSec"]),
+		NonCrashFailures: props["Restart"] == "on-failure" || props["Restart"] == "always",
+	}
+	reboot := strings.HasPrefix(props["StartLimitAction"], "reboot")
+
+	if props["Restart"] != "no" && props["Restart"] != "" {
+		restarts := leadingInt(props["StartLimitBurst"])
+		limit := MaxRecoveryActions
+		if reboot {
+			limit--
+		}
+		restarts = min(max(restarts, 1), limit)
+		delay := parseSystemdTimespan(props["RestartUSec"])
+		for range restarts {
+			policy.Actions = append(policy.Actions, RecoveryAction{Type: RecoveryRestart, Delay: delay})
+		}
+		if reboot {
+			policy.Actions = append(policy.Actions, RecoveryAction{Type: RecoveryReboot})
+		}
+	}
+	return policy, nil
+}
+
+// systemdTimespanUnits are the unit suffixes `systemctl show` prints
+var systemdTimespanUnits = map[string]time.Duration{
+	"us": time.Microsecond, "ms": time.Millisecond, "s": time.Second,
+	"min": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour,
+}
+
+// systemdTimespanPart matches one "<n><unit>" component ("1min", "30s")
+var systemdTimespanPart = regexp.MustCompile(`(\d+)([a-z]*)`)
+
+// parseSystemdTimespan parses a systemd time span ("1d", "1min 30s",
+// "100ms"). "infinity" and unparsable values yield 0.
+func parseSystemdTimespan(value string) time.Duration {
+	var total time.Duration
+	for _, match := range systemdTimespanPart.FindAllStringSubmatch(value, -1) {
+		n, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		unit, ok := systemdTimespanUnits[match[2]]
+		if !ok {
+			if match[2] != "" {
+				return 0
+			}
+			unit = time.Microsecond // bare numbers are microseconds
+		}
+		total += time.Duration(n) * unit
+	}
+	return total
+}
+
+// Kill sends SIGKILL to every process of the unit
+func (c *SystemdController) Kill(ctx context.Context, regName string) error {
+	if !isValidServiceName(regName) {
+		return fmt.Errorf("invalid RegistryName")
+	}
+	output, err := c.systemctl(ctx, "kill", "--signal=SIGKILL", unitName(regName))
+	if err != nil {
+		return fmt.Errorf("systemctl kill: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// InstallRoot returns {Root}/opt
+func (c *SystemdController) InstallRoot() (string, error) {
+	return filepath.Join(c.Root, "opt"), nil
+}
+
+// LogRoot returns {Root}/var/log
+func (c *SystemdController) LogRoot() string {
+	return filepath.Join(c.Root, "var", "log")
+}
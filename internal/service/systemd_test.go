@@ -0,0 +1,536 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSystemctl logs every call to calls.log next to itself. `show UNIT`
+// prints show-UNIT from the same directory (LoadState=not-found if absent),
+// and any command with a fail-COMMAND file prints it and exits 1.
+const fakeSystemctl = `#!/bin/sh
+dir=$(dirname "$0")
+echo "$*" >> "$dir/calls.log"
+if [ -f "$dir/fail-$1" ]; then
+	cat "$dir/fail-$1"
+	exit 1
+fi
+if [ "$1" = "show" ]; then
+	if [ -f "$dir/show-$2" ]; then
+		cat "$dir/show-$2"
+	else
+		echo "LoadState=not-found"
+	fi
+fi
+`
+
+// localSystem runs the unit as root, without an account drop-in
+var localSystem = ServiceAccount{Name: AccountLocalSystem}
+
+// systemdFixture is a SystemdController over a temporary root driven by
+// fakeSystemctl
+type systemdFixture struct {
+	ctrl *SystemdController
+	bin  string // Directory holding the fake systemctl and its files
+}
+
+// newSystemdFixture creates the temporary root and installs fakeSystemctl
+func newSystemdFixture(t *testing.T) *systemdFixture {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("el systemctl simulado es un script de shell")
+	}
+	bin := t.TempDir()
+	exe := filepath.Join(bin, "systemctl")
+	//nolint:gosec // the stand-in must be executable
+	if err := os.WriteFile(exe, []byte(fakeSystemctl), 0755); err != nil {
+		t.Fatalf("escribir systemctl simulado: %v", err)
+	}
+	return &systemdFixture{ctrl: &SystemdController{Root: t.TempDir(), Systemctl: exe}, bin: bin}
+}
+
+// calls returns the systemctl invocations so far and clears the log
+func (f *systemdFixture) calls(t *testing.T) []string {
+	t.Helper()
+	path := filepath.Join(f.bin, "calls.log")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("leer llamadas: %v", err)
+	}
+	_ = os.Remove(path)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// show sets what `systemctl show unit` prints
+func (f *systemdFixture) show(t *testing.T, unit, output string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(f.bin, "show-"+unit), []byte(output), 0600); err != nil {
+		t.Fatalf("escribir salida de show: %v", err)
+	}
+}
+
+// fail makes every `systemctl command` fail with output
+func (f *systemdFixture) fail(t *testing.T, command, output string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(f.bin, "fail-"+command), []byte(output), 0600); err != nil {
+		t.Fatalf("escribir falla: %v", err)
+	}
+}
+
+// read returns the content of a file under the unit directory
+func (f *systemdFixture) read(t *testing.T, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(f.ctrl.Root, "etc", "systemd", "system", rel))
+	if err != nil {
+		t.Fatalf("leer %s: %v", rel, err)
+	}
+	return string(data)
+}
+
+// assertContains fails unless every want line appears in content
+func assertContains(t *testing.T, name, content string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(content, w) {
+			t.Errorf("%s no contiene %q:\n%s", name, w, content)
+		}
+	}
+}
+
+// assertSCCode fails unless output reports the given Win32 code
+func assertSCCode(t *testing.T, output []byte, err error, want int) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("se esperaba el código %d, no hubo error", want)
+	}
+	if code, ok := ParseSCError(output); !ok || code != want {
+		t.Fatalf("código = %d (%v), se esperaba %d; salida: %s", code, ok, want, output)
+	}
+}
+
+func TestSystemdCreate(t *testing.T) {
+	f := newSystemdFixture(t)
+	ctx := context.Background()
+	binPath := "/opt/Test_Servicio/Test_Servicio.exe"
+
+	_, err := f.ctrl.Create(ctx, "Test_Servicio", binPath, "Servicio de Prueba 100%", CreateOptions{
+		StartType:    StartDelayedAuto,
+		Account:      ServiceAccount{Name: AccountNetworkService},
+		Dependencies: []string{DependencySpooler, DependencyTcpip},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	assertContains(t, "unidad", f.read(t, "Test_Servicio.service"),
+		"Description=Servicio de Prueba 100%%\n",
+		`ExecStart="/opt/Test_Servicio/Test_Servicio.exe"`+"\n",
+		"WorkingDirectory=/opt/Test_Servicio\n",
+		"LogsDirectory=Test_Servicio\n",
+		"WantedBy=multi-user.target\n")
+	assertContains(t, "dependencias", f.read(t, "Test_Servicio.service.d/dependencies.conf"),
+		"# depend=Spooler/Tcpip\n",
+		"Requires=cups.service network-online.target\n",
+		"After=cups.service network-online.target\n")
+	assertContains(t, "cuenta", f.read(t, "Test_Servicio.service.d/account.conf"),
+		"# account=NT AUTHORITY\\NetworkService\n",
+		"DynamicUser=yes\n")
+	assertContains(t, "tipo de inicio", f.read(t, "Test_Servicio.service.d/start-type.conf"),
+		"# start=delayed-auto\n",
+		"After=network-online.target cups.service\n")
+
+	want := []string{"daemon-reload", "daemon-reload", "daemon-reload", "enable Test_Servicio.service"}
+	if got := f.calls(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("llamadas = %q, se esperaba %q", got, want)
+	}
+}
+
+func TestSystemdCreateLocalSystemManual(t *testing.T) {
+	f := newSystemdFixture(t)
+
+	_, err := f.ctrl.Create(context.Background(), "Test_Servicio", "/opt/Test_Servicio/Test_Servicio.exe", "Prueba",
+		CreateOptions{StartType: StartManual, Account: localSystem})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	assertContains(t, "unidad", f.read(t, "Test_Servicio.service"),
+		`ExecStart="/opt/Test_Servicio/Test_Servicio.exe"`+"\n")
+
+	// LocalSystem runs as root and needs no account drop-in
+	if _, err := os.Stat(filepath.Join(f.ctrl.dropInDir("Test_Servicio"), accountDropIn)); !os.IsNotExist(err) {
+		t.Errorf("drop-in de cuenta para LocalSystem (stat: %v)", err)
+	}
+	if got := f.calls(t); got[len(got)-1] != "disable Test_Servicio.service" {
+		t.Errorf("última llamada = %q, se esperaba disable", got[len(got)-1])
+	}
+}
+
+func TestSystemdCreateCustomAccount(t *testing.T) {
+	f := newSystemdFixture(t)
+
+	_, err := f.ctrl.Create(context.Background(), "Test_Servicio", "/opt/Test_Servicio/Test_Servicio.exe", "Prueba",
+		CreateOptions{StartType: StartAuto, Account: ServiceAccount{Name: `TIENDA\cajero`, Password: "secreto"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	account := f.read(t, "Test_Servicio.service.d/account.conf")
+	assertContains(t, "cuenta", account, "# account=TIENDA\\cajero\n", "User=cajero\n")
+	if strings.Contains(account, "secreto") {
+		t.Error("la contraseña se escribió en el drop-in")
+	}
+}
+
+func TestSystemdCreateExisting(t *testing.T) {
+	f := newSystemdFixture(t)
+	ctx := context.Background()
+	opts := CreateOptions{StartType: StartAuto, Account: localSystem}
+	if _, err := f.ctrl.Create(ctx, "Test_Servicio", "/opt/x/x.exe", "Prueba", opts); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	output, err := f.ctrl.Create(ctx, "Test_Servicio", "/opt/x/x.exe", "Prueba", opts)
+	assertSCCode(t, output, err, win32ServiceExists)
+}
+
+func TestSystemdCreateRollsBackOnEnableFailure(t *testing.T) {
+	f := newSystemdFixture(t)
+	f.fail(t, "enable", "Failed to enable unit: Access denied")
+
+	_, err := f.ctrl.Create(context.Background(), "Test_Servicio", "/opt/x/x.exe", "Prueba",
+		CreateOptions{StartType: StartAuto, Account: ServiceAccount{Name: AccountLocalService}})
+	if err == nil {
+		t.Fatal("Create no falló")
+	}
+	if _, err := os.Stat(f.ctrl.unitPath("Test_Servicio")); !os.IsNotExist(err) {
+		t.Errorf("la unidad quedó en disco (stat: %v)", err)
+	}
+	if _, err := os.Stat(f.ctrl.dropInDir("Test_Servicio")); !os.IsNotExist(err) {
+		t.Errorf("los drop-ins quedaron en disco (stat: %v)", err)
+	}
+}
+
+func TestSystemdCreateRollsBackOnDropInFailure(t *testing.T) {
+	f := newSystemdFixture(t)
+	// A directory where the dependencies drop-in goes makes its write fail
+	blocker := filepath.Join(f.ctrl.dropInDir("Test_Servicio"), dependenciesDropIn)
+	if err := os.MkdirAll(blocker, 0750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	_, err := f.ctrl.Create(context.Background(), "Test_Servicio", "/opt/x/x.exe", "Prueba",
+		CreateOptions{StartType: StartAuto, Account: localSystem, Dependencies: []string{DependencySpooler}})
+	if err == nil {
+		t.Fatal("Create no falló")
+	}
+	if _, err := os.Stat(f.ctrl.unitPath("Test_Servicio")); !os.IsNotExist(err) {
+		t.Errorf("la unidad quedó en disco (stat: %v)", err)
+	}
+	if _, err := os.Stat(f.ctrl.dropInDir("Test_Servicio")); !os.IsNotExist(err) {
+		t.Errorf("los drop-ins quedaron en disco (stat: %v)", err)
+	}
+	assertCalls(t, f, "daemon-reload", "daemon-reload")
+}
+
+func TestSystemdSetDescription(t *testing.T) {
+	f := newSystemdFixture(t)
+	ctx := context.Background()
+
+	output, err := f.ctrl.SetDescription(ctx, "Test_Servicio", "Sin unidad")
+	assertSCCode(t, output, err, win32ServiceDoesNotExist)
+
+	if _, err := f.ctrl.Create(ctx, "Test_Servicio", "/opt/x/x.exe", "Prueba", CreateOptions{StartType: StartAuto, Account: localSystem}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := f.ctrl.SetDescription(ctx, "Test_Servicio", "Báscula al 100%"); err != nil {
+		t.Fatalf("SetDescription: %v", err)
+	}
+	assertContains(t, "descripción", f.read(t, "Test_Servicio.service.d/description.conf"),
+		"X-Description=Báscula al 100%%\n")
+	if got := f.ctrl.dropInDescription("Test_Servicio"); got != "Báscula al 100%" {
+		t.Errorf("descripción leída = %q", got)
+	}
+
+	if _, err := f.ctrl.SetDescription(ctx, "Test_Servicio", ""); err != nil {
+		t.Fatalf("SetDescription vacía: %v", err)
+	}
+	if got := f.ctrl.dropInDescription("Test_Servicio"); got != "" {
+		t.Errorf("descripción tras borrarla = %q", got)
+	}
+}
+
+func TestSystemdConfigureFailure(t *testing.T) {
+	f := newSystemdFixture(t)
+	policy := RecoveryPolicy{
+		ResetPeriod: 24 * time.Hour,
+		Actions: []RecoveryAction{
+			{Type: RecoveryRestart, Delay: 5 * time.Second},
+			{Type: RecoveryRestart, Delay: 30 * time.Second},
+			{Type: RecoveryReboot, Delay: time.Minute},
+		},
+	}
+
+	if _, err := f.ctrl.ConfigureFailure(context.Background(), "Test_Servicio", policy); err != nil {
+		t.Fatalf("ConfigureFailure: %v", err)
+	}
+	assertContains(t, "recuperación", f.read(t, "Test_Servicio.service.d/recovery.conf"),
+		"StartLimitIntervalSec=86400\n",
+		"StartLimitBurst=2\n",
+		"StartLimitAction=reboot\n",
+		"Restart=on-abnormal\n",
+		"RestartSec=5000ms\n")
+	if got := f.calls(t); !reflect.DeepEqual(got, []string{"daemon-reload"}) {
+		t.Errorf("llamadas = %q", got)
+	}
+
+	_, err := f.ctrl.ConfigureFailure(context.Background(), "Test_Servicio",
+		RecoveryPolicy{Actions: []RecoveryAction{{Type: RecoveryRun}}})
+	if err == nil {
+		t.Error("ConfigureFailure aceptó una acción de comando")
+	}
+}
+
+func TestSystemdDelete(t *testing.T) {
+	f := newSystemdFixture(t)
+	ctx := context.Background()
+
+	output, err := f.ctrl.Delete(ctx, "Test_Servicio")
+	assertSCCode(t, output, err, win32ServiceDoesNotExist)
+
+	if _, err := f.ctrl.Create(ctx, "Test_Servicio", "/opt/x/x.exe", "Prueba",
+		CreateOptions{StartType: StartAuto, Account: ServiceAccount{Name: AccountLocalService}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	f.calls(t)
+
+	if _, err := f.ctrl.Delete(ctx, "Test_Servicio"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(f.ctrl.unitPath("Test_Servicio")); !os.IsNotExist(err) {
+		t.Errorf("la unidad sigue en disco (stat: %v)", err)
+	}
+	if _, err := os.Stat(f.ctrl.dropInDir("Test_Servicio")); !os.IsNotExist(err) {
+		t.Errorf("los drop-ins siguen en disco (stat: %v)", err)
+	}
+	want := []string{"disable Test_Servicio.service", "daemon-reload"}
+	if got := f.calls(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("llamadas = %q, se esperaba %q", got, want)
+	}
+}
+
+func TestSystemdQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		show string
+		want ServiceState
+	}{
+		{
+			name: "no instalado",
+			show: "LoadState=not-found\nActiveState=inactive\nSubState=dead\n",
+			want: ServiceState{Status: StatusNotInstalled},
+		},
+		{
+			name: "en ejecución",
+			show: "LoadState=loaded\nActiveState=active\nSubState=running\nMainPID=4242\nExecMainStatus=0\n",
+			want: ServiceState{Name: "Test_Servicio", Status: StatusRunning, StateCode: 4, PID: 4242},
+		},
+		{
+			name: "iniciando",
+			show: "LoadState=loaded\nActiveState=activating\nSubState=start\nMainPID=4243\nExecMainStatus=0\n",
+			want: ServiceState{Name: "Test_Servicio", Status: StatusStartPending, StateCode: 2, PID: 4243},
+		},
+		{
+			name: "deteniendo",
+			show: "LoadState=loaded\nActiveState=deactivating\nSubState=stop-sigterm\nMainPID=4244\n",
+			want: ServiceState{Name: "Test_Servicio", Status: StatusStopPending, StateCode: 3, PID: 4244},
+		},
+		{
+			name: "detenido",
+			show: "LoadState=loaded\nActiveState=inactive\nSubState=dead\nMainPID=0\nExecMainStatus=0\n",
+			want: ServiceState{Name: "Test_Servicio", Status: StatusStopped, StateCode: 1},
+		},
+		{
+			name: "falló",
+			show: "LoadState=loaded\nActiveState=failed\nSubState=failed\nMainPID=0\nExecMainStatus=203\n",
+			want: ServiceState{Name: "Test_Servicio", Status: StatusStopped, StateCode: 1, Win32ExitCode: 203},
+		},
+		{
+			name: "terminó",
+			show: "LoadState=loaded\nActiveState=active\nSubState=exited\nMainPID=0\n",
+			want: ServiceState{Name: "Test_Servicio", Status: StatusStopped, StateCode: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSystemdFixture(t)
+			f.show(t, "Test_Servicio.service", tt.show)
+
+			got, err := f.ctrl.Query(context.Background(), "Test_Servicio")
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query = %+v, se esperaba %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystemdQueryConfig(t *testing.T) {
+	f := newSystemdFixture(t)
+	ctx := context.Background()
+	if _, err := f.ctrl.Create(ctx, "Test_Servicio", "/opt/x/x.exe", "Prueba", CreateOptions{
+		StartType:    StartDelayedAuto,
+		Account:      ServiceAccount{Name: AccountLocalService},
+		Dependencies: []string{DependencySpooler},
+	}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := f.ctrl.SetDescription(ctx, "Test_Servicio", "Descripción"); err != nil {
+		t.Fatalf("SetDescription: %v", err)
+	}
+	f.show(t, "Test_Servicio.service", "LoadState=loaded\nDescription=Prueba\n"+
+		"ExecStart={ path=/opt/x/x.exe ; argv[]=/opt/x/x.exe ; ignore_errors=no ; start_time=[n/a] }\n"+
+		"UnitFileState=enabled\nRequires=cups.service system.slice\nUser=\n")
+
+	cfg, err := f.ctrl.QueryConfig(ctx, "Test_Servicio")
+	if err != nil {
+		t.Fatalf("QueryConfig: %v", err)
+	}
+	want := ServiceConfig{
+		Name:         "Test_Servicio",
+		Type:         "simple",
+		StartType:    "AUTO_START",
+		DelayedStart: true,
+		BinaryPath:   "/opt/x/x.exe",
+		DisplayName:  "Prueba",
+		Description:  "Descripción",
+		Dependencies: []string{DependencySpooler},
+		Account:      AccountLocalService,
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("QueryConfig = %+v\nse esperaba     %+v", cfg, want)
+	}
+}
+
+func TestSystemdQueryConfigNotInstalled(t *testing.T) {
+	f := newSystemdFixture(t)
+
+	// Like sc qc, a missing unit fails with exit status 1060
+	_, err := f.ctrl.QueryConfig(context.Background(), "Test_Servicio")
+	if err == nil || !strings.Contains(err.Error(), "1060") {
+		t.Errorf("QueryConfig error = %v, se esperaba el código 1060", err)
+	}
+}
+
+func TestSystemdStartStop(t *testing.T) {
+	f := newSystemdFixture(t)
+	ctx := context.Background()
+	if _, err := f.ctrl.Create(ctx, "Test_Servicio", "/opt/x/x.exe", "Prueba", CreateOptions{StartType: StartDisabled, Account: localSystem}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	f.show(t, "Test_Servicio.service", "LoadState=loaded\nActiveState=inactive\nSubState=dead\n")
+	f.calls(t)
+
+	output, err := f.ctrl.Start(ctx, "Test_Servicio")
+	assertSCCode(t, output, err, win32ServiceDisabled)
+
+	if _, err := f.ctrl.SetStartType(ctx, "Test_Servicio", StartManual); err != nil {
+		t.Fatalf("SetStartType: %v", err)
+	}
+	if _, err := f.ctrl.Start(ctx, "Test_Servicio"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	output, err = f.ctrl.Stop(ctx, "Test_Servicio")
+	assertSCCode(t, output, err, win32ServiceNotActive)
+
+	f.show(t, "Test_Servicio.service", "LoadState=loaded\nActiveState=active\nSubState=running\nMainPID=1\n")
+	output, err = f.ctrl.Start(ctx, "Test_Servicio")
+	assertSCCode(t, output, err, win32ServiceAlreadyRunning)
+	if _, err := f.ctrl.Stop(ctx, "Test_Servicio"); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	var queued []string
+	for _, call := range f.calls(t) {
+		if !strings.HasPrefix(call, "show ") {
+			queued = append(queued, call)
+		}
+	}
+	want := []string{"daemon-reload", "disable Test_Servicio.service",
+		"start --no-block Test_Servicio.service", "stop --no-block Test_Servicio.service"}
+	if !reflect.DeepEqual(queued, want) {
+		t.Errorf("llamadas = %q, se esperaba %q", queued, want)
+	}
+}
+
+// assertCalls fails unless the systemctl invocations match want
+func assertCalls(t *testing.T, f *systemdFixture, want ...string) {
+	t.Helper()
+	if got := f.calls(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("llamadas a systemctl = %q, se esperaba %q", got, want)
+	}
+}
+
+func TestSystemdCreateRejectsInvalidInput(t *testing.T) {
+	ctx := context.Background()
+	f := newSystemdFixture(t)
+	for _, tt := range []struct{ regName, binPath string }{
+		{"Test Servicio", "/opt/x/x.exe"},
+		{"Test_Servicio", "/opt/../etc/x.exe"},
+		{"Test_Servicio", "/opt/x/x\".exe"},
+	} {
+		if _, err := f.ctrl.Create(ctx, tt.regName, tt.binPath, "Prueba", CreateOptions{StartType: StartAuto, Account: localSystem}); err == nil {
+			t.Errorf("Create(%q, %q) debía rechazarse", tt.regName, tt.binPath)
+		}
+	}
+	assertCalls(t, f)
+}
+
+func TestSystemdQueryState(t *testing.T) {
+	ctx := context.Background()
+	f := newSystemdFixture(t)
+	f.show(t, "Test_Servicio.service",
+		"LoadState=loaded\nActiveState=failed\nSubState=failed\nMainPID=0\nExecMainStatus=203\n")
+
+	got, err := f.ctrl.Query(ctx, "Test_Servicio")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	want := ServiceState{Name: "Test_Servicio", Status: StatusStopped, StateCode: 1, Win32ExitCode: 203}
+	if got != want {
+		t.Errorf("Query = %+v, se esperaba %+v", got, want)
+	}
+}
+
+func TestSystemdKill(t *testing.T) {
+	ctx := context.Background()
+	f := newSystemdFixture(t)
+	if err := f.ctrl.Kill(ctx, "Test_Servicio"); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	assertCalls(t, f, "kill --signal=SIGKILL Test_Servicio.service")
+
+	f.fail(t, "kill", "Failed to kill unit")
+	if err := f.ctrl.Kill(ctx, "Test_Servicio"); err == nil || !strings.Contains(err.Error(), "Failed to kill unit") {
+		t.Errorf("Kill = %v, se esperaba la salida de systemctl", err)
+	}
+}
+
+func TestSystemdRoots(t *testing.T) {
+	ctrl := &SystemdController{Root: "/raiz"}
+	if root, err := ctrl.InstallRoot(); err != nil || root != filepath.Join("/raiz", "opt") {
+		t.Errorf("InstallRoot = %q, %v", root, err)
+	}
+	if root := ctrl.LogRoot(); root != filepath.Join("/raiz", "var", "log") {
+		t.Errorf("LogRoot = %q", root)
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ══════════════════════════════════════════════════════════════
+// In-Place Upgrade
+// ══════════════════════════════════════════════════════════════
+
+// Upgrade replaces the installed binary with the embedded one without
+// touching the service registration or its recovery settings. The service
+// is stopped, the current exe is backed up next to it as {ExeName}.prev,
+// the new binary is swapped in atomically and the service is restarted if
+// it was running before. If it does not reach RUNNING the previous binary
+// is restored and started. A stopped or disabled service stays stopped.
+func (m *Manager) Upgrade(ctx context.Context) (err error) {
+	ctx, end := m.beginAudit(ctx, AuditUpgrade)
+	defer end(&err)
+	defer m.invalidateStatus()
+
+	var (
+		absTargetPath string
+		restart       bool
+	)
+	err = runStep(ctx, stepPrecheck, func() (err error) {
+		absTargetPath, restart, err = m.prepareUpgrade(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return m.runTransaction(ctx, "upgrade", m.upgradeSteps(absTargetPath, restart))
+}
+
+// prepareUpgrade checks that the service is installed and that the embedded
+// binary can replace it, returning the installed binary path and whether
+// the service was running (and so must be started again)
+func (m *Manager) prepareUpgrade(ctx context.Context) (absTargetPath string, running bool, err error) {
+	if err := validateServiceVariantFields(m.variant); err != nil {
+		return "", false, m.opError("upgrade", CategoryInvalid, err, "validación de campos: %v", err)
+	}
+	if err := m.variant.CheckEmbedded(); err != nil {
+		return "", false, m.opError("upgrade", CategoryInvalid, err, "binario embebido: %v", err)
+	}
+	switch m.status(ctx) {
+	case StatusNotInstalled:
+		return "", false, m.opError("upgrade", CategoryNotInstalled, nil, "el servicio no está instalado — use Instalar")
+	case StatusRunning, StatusStartPending:
+		running = true
+	default:
+	}
+
+	_, absTargetPath, err = m.installPaths()
+	if err != nil {
+		return "", false, m.opError("upgrade", CategoryInvalid, err, "%v", err)
+	}
+	if _, err := os.Stat(absTargetPath); err != nil {
+		return "", false, m.opError("upgrade", CategoryFilesystem, err, "binario instalado no encontrado: %v", err)
+	}
+	return absTargetPath, running, nil
+}
+
+// BinaryDiffers reports whether the installed binary differs from the
+// embedded one. Returns false if the binary is not on disk.
+func (m *Manager) BinaryDiffers() bool {
+	_, absTargetPath, err := m.installPaths()
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(absTargetPath)
+	if err != nil {
+		return false
+	}
+	if info.Size() != int64(len(m.variant.Binary)) {
+		return true
+	}
+	//nolint:gosec // We have validated the path, so this is not vulnerable to injection
+	data, err := os.ReadFile(absTargetPath)
+	if err != nil {
+		return false
+	}
+	return !bytes.Equal(data, m.variant.Binary)
+}
+
+// upgradeSteps builds the journaled upgrade sequence. restart is whether
+// the service was running before the upgrade: only then is it started
+// again, and the "inicio" step recorded in the journal says so.
+func (m *Manager) upgradeSteps(absTargetPath string, restart bool) []txStep {
+	backupPath := absTargetPath + ".prev"
+	stagingPath := absTargetPath + ".new"
+
+	steps := []txStep{
+		{
+			// 1. Stop the service so the exe is no longer locked
+			name:  "detener",
+			label: stepStop,
+			do: func(ctx context.Context) error {
+				return m.stopAndWait(ctx, "upgrade")
+			},
+			undo: func(ctx context.Context) error {
+				if !restart {
+					return nil
+				}
+				// Restart whatever binary is in place (the restored one)
+				return m.startAndWait(ctx, "rollback")
+			},
+		},
+		{
+			// 2. Back up the current exe next to it
+			name:  "respaldo",
+			label: "Respaldar binario",
+			do: func(ctx context.Context) error {
+				if err := copyFile(absTargetPath, backupPath); err != nil {
+					return m.opError("upgrade", CategoryFilesystem, err, "respaldar binario: %v", err)
+				}
+				return nil
+			},
+			undo: func(ctx context.Context) error {
+				if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				return nil
+			},
+		},
+		{
+			// 3. Stage the embedded binary and swap it in atomically
+			name:  "reemplazo",
+			label: "Reemplazar binario",
+			do: func(ctx context.Context) error {
+				//nolint:gosec // We have validated the path; the service account must execute it
+				if err := os.WriteFile(stagingPath, m.variant.Binary, binaryFileMode); err != nil {
+					return m.opError("upgrade", CategoryFilesystem, err, "escribir binario nuevo: %v", err)
+				}
+				if err := m.verifyWritten("upgrade", stagingPath); err != nil {
+					_ = os.Remove(stagingPath)
+					return err
+				}
+				if err := os.Rename(stagingPath, absTargetPath); err != nil {
+					_ = os.Remove(stagingPath)
+					return m.opError("upgrade", CategoryFilesystem, err, "reemplazar binario: %v", err)
+				}
+				if err := setServiceModes(absTargetPath); err != nil {
+					return m.opError("upgrade", CategoryFilesystem, err, "permisos del binario: %v", err)
+				}
+				return nil
+			},
+			undo: func(ctx context.Context) error {
+				_ = os.Remove(stagingPath)
+				if _, err := os.Stat(backupPath); err != nil {
+					return nil
+				}
+				return copyFile(backupPath, absTargetPath)
+			},
+		},
+	}
+
+	if restart {
+		steps = append(steps, txStep{
+			// 4. Start the new binary and verify it stays up
+			name:  "inicio",
+			label: stepStart,
+			do: func(ctx context.Context) error {
+				return m.startAndWait(ctx, "upgrade")
+			},
+			undo: func(ctx context.Context) error {
+				return m.stopAndWait(ctx, "rollback")
+			},
+		})
+	}
+	return steps
+}
+
+// stopAndWait stops the service (force-killing it if it hangs) and waits
+// until it is STOPPED. A service that is already stopped is left alone.
+func (m *Manager) stopAndWait(ctx context.Context, op string) error {
+	status := m.status(ctx)
+	if status == StatusStopped || status == StatusNotInstalled {
+		return nil
+	}
+
+	if output, err := m.ctrl.Stop(ctx, m.variant.RegistryName); err != nil {
+		if cErr := m.cancelled(ctx, op); cErr != nil {
+			return cErr
+		}
+		switch svcErr := m.toolError(op, output, err); svcErr.Category {
+		case CategoryNotRunning:
+			// Stopped in the meantime
+		case CategoryBusy:
+			// Stuck in START_PENDING, the stop control is refused
+			return m.killAndWait(ctx, op)
+		default:
+			return svcErr
+		}
+	}
+	if m.WaitForStatus(ctx, StatusStopped, m.opts.StopTimeout) == nil {
+		return nil
+	}
+	if cErr := m.cancelled(ctx, op); cErr != nil {
+		return cErr
+	}
+	return m.killAndWait(ctx, op)
+}
+
+// killAndWait force-kills the service process as a last resort and waits
+// until it is STOPPED
+func (m *Manager) killAndWait(ctx context.Context, op string) error {
+	progressFrom(ctx).detail("forzando el cierre del proceso")
+	_ = m.ctrl.Kill(ctx, m.variant.RegistryName)
+	if err := m.WaitForStatus(ctx, StatusStopped, m.opts.KillTimeout); err != nil {
+		if cErr := m.cancelled(ctx, op); cErr != nil {
+			return cErr
+		}
+		return m.opError(op, CategoryTimeout, nil, "el servicio no se detuvo a tiempo")
+	}
+	return nil
+}
+
+// startAndWait starts the service and waits until it is RUNNING. The port
+// is checked again here: a transaction or journal recovery may bring the
+// daemon up long after its precheck.
+func (m *Manager) startAndWait(ctx context.Context, op string) error {
+	if err := m.checkPort(ctx, op); err != nil {
+		return err
+	}
+	if output, err := m.ctrl.Start(ctx, m.variant.RegistryName); err != nil {
+		if cErr := m.cancelled(ctx, op); cErr != nil {
+			return cErr
+		}
+		if svcErr := m.toolError(op, output, err); svcErr.Category != CategoryAlreadyRunning {
+			return svcErr
+		}
+	}
+	if err := m.WaitForStatus(ctx, StatusRunning, m.opts.StartTimeout); err != nil {
+		if cErr := m.cancelled(ctx, op); cErr != nil {
+			return cErr
+		}
+		return m.opError(op, CategoryTimeout, nil, "el servicio no alcanzó el estado EN EJECUCIÓN")
+	}
+	return nil
+}
+
+// copyFile copies src over dst, syncing it to disk
+func copyFile(src, dst string) error {
+	//nolint:gosec // callers pass validated install paths
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	//nolint:gosec // callers pass validated install paths; the service account must execute them
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, binaryFileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("copiar %s: %w", src, err)
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManagerUpgradeKeepsState(t *testing.T) {
+	tests := []struct {
+		name  string
+		start bool
+		want  Status
+	}{
+		{"en ejecución", true, StatusRunning},
+		{"detenido", false, StatusStopped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t)
+			ctx := context.Background()
+			if err := m.Install(ctx); err != nil {
+				t.Fatalf("Install: %v", err)
+			}
+			if tt.start {
+				if err := m.startAndWait(ctx, "start"); err != nil {
+					t.Fatalf("startAndWait: %v", err)
+				}
+			}
+			// An older build on disk
+			if err := os.WriteFile(binaryPath(t, m), []byte("binario anterior"), 0600); err != nil {
+				t.Fatalf("escribir binario anterior: %v", err)
+			}
+
+			if err := m.Upgrade(ctx); err != nil {
+				t.Fatalf("Upgrade: %v", err)
+			}
+			if m.BinaryDiffers() {
+				t.Error("el binario instalado no es el embebido")
+			}
+			if got := m.status(ctx); got != tt.want {
+				t.Errorf("estado tras Upgrade = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagerUpgradeRecoveryKeepsStoppedService(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+	if err := m.Install(ctx); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	// An upgrade of a stopped service interrupted after its first step
+	j := &Journal{Op: "upgrade", Variant: m.variant.ID, Planned: []string{"detener", "respaldo", "reemplazo"},
+		Steps: []JournalStep{{Name: "detener", Done: true}}}
+	if err := m.saveJournal(j); err != nil {
+		t.Fatalf("saveJournal: %v", err)
+	}
+
+	if completed, err := m.RecoverJournal(ctx); err != nil || completed {
+		t.Fatalf("RecoverJournal = %v, %v; se esperaba una reversión", completed, err)
+	}
+	if got := m.status(ctx); got != StatusStopped {
+		t.Errorf("estado tras la reversión = %v, se esperaba %v", got, StatusStopped)
+	}
+}
+
+func TestManagerUpgradeStartFailureRestoresOldBinary(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	ctx := context.Background()
+	if err := m.InstallAndStart(ctx); err != nil {
+		t.Fatalf("InstallAndStart: %v", err)
+	}
+	path := binaryPath(t, m)
+	if err := os.WriteFile(path, []byte("binario anterior"), 0600); err != nil {
+		t.Fatalf("escribir binario anterior: %v", err)
+	}
+	// The new binary is swapped in but never reaches RUNNING
+	ctrl.FailNext("start", win32ServiceLogonFailed)
+
+	err := m.Upgrade(ctx)
+	var svcErr *Error
+	if !errors.As(err, &svcErr) {
+		t.Fatalf("Upgrade = %v, se esperaba un *Error", err)
+	}
+	assertCategory(t, err, CategoryLogonFailed)
+
+	if data, err := os.ReadFile(path); err != nil || string(data) != "binario anterior" {
+		t.Errorf("binario anterior no restaurado: %q, %v", data, err)
+	}
+	for _, leftover := range []string{path + ".prev", path + ".new"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("quedó %s tras la reversión (stat: %v)", filepath.Base(leftover), err)
+		}
+	}
+	if got := m.status(ctx); got != StatusRunning {
+		t.Errorf("estado tras la reversión = %v, se esperaba %v", got, StatusRunning)
+	}
+	if j, err := m.PendingJournal(); err != nil || j != nil {
+		t.Errorf("bitácora pendiente tras la reversión: %v, %v", j, err)
+	}
+}
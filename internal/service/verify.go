@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/adcondev/poster-tuis/internal/assets"
+	"github.com/adcondev/poster-tuis/internal/manifest"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Binary Integrity
+// ══════════════════════════════════════════════════════════════
+// The build writes a manifest (SHA-256, size, source commit, build date) for
+// every embedded binary. It is checked at startup against the embedded bytes
+// and, after install, against the files on disk, so a field tech can prove a
+// terminal runs the exact build that was shipped.
+
+// embeddedManifest is the parsed build manifest; embeddedManifestErr is set
+// if assets.Manifest could not be decoded
+var embeddedManifest, embeddedManifestErr = manifest.Parse(assets.Manifest)
+
+// ErrIntegrity is matched by errors reporting a binary that does not match
+// its manifest entry
+var ErrIntegrity = errors.New("el binario no coincide con el manifiesto de compilación")
+
+// CheckEmbedded verifies the variant's embedded binary against its manifest entry
+func (v Variant) CheckEmbedded() error {
+	if embeddedManifestErr != nil {
+		return embeddedManifestErr
+	}
+	if v.Manifest.SHA256 == "" {
+		return fmt.Errorf("%s: sin entrada en el manifiesto: %w", v.ExeName, ErrIntegrity)
+	}
+	if !v.Manifest.Matches(v.Binary) {
+		return fmt.Errorf("%s: SHA-256 %s, esperado %s: %w",
+			v.ExeName, manifest.Hash(v.Binary)[:12], v.Manifest.Short(), ErrIntegrity)
+	}
+	return nil
+}
+
+// VerifyEmbedded checks every registered variant's embedded binary against the
+// build manifest. Returns one error per variant that fails; nil if all match.
+func VerifyEmbedded() []error {
+	var errs []error
+	registry := GetServiceRegistry()
+	for _, family := range GetFamilyNames() {
+		for _, v := range registry[family] {
+			if err := v.CheckEmbedded(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// ══════════════════════════════════════════════════════════════
+// Installed Binary Verification
+// ══════════════════════════════════════════════════════════════
+
+// VerifyOutcome is the result of comparing an installed binary to the manifest
+type VerifyOutcome int
+
+const (
+	// VerifyMatch means the installed file is the shipped build
+	VerifyMatch VerifyOutcome = iota
+	// VerifyMismatch means the installed file differs from the shipped build
+	VerifyMismatch
+	// VerifyMissing means there is no file at the install path
+	VerifyMissing
+)
+
+// String returns the outcome label for display
+func (o VerifyOutcome) String() string {
+	switch o {
+	case VerifyMatch:
+		return "[+] COINCIDE"
+	case VerifyMismatch:
+		return "[!] NO COINCIDE"
+	default:
+		return "[-] NO ENCONTRADO"
+	}
+}
+
+// VerifyResult describes the installed binary and the manifest entry it was
+// compared against
+type VerifyResult struct {
+	Path         string
+	Expected     manifest.Entry
+	ActualSHA256 string
+	ActualSize   int64
+	Outcome      VerifyOutcome
+}
+
+// VerifyInstallation hashes the installed binary under the install directory
+// and compares it with the manifest entry of the embedded build.
+func (m *Manager) VerifyInstallation() (VerifyResult, error) {
+	if err := m.variant.CheckEmbedded(); err != nil {
+		return VerifyResult{}, m.opError("verify", CategoryInvalid, err, "manifiesto embebido: %v", err)
+	}
+
+	_, absTargetPath, err := m.installPaths()
+	if err != nil {
+		return VerifyResult{}, m.opError("verify", CategoryInvalid, err, "%v", err)
+	}
+
+	result := VerifyResult{Path: absTargetPath, Expected: m.variant.Manifest}
+	sum, size, err := manifest.HashFile(absTargetPath)
+	switch {
+	case os.IsNotExist(err):
+		result.Outcome = VerifyMissing
+		return result, nil
+	case err != nil:
+		return result, m.opError("verify", CategoryFilesystem, err, "leer binario instalado: %v", err)
+	}
+
+	result.ActualSHA256 = sum
+	result.ActualSize = size
+	if sum == result.Expected.SHA256 && size == result.Expected.Size {
+		result.Outcome = VerifyMatch
+	} else {
+		result.Outcome = VerifyMismatch
+	}
+	return result, nil
+}
+
+// verifyWritten reads back a freshly written binary and checks it against the
+// manifest entry
+func (m *Manager) verifyWritten(op, path string) error {
+	sum, size, err := manifest.HashFile(path)
+	if err != nil {
+		return m.opError(op, CategoryFilesystem, err, "releer binario escrito: %v", err)
+	}
+	if sum != m.variant.Manifest.SHA256 || size != m.variant.Manifest.Size {
+		return m.opError(op, CategoryFilesystem, ErrIntegrity,
+			"el binario escrito en disco está corrupto (SHA-256 %s, esperado %s)", sum[:12], m.variant.Manifest.Short())
+	}
+	return nil
+}
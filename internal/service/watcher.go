@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ══════════════════════════════════════════════════════════════
+// Status Watcher
+// ══════════════════════════════════════════════════════════════
+// A Watcher is the single owner of status polling for the variants of one
+// backend. It queries every tracked variant in parallel, publishes the
+// transitions as StatusChange events and backs off while nothing changes.
+// The poll loop only runs while someone is subscribed.
+
+// Poll intervals: fast while something changes or someone waits for a
+// state, doubling up to watcherMaxInterval while everything is idle
+const (
+	watcherMinInterval = 500 * time.Millisecond
+	watcherMaxInterval = 5 * time.Second
+)
+
+// watcherBuffer is the capacity of each subscriber channel. A subscriber
+// that falls behind loses its oldest events, never blocks the poll loop.
+const watcherBuffer = 32
+
+// StatusChange is a transition of one variant observed by a Watcher
+type StatusChange struct {
+	Variant string // Variant.ID
+	From    Status
+	To      Status
+	At      time.Time
+}
+
+// Watcher polls the status of the registered variants and publishes changes
+type Watcher struct {
+	ctrl Controller
+
+	mu       sync.Mutex
+	variants map[string]Variant // Indexed by Variant.ID
+	states   map[string]Status  // Last observed status; absent until the first poll
+	subs     map[chan StatusChange]struct{}
+	waiters  int                // WaitFor calls in progress; keep polling fast
+	stopLoop context.CancelFunc // Ends the poll loop and its query round; nil while it is not running
+	inflight *pollRound         // Query round in progress, shared by concurrent Poll calls
+
+	wake chan struct{} // Pending Refresh request (capacity 1, so requests coalesce)
+}
+
+// pollRound is one query round; done is closed when its results are stored
+type pollRound struct {
+	done    chan struct{}
+	changed bool
+}
+
+// NewWatcher creates a watcher over variants using the given backend
+func NewWatcher(ctrl Controller, variants []Variant) *Watcher {
+	w := &Watcher{
+		ctrl:     ctrl,
+		variants: make(map[string]Variant),
+		states:   make(map[string]Status),
+		subs:     make(map[chan StatusChange]struct{}),
+		wake:     make(chan struct{}, 1),
+	}
+	for _, v := range variants {
+		w.variants[v.ID] = v
+	}
+	return w
+}
+
+// watchers holds the shared watcher of each backend
+var (
+	watchersMu sync.Mutex
+	watchers   = make(map[Controller]*Watcher)
+)
+
+// DefaultWatcher returns the shared watcher over every registered variant
+// on the platform backend
+func DefaultWatcher() *Watcher {
+	return watcherFor(defaultController)
+}
+
+// watcherFor returns the shared watcher of ctrl, creating it on first use
+func watcherFor(ctrl Controller) *Watcher {
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+
+	if w, ok := watchers[ctrl]; ok {
+		return w
+	}
+	var variants []Variant
+	for _, family := range GetServiceRegistry() {
+		variants = append(variants, family...)
+	}
+	w := NewWatcher(ctrl, variants)
+	watchers[ctrl] = w
+	return w
+}
+
+// Track adds a variant to the watched set, or updates it if already tracked
+func (w *Watcher) Track(v Variant) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.variants[v.ID] = v
+}
+
+// Status returns the last observed status of a variant. Returns
+// StatusUnknown until the variant has been polled once.
+func (w *Watcher) Status(variantID string) Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if s, ok := w.states[variantID]; ok {
+		return s
+	}
+	return StatusUnknown
+}
+
+// ── Subscriptions ──
+
+// Subscribe returns a channel of status changes and the function that ends
+// the subscription (closing the channel). Polling starts with the first
+// subscriber and stops after the last one leaves.
+func (w *Watcher) Subscribe() (<-chan StatusChange, func()) {
+	ch := make(chan StatusChange, watcherBuffer)
+
+	w.mu.Lock()
+	w.subs[ch] = struct{}{}
+	w.startLocked()
+	w.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			w.mu.Lock()
+			delete(w.subs, ch)
+			close(ch)
+			if len(w.subs) == 0 && w.stopLoop != nil {
+				w.stopLoop()
+				w.stopLoop = nil
+			}
+			w.mu.Unlock()
+		})
+	}
+	return ch, cancel
+}
+
+// Refresh asks the poll loop for an immediate round and resets the backoff.
+// Requests made before the round starts are served by that same round.
+func (w *Watcher) Refresh() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// WaitFor blocks until the variant reaches want or ctx is done. Waiting for
+// StatusStopped is also satisfied by StatusNotInstalled (the service was
+// removed while stopping).
+func (w *Watcher) WaitFor(ctx context.Context, variantID string, want Status) error {
+	w.mu.Lock()
+	_, tracked := w.variants[variantID]
+	w.mu.Unlock()
+	if !tracked {
+		return fmt.Errorf("variante desconocida: %q", variantID)
+	}
+
+	events, cancel := w.Subscribe()
+	defer cancel()
+
+	w.mu.Lock()
+	w.waiters++
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		w.waiters--
+		w.mu.Unlock()
+	}()
+
+	// Subscribed first, so a change between this poll and the loop below
+	// still arrives as an event
+	w.Poll(ctx)
+	if statusReached(w.Status(variantID), want) {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-events:
+			if !ok {
+				return fmt.Errorf("suscripción cerrada")
+			}
+			// Re-read the state: a lagging subscriber may have lost events
+			if (ev.Variant == variantID && statusReached(ev.To, want)) || statusReached(w.Status(variantID), want) {
+				return nil
+			}
+		}
+	}
+}
+
+// statusReached reports whether got satisfies a wait for want
+func statusReached(got, want Status) bool {
+	return got == want || (want == StatusStopped && got == StatusNotInstalled)
+}
+
+// ── Polling ──
+
+// Poll queries every tracked variant now and publishes the changes. A call
+// made while another round is in progress waits for that round instead of
+// starting a new one. Cancelling ctx abandons the wait or the queries; a
+// variant whose query was cut short keeps its last observed status.
+func (w *Watcher) Poll(ctx context.Context) {
+	w.poll(ctx)
+}
+
+// poll runs (or joins) a query round and reports whether anything changed
+func (w *Watcher) poll(ctx context.Context) bool {
+	w.mu.Lock()
+	if round := w.inflight; round != nil {
+		w.mu.Unlock()
+		select {
+		case <-round.done:
+			return round.changed
+		case <-ctx.Done():
+			return false
+		}
+	}
+	round := &pollRound{done: make(chan struct{})}
+	w.inflight = round
+	variants := make([]Variant, 0, len(w.variants))
+	for _, v := range w.variants {
+		variants = append(variants, v)
+	}
+	w.mu.Unlock()
+
+	// One query per variant on the worker pool — sc.exe round trips dominate.
+	// Polls always reach the backend and refresh the status cache.
+	results := make([]Status, len(variants))
+	aborted := make([]bool, len(variants))
+	runBounded(len(variants), queryWorkers, func(i int) {
+		regName := variants[i].RegistryName
+		state, err := w.ctrl.Query(ctx, regName)
+		if err == nil {
+			queryCache.storeState(w.ctrl, regName, state)
+		}
+		results[i] = state.Status
+		aborted[i] = err != nil && ctx.Err() != nil
+	})
+
+	now := time.Now()
+	w.mu.Lock()
+	for i, v := range variants {
+		if aborted[i] {
+			continue
+		}
+		prev, seen := w.states[v.ID]
+		w.states[v.ID] = results[i]
+		// The first observation is a baseline, not a transition
+		if !seen || prev == results[i] {
+			continue
+		}
+		round.changed = true
+		w.publishLocked(StatusChange{Variant: v.ID, From: prev, To: results[i], At: now})
+	}
+	w.inflight = nil
+	w.mu.Unlock()
+
+	close(round.done)
+	return round.changed
+}
+
+// publishLocked delivers ev to every subscriber, dropping a subscriber's
+// oldest pending event when its buffer is full
+func (w *Watcher) publishLocked(ev StatusChange) {
+	for ch := range w.subs {
+		select {
+		case ch <- ev:
+			continue
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// startLocked launches the poll loop if it is not running
+func (w *Watcher) startLocked() {
+	if w.stopLoop != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.stopLoop = cancel
+	go w.run(ctx)
+}
+
+// run is the poll loop: fast after a change, a Refresh or while someone
+// waits, doubling the interval up to watcherMaxInterval otherwise. Exits
+// once ctx is cancelled, when the last subscriber leaves.
+func (w *Watcher) run(ctx context.Context) {
+	interval := time.Duration(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		case <-w.wake:
+			interval = watcherMinInterval
+		}
+
+		w.mu.Lock()
+		waiting := w.waiters > 0
+		w.mu.Unlock()
+
+		changed := w.poll(ctx)
+		switch {
+		case changed || waiting || interval == 0:
+			interval = watcherMinInterval
+		case interval < watcherMaxInterval:
+			interval = min(interval*2, watcherMaxInterval)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// stalledController is a MemoryController whose status queries hang until
+// their context is done
+type stalledController struct {
+	*MemoryController
+}
+
+func (c stalledController) Query(ctx context.Context, _ string) (ServiceState, error) {
+	<-ctx.Done()
+	return ServiceState{}, ctx.Err()
+}
+
+func TestWatcherPollCancelled(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	if err := m.Install(context.Background()); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	w := NewWatcher(ctrl, []Variant{m.variant})
+	w.Poll(context.Background())
+	if got := w.Status(m.variant.ID); got != StatusStopped {
+		t.Fatalf("Status = %v, se esperaba %v", got, StatusStopped)
+	}
+
+	// The same watcher over a backend that no longer answers
+	w.ctrl = stalledController{ctrl}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		w.Poll(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Poll no respetó la cancelación del contexto")
+	}
+	if got := w.Status(m.variant.ID); got != StatusStopped {
+		t.Errorf("Status tras cancelar = %v, se esperaba conservar %v", got, StatusStopped)
+	}
+}
+
+func TestWatcherWaitForCancelled(t *testing.T) {
+	m, ctrl := newTestManager(t)
+	if err := m.Install(context.Background()); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	w := NewWatcher(stalledController{ctrl}, []Variant{m.variant})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := w.WaitFor(ctx, m.variant.ID, StatusRunning); err == nil {
+		t.Fatal("WaitFor = nil, se esperaba el error del contexto")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("WaitFor tardó %v en respetar la cancelación", elapsed)
+	}
+}
@@ -44,6 +44,18 @@ func buildDashboardItems(statuses map[string]service.FamilyStatus) []list.Item {
 			icon:        "[2]",
 			data:        "ticket",
 		},
+		menuItem{
+			title:       "Historial de operaciones",
+			description: "Quién instaló, detuvo o desinstaló qué y cuándo",
+			icon:        "[H]",
+			data:        "history",
+		},
+		menuItem{
+			title:       "Generar paquete de soporte",
+			description: "Reúne logs, estado, versiones y entorno en un .zip para soporte técnico",
+			icon:        "[P]",
+			data:        "support",
+		},
 		menuItem{
 			title:       "Salir",
 			description: "Cerrar el instalador",
@@ -55,12 +67,19 @@ func buildDashboardItems(statuses map[string]service.FamilyStatus) []list.Item {
 
 // formatFamilyStatus generates a human-readable status summary for the dashboard
 func formatFamilyStatus(fs service.FamilyStatus) string {
+	if fs.HasConflict() {
+		return "[!] CONFLICTO - Local y Remoto registrados, requiere reparación"
+	}
+
 	installed := fs.GetInstalledVariant()
 	if installed == "" {
 		return "No instalado"
 	}
 
 	status := fs.GetActiveStatus()
+	if fs.Health() == service.HealthUnhealthy {
+		return fmt.Sprintf("%s - %s, [!] SIN RESPUESTA en %s", installed, status.String(), fs.Probe.Address)
+	}
 	return fmt.Sprintf("%s - %s", installed, status.String())
 }
 
@@ -144,8 +163,49 @@ func buildFamilyMenuItems(fs service.FamilyStatus) []list.Item {
 		log.Printf("Estado inesperado para %s: %s", installed, status.String())
 	}
 
+	upgradeDesc := "Reinstala el binario embebido conservando el registro del servicio"
+	if fs.BinaryDiffers {
+		upgradeDesc = "El binario instalado difiere del embebido — actualizar en sitio"
+	}
+
 	// Always available when installed
 	items = append(items,
+		menuItem{
+			title:       "Actualizar",
+			description: upgradeDesc,
+			icon:        "[^]",
+			data:        "upgrade",
+		},
+		menuItem{
+			title:       fmt.Sprintf("Cambiar a %s", otherVariant(installed)),
+			description: "Desinstala la versión actual e instala la otra; si falla, se restaura la actual",
+			icon:        "[~]",
+			data:        "switch",
+		},
+		menuItem{
+			title:       "Tipo de Inicio",
+			description: "Automático, automático retrasado, manual o deshabilitado",
+			icon:        "[T]",
+			data:        "start-type",
+		},
+		menuItem{
+			title:       "Cuenta de Servicio",
+			description: "Cuenta con la que se ejecuta el servicio (integrada o personalizada)",
+			icon:        "[U]",
+			data:        "account",
+		},
+		menuItem{
+			title:       "Política de Recuperación",
+			description: "Ver y editar qué hace el sistema cuando el servicio falla",
+			icon:        "[R]",
+			data:        "recovery",
+		},
+		menuItem{
+			title:       "Verificar instalación",
+			description: "Compara el SHA-256 del binario instalado con el manifiesto de compilación",
+			icon:        "[=]",
+			data:        "verify",
+		},
 		menuItem{
 			title:       "Ver Logs",
 			description: "Abrir archivo o carpeta de logs",
@@ -169,6 +229,126 @@ func buildFamilyMenuItems(fs service.FamilyStatus) []list.Item {
 	return items
 }
 
+// otherVariant returns the variant a family can be switched to
+func otherVariant(installed string) string {
+	if installed == service.Local {
+		return service.Remoto
+	}
+	return service.Local
+}
+
+// ══════════════════════════════════════════════════════════════
+// Conflict Menu Builder
+// ══════════════════════════════════════════════════════════════
+
+// buildConflictMenuItems creates the repair menu shown when both variants of
+// a family are registered: keep one, uninstall the other
+func buildConflictMenuItems() []list.Item {
+	return []list.Item{
+		menuItem{
+			title:       "Conservar Versión LOCAL",
+			description: "Desinstala la versión Remoto y deja la Local",
+			icon:        "[1]",
+			data:        "keep-local",
+		},
+		menuItem{
+			title:       "Conservar Versión REMOTA",
+			description: "Desinstala la versión Local y deja la Remoto",
+			icon:        "[2]",
+			data:        "keep-remote",
+		},
+		menuItem{
+			title:       "Volver",
+			description: "Regresar al menú principal",
+			icon:        "[<]",
+			data:        "back",
+		},
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Start Type Menu Builder
+// ══════════════════════════════════════════════════════════════
+
+// buildStartTypeMenuItems creates one item per start type; the registered
+// one is marked as current. Item data is the service.StartType value.
+func buildStartTypeMenuItems(current service.StartType) []list.Item {
+	descriptions := map[service.StartType]string{
+		service.StartAuto:        "Inicia con Windows",
+		service.StartDelayedAuto: "Inicia con Windows, después de los demás servicios automáticos (p. ej. la cola de impresión)",
+		service.StartManual:      "Solo inicia cuando se solicita",
+		service.StartDisabled:    "No puede iniciarse hasta cambiar el tipo de inicio",
+	}
+
+	items := make([]list.Item, 0, len(service.StartTypes)+1)
+	for _, t := range service.StartTypes {
+		icon := "[ ]"
+		if t == current {
+			icon = "[*]"
+		}
+		items = append(items, menuItem{
+			title:       t.Label(),
+			description: descriptions[t],
+			icon:        icon,
+			data:        string(t),
+		})
+	}
+	return append(items, menuItem{
+		title:       "Volver",
+		description: "Regresar al menú de servicio",
+		icon:        "[<]",
+		data:        "back",
+	})
+}
+
+// ══════════════════════════════════════════════════════════════
+// Account Menu Builder
+// ══════════════════════════════════════════════════════════════
+
+// buildAccountMenuItems creates one item per built-in account plus a custom
+// account entry; the registered account is marked as current. Item data is
+// the account name, or "custom".
+func buildAccountMenuItems(current string) []list.Item {
+	descriptions := map[string]string{
+		service.AccountLocalService:   "Privilegios mínimos en este equipo; sin credenciales de red",
+		service.AccountNetworkService: "Privilegios mínimos; se presenta en la red con la cuenta del equipo",
+		service.AccountLocalSystem:    "Control total del equipo (predeterminado de Windows, no recomendado)",
+	}
+
+	items := make([]list.Item, 0, len(service.BuiltinAccounts)+2)
+	for _, name := range service.BuiltinAccounts {
+		icon := "[ ]"
+		if service.AccountLabel(current) == service.AccountLabel(name) {
+			icon = "[*]"
+		}
+		items = append(items, menuItem{
+			title:       service.AccountLabel(name),
+			description: descriptions[name],
+			icon:        icon,
+			data:        name,
+		})
+	}
+
+	customIcon, customDesc := "[ ]", "Usuario de dominio o local dedicado (requiere contraseña)"
+	if current != "" && service.AccountLabel(current) == current {
+		customIcon, customDesc = "[*]", "Actual: "+current
+	}
+	return append(items,
+		menuItem{
+			title:       "Cuenta personalizada...",
+			description: customDesc,
+			icon:        customIcon,
+			data:        "custom",
+		},
+		menuItem{
+			title:       "Volver",
+			description: "Regresar al menú de servicio",
+			icon:        "[<]",
+			data:        "back",
+		},
+	)
+}
+
 // ══════════════════════════════════════════════════════════════
 // Logs Menu Builder
 // ══════════════════════════════════════════════════════════════
@@ -176,6 +356,12 @@ func buildFamilyMenuItems(fs service.FamilyStatus) []list.Item {
 // buildLogsMenuItems creates the log management submenu
 func buildLogsMenuItems() []list.Item {
 	return []list.Item{
+		menuItem{
+			title:       "Ver Logs",
+			description: "Muestra el log en el instalador y sigue lo que escribe el servicio",
+			icon:        "[V]",
+			data:        "view",
+		},
 		menuItem{
 			title:       "Abrir Archivo de Logs",
 			description: "Abre el archivo .log en Notepad",
@@ -188,6 +374,12 @@ func buildLogsMenuItems() []list.Item {
 			icon:        "[D]",
 			data:        "open-dir",
 		},
+		menuItem{
+			title:       "Mantenimiento de Logs",
+			description: "Comprime los logs antiguos y depura los archivos según la política de retención",
+			icon:        "[M]",
+			data:        "maintain",
+		},
 		menuItem{
 			title:       "Volver",
 			description: "Regresar al menú de servicio",
@@ -2,6 +2,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -11,6 +12,8 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/adcondev/poster-tuis/internal/service"
@@ -31,6 +34,8 @@ type Model struct {
 	registry       map[string][]service.Variant
 	managers       map[string]*service.Manager // Indexed by variant.ID
 	familyStatuses map[string]service.FamilyStatus
+	queryStats     service.QueryStats          // Measurements of the last status round
+	statusEvents   <-chan service.StatusChange // Subscription to the service status watcher
 
 	// UI components
 	list     list.Model
@@ -42,12 +47,72 @@ type Model struct {
 	// Operation state
 	processing      bool
 	result          string
+	resultErr       error
 	success         bool
 	confirmAction   string
-	confirmCallback tea.Cmd
-	progressPercent float64
+	confirmCallback operation
+	cancelOp        context.CancelFunc     // Cancels the running operation; nil when none is running
+	cancelling      bool                   // Cancel requested; waiting for the operation to unwind
+	installVariant  string                 // Variant being confirmed for install; enables start type and account selection
+	installStart    service.StartType      // Start type chosen on the install confirmation
+	installAccount  service.ServiceAccount // Account chosen on the install confirmation; its password is cleared once used
 	statusMessage   string
 
+	// Step reports of the running operation (screenProcessing)
+	opProgress      service.Progress        // Latest snapshot
+	progressUpdates <-chan service.Progress // Closed when the operation returns
+
+	// Log viewer (screenLogViewer)
+	logViewport  viewport.Model
+	logTail      *service.LogTail // Followed file; nil until opened
+	logLines     []string         // Last logMaxLines lines read
+	logFollow    bool             // Keep the view at the bottom as lines arrive
+	logTruncated bool             // Only the tail of the file was loaded
+	logErr       error
+
+	// Log search and filters (screenLogViewer)
+	searchInput  textinput.Model
+	logSearching bool             // Typing a pattern into searchInput
+	logQuery     service.LogQuery // Applied search pattern and level filter
+	logRange     int              // Index into logRanges
+	logShown     int              // Lines that pass the filters
+	logMatches   []int            // Viewer lines holding a match of the pattern
+	logMatch     int              // Current entry of logMatches; -1 before the first jump
+
+	// Operation history (screenHistory)
+	auditEntries   []service.AuditEntry // Newest first
+	auditErr       error
+	historyFamily  string // Family filter; "" shows every family
+	historyOutcome string // Outcome filter; "" shows every outcome
+	historyOffset  int    // First visible entry of the filtered list
+
+	// Support bundle destination (screenSupport)
+	supportInput textinput.Model
+
+	// Port check before install/start (screenPorts)
+	portConflicts []service.PortConflict
+	portAction    string // "install" or "start"
+	portVariant   string // Variant being installed or started
+	portAccepted  bool   // The user chose to go ahead despite the conflicts
+
+	// Startup check of the embedded binaries against the build manifest
+	integrityIssues []string
+
+	// Recovery policy editor (screenRecovery)
+	recoveryDraft   service.RecoveryPolicy
+	recoveryApplied *service.RecoveryPolicy // Read back from sc qfailure; nil until loaded
+	recoveryErr     error
+	recoveryCursor  int
+	recoveryEditing bool // Typing the failure command into commandInput
+	recoveryTouched bool // The draft was edited; keep it when the read-back arrives
+	commandInput    textinput.Model
+
+	// Custom service account form (screenAccount)
+	accountEditing bool
+	accountField   int // 0: user, 1: password
+	userInput      textinput.Model
+	passwordInput  textinput.Model // Masked; cleared as soon as it is used
+
 	// Dimensions
 	width  int
 	height int
@@ -92,16 +157,77 @@ var defaultKeys = keyMap{
 // Message Types
 // ══════════════════════════════════════════════════════════════
 
+// portCheckMsg carries the port conflicts found before an install or start
+type portCheckMsg struct {
+	family    string
+	action    string
+	variant   string
+	conflicts []service.PortConflict
+}
+
 type statusUpdateMsg struct {
 	statuses map[string]service.FamilyStatus
+	stats    service.QueryStats
+}
+
+// healthTickMsg asks for the next health probe round
+type healthTickMsg struct{}
+
+// healthProbeMsg carries the results of a health probe round
+type healthProbeMsg struct {
+	results   map[string]service.ProbeResult
+	scheduled bool // Round of the health tick, which schedules the next one
+}
+
+// statusChangedMsg reports that the watcher saw at least one variant change
+// state; the family statuses need to be rebuilt
+type statusChangedMsg struct {
+	changes []service.StatusChange
 }
 
 type operationDoneMsg struct {
 	success bool
 	message string
+	err     error // Failure cause; drives the remediation hint on the result screen
+}
+
+// progressMsg carries a step report of the running operation and the
+// channel it came from, to keep listening and to drop stale reports
+type progressMsg struct {
+	progress service.Progress
+	updates  <-chan service.Progress
 }
 
-type progressMsg float64
+// operation is a service operation run from the processing screen. It must
+// return once ctx is cancelled (the cancel key on that screen).
+type operation func(ctx context.Context) tea.Msg
+
+// auditLoadedMsg carries the audit log read for the history screen
+type auditLoadedMsg struct {
+	entries []service.AuditEntry
+	err     error
+}
+
+// logOpenedMsg carries the tail of a log opened in the viewer
+type logOpenedMsg struct {
+	tail   *service.LogTail
+	update service.LogUpdate
+	err    error
+}
+
+// logPolledMsg carries the lines appended to a followed log. tail tells
+// apart a poll of a log the viewer has since closed.
+type logPolledMsg struct {
+	tail   *service.LogTail
+	update service.LogUpdate
+	err    error
+}
+
+// recoveryLoadedMsg carries the policy read back from the installed service
+type recoveryLoadedMsg struct {
+	policy service.RecoveryPolicy
+	err    error
+}
 
 // ══════════════════════════════════════════════════════════════
 // Initialization
@@ -121,9 +247,15 @@ func InitialModel() Model {
 	}
 
 	// Initialize family statuses (initial check)
-	familyStatuses := make(map[string]service.FamilyStatus)
-	for _, family := range service.GetFamilyNames() {
-		familyStatuses[family] = service.CheckFamilyStatus(registry[family])
+	report := queryStatus()
+
+	// Status changes are pushed by the watcher for the program's lifetime
+	statusEvents, _ := service.DefaultWatcher().Subscribe()
+
+	// Startup integrity check: every embedded binary must match the manifest
+	var integrityIssues []string
+	for _, err := range service.VerifyEmbedded() {
+		integrityIssues = append(integrityIssues, err.Error())
 	}
 
 	// ── Initialize UI components ──
@@ -144,8 +276,40 @@ func InitialModel() Model {
 	h.Styles.FullKey = helpKeyStyle
 	h.Styles.FullDesc = helpDescStyle
 
+	ti := textinput.New()
+	ti.Placeholder = `"C:\ruta\alerta.exe" /arg`
+	ti.CharLimit = 1024
+	ti.Width = 60
+
+	ui := textinput.New()
+	ui.Placeholder = `DOMINIO\usuario`
+	ui.CharLimit = 256
+	ui.Width = 40
+
+	pi := textinput.New()
+	pi.Placeholder = "contraseña"
+	pi.EchoMode = textinput.EchoPassword
+	pi.EchoCharacter = '•'
+	pi.CharLimit = 256
+	pi.Width = 40
+
+	sd := textinput.New()
+	sd.Placeholder = `C:\Users\usuario\Desktop`
+	sd.CharLimit = 1024
+	sd.Width = 60
+
+	// Log viewer; sized on the first WindowSizeMsg
+	lv := viewport.New(80, 20)
+	lv.MouseWheelEnabled = true
+
+	si := textinput.New()
+	si.Placeholder = "expresión regular"
+	si.Prompt = "/"
+	si.CharLimit = 256
+	si.Width = 40
+
 	// Build dashboard menu
-	dashboardItems := buildDashboardItems(familyStatuses)
+	dashboardItems := buildDashboardItems(report.Families)
 
 	delegate := list.NewDefaultDelegate()
 	delegate.Styles.SelectedTitle = selectedStyle
@@ -163,16 +327,25 @@ func InitialModel() Model {
 	l.SetShowHelp(false)
 
 	return Model{
-		currentScreen:  screenDashboard,
-		registry:       registry,
-		managers:       managers,
-		familyStatuses: familyStatuses,
-		list:           l,
-		spinner:        s,
-		progress:       p,
-		help:           h,
-		keys:           defaultKeys,
-		ready:          false,
+		currentScreen:   screenDashboard,
+		registry:        registry,
+		managers:        managers,
+		familyStatuses:  report.Families,
+		queryStats:      report.Stats,
+		statusEvents:    statusEvents,
+		integrityIssues: integrityIssues,
+		list:            l,
+		spinner:         s,
+		progress:        p,
+		help:            h,
+		commandInput:    ti,
+		userInput:       ui,
+		passwordInput:   pi,
+		logViewport:     lv,
+		searchInput:     si,
+		supportInput:    sd,
+		keys:            defaultKeys,
+		ready:           false,
 	}
 }
 
@@ -180,7 +353,10 @@ func InitialModel() Model {
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.spinner.Tick,
+		m.recoverJournalsCmd(),
 		m.refreshStatusCmd(),
+		m.watchStatusCmd(),
+		probeHealthCmd(m.familyStatuses, true),
 	)
 }
 
@@ -194,7 +370,8 @@ func (m Model) getActiveManager() *service.Manager {
 	fs := m.familyStatuses[m.selectedFamily]
 	installed := fs.GetInstalledVariant()
 
-	if installed == "" {
+	// No single active variant while both are registered
+	if installed == "" || fs.HasConflict() {
 		return nil
 	}
 
@@ -202,35 +379,112 @@ func (m Model) getActiveManager() *service.Manager {
 	return m.managers[variantID]
 }
 
-// refreshStatusCmd checks the status of all service families in the background.
-//
-// CONCURRENCY NOTE: This function runs in a goroutine and accesses m.registry,
-// which is shared state. However, this is safe because:
-// 1. m.registry is initialized once during model creation and never modified thereafter
-// 2. The Bubble Tea architecture ensures model updates happen sequentially via message passing
-// 3. Only read operations are performed on m.registry (no writes)
-//
-// If m.registry ever needs to be modified after initialization, proper synchronization
-// (e.g., mutex or atomic operations) must be added.
+// refreshStatusCmd checks every variant of every family in the background,
+// in one QueryAll round on the service layer's worker pool and cache
 func (m Model) refreshStatusCmd() tea.Cmd {
 	return func() tea.Msg {
-		statuses := make(map[string]service.FamilyStatus)
+		report := queryStatus()
+		return statusUpdateMsg{statuses: report.Families, stats: report.Stats}
+	}
+}
+
+// statusQueryTimeout bounds a status round so a hung sc.exe or systemctl
+// cannot leave the dashboard waiting forever
+const statusQueryTimeout = 15 * time.Second
+
+// queryStatus runs one QueryAll round under statusQueryTimeout
+func queryStatus() service.StatusReport {
+	ctx, cancel := context.WithTimeout(context.Background(), statusQueryTimeout)
+	defer cancel()
+	return service.QueryAll(ctx)
+}
+
+// healthProbeInterval is how often the running daemons are probed between
+// status changes
+const healthProbeInterval = 30 * time.Second
+
+// healthTickCmd schedules the next health probe round. The watcher only
+// reports SCM transitions; a listener that dies inside a running process
+// shows up on these rounds.
+func healthTickCmd() tea.Cmd {
+	return tea.Tick(healthProbeInterval, func(time.Time) tea.Msg {
+		return healthTickMsg{}
+	})
+}
+
+// probeHealthCmd probes the running daemons of the given families in the
+// background. Only the scheduled rounds keep the health tick going.
+func probeHealthCmd(statuses map[string]service.FamilyStatus, scheduled bool) tea.Cmd {
+	return func() tea.Msg {
+		return healthProbeMsg{results: service.ProbeAll(context.Background(), statuses), scheduled: scheduled}
+	}
+}
+
+// recoverJournalsCmd settles operations left half-done by a previous run
+// (e.g. the installer was closed mid-install). Reports the outcome on the
+// result screen, or nothing if no journal was found.
+func (m Model) recoverJournalsCmd() tea.Cmd {
+	return func() tea.Msg {
+		var lines []string
+		success := true
 
 		for _, family := range service.GetFamilyNames() {
-			statuses[family] = service.CheckFamilyStatus(m.registry[family])
+			for _, v := range m.registry[family] {
+				mgr := m.managers[v.ID]
+				if j, err := mgr.PendingJournal(); err != nil || j == nil {
+					if err != nil {
+						success = false
+						lines = append(lines, fmt.Sprintf("[X] %s: %v", v.DisplayName, err))
+					}
+					continue
+				}
+
+				completed, err := mgr.RecoverJournal(context.Background())
+				switch {
+				case err != nil:
+					success = false
+					lines = append(lines, fmt.Sprintf("[X] %s: no se pudo revertir la operación interrumpida: %v", v.DisplayName, err))
+				case completed:
+					lines = append(lines, fmt.Sprintf("[+] %s: se completó la operación interrumpida", v.DisplayName))
+				default:
+					lines = append(lines, fmt.Sprintf("[-] %s: se revirtió la operación interrumpida", v.DisplayName))
+				}
+			}
 		}
 
-		return statusUpdateMsg{statuses: statuses}
+		if len(lines) == 0 {
+			return nil
+		}
+		return operationDoneMsg{
+			success: success,
+			message: "Recuperación de operaciones interrumpidas\n\n" + strings.Join(lines, "\n"),
+		}
 	}
 }
 
-// periodicRefreshCmd sets up periodic status polling
-func periodicRefreshCmd() tea.Cmd {
-	return tea.Every(5*time.Second, func(_ time.Time) tea.Msg {
-		// Return an empty statusUpdateMsg to trigger a refresh
-		// The actual check happens in the Update handler
-		return statusUpdateMsg{}
-	})
+// watchStatusCmd waits for the next status change from the watcher. Changes
+// that are already queued are folded into the same message, so a burst of
+// transitions triggers a single refresh.
+func (m Model) watchStatusCmd() tea.Cmd {
+	events := m.statusEvents
+	return func() tea.Msg {
+		ev, ok := <-events
+		if !ok {
+			return nil
+		}
+		changes := []service.StatusChange{ev}
+		for {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					return statusChangedMsg{changes: changes}
+				}
+				changes = append(changes, ev)
+			default:
+				return statusChangedMsg{changes: changes}
+			}
+		}
+	}
 }
 
 // ── Navigation helpers ──
@@ -250,9 +504,13 @@ func (m Model) goToDashboard() (Model, tea.Cmd) {
 func (m Model) goToFamilyMenu(family, familyTitle string) (Model, tea.Cmd) {
 	m.selectedFamily = family
 	m.previousScreen = screenDashboard
-	m.currentScreen = screenFamily
 
 	fs := m.familyStatuses[family]
+	if fs.HasConflict() {
+		return m.goToConflictMenu()
+	}
+
+	m.currentScreen = screenFamily
 	items := buildFamilyMenuItems(fs)
 	m.list.SetItems(items)
 	m.list.Title = fmt.Sprintf("Gestión - %s", familyTitle)
@@ -270,9 +528,151 @@ func (m Model) goToLogsMenu() (Model, tea.Cmd) {
 	return m, nil
 }
 
+// Log viewer limits: how often a followed log is polled and how many lines
+// the viewer keeps
+const (
+	logPollInterval = 500 * time.Millisecond
+	logMaxLines     = 10000
+)
+
+// logRanges are the time windows the log viewer steps through with [t];
+// a zero span shows the whole log
+var logRanges = []struct {
+	label string
+	span  time.Duration
+}{
+	{"todo", 0},
+	{"última hora", time.Hour},
+	{"últimas 6 h", 6 * time.Hour},
+	{"últimas 24 h", 24 * time.Hour},
+}
+
+// goToLogViewer opens the service's log in the built-in viewer
+func (m Model) goToLogViewer(mgr *service.Manager) (Model, tea.Cmd) {
+	m.logTail = nil
+	m.logLines = nil
+	m.logErr = nil
+	m.logFollow = true
+	m.logTruncated = false
+	m.logSearching = false
+	m.logQuery = service.LogQuery{}
+	m.logRange = 0
+	m.logMatches = nil
+	m.logMatch = -1
+	m.searchInput.SetValue("")
+	m.logViewport.SetContent("")
+	m.logViewport.GotoTop()
+	m.statusMessage = ""
+	m.previousScreen = screenLogs
+	m.currentScreen = screenLogViewer
+	return m, openLogCmd(mgr)
+}
+
+// openLogCmd reads the tail of the service's log in the background
+func openLogCmd(mgr *service.Manager) tea.Cmd {
+	return func() tea.Msg {
+		tail, update, err := mgr.OpenLogTail()
+		return logOpenedMsg{tail: tail, update: update, err: err}
+	}
+}
+
+// pollLogCmd reads what was appended to the log after logPollInterval
+func pollLogCmd(tail *service.LogTail) tea.Cmd {
+	return tea.Tick(logPollInterval, func(time.Time) tea.Msg {
+		update, err := tail.Poll()
+		return logPolledMsg{tail: tail, update: update, err: err}
+	})
+}
+
+// goToHistory opens the operation history and loads the audit log
+func (m Model) goToHistory() (Model, tea.Cmd) {
+	m.historyOffset = 0
+	m.auditErr = nil
+	m.statusMessage = ""
+	m.previousScreen = screenDashboard
+	m.currentScreen = screenHistory
+	return m, loadAuditCmd()
+}
+
+// goToSupport asks where to write the support bundle, starting from the desktop
+func (m Model) goToSupport() (Model, tea.Cmd) {
+	m.supportInput.SetValue(service.DefaultSupportDir())
+	m.supportInput.CursorEnd()
+	m.statusMessage = ""
+	m.previousScreen = screenDashboard
+	m.currentScreen = screenSupport
+	return m, m.supportInput.Focus()
+}
+
+// loadAuditCmd reads the audit log in the background
+func loadAuditCmd() tea.Cmd {
+	return func() tea.Msg {
+		entries, err := service.ReadAuditLog()
+		return auditLoadedMsg{entries: entries, err: err}
+	}
+}
+
+// goToConflictMenu navigates to the repair screen for a family with both
+// variants registered
+func (m Model) goToConflictMenu() (Model, tea.Cmd) {
+	m.list.SetItems(buildConflictMenuItems())
+	m.list.Title = fmt.Sprintf("Conflicto - %s", capitalize(m.selectedFamily))
+	m.currentScreen = screenConflict
+	m.statusMessage = ""
+	return m, nil
+}
+
+// goToRecovery opens the recovery policy editor for the active variant and
+// starts reading back the policy in effect
+func (m Model) goToRecovery(mgr *service.Manager) (Model, tea.Cmd) {
+	m.recoveryDraft = padRecoveryActions(mgr.RecoveryPolicy())
+	m.recoveryApplied = nil
+	m.recoveryErr = nil
+	m.recoveryCursor = 0
+	m.recoveryEditing = false
+	m.recoveryTouched = false
+	m.previousScreen = screenFamily
+	m.currentScreen = screenRecovery
+	m.statusMessage = ""
+
+	return m, func() tea.Msg {
+		policy, err := mgr.QueryRecovery(context.Background())
+		return recoveryLoadedMsg{policy: policy, err: err}
+	}
+}
+
+// goToStartTypeMenu lists the start types for the active variant, with the
+// registered one marked
+func (m Model) goToStartTypeMenu() (Model, tea.Cmd) {
+	current := service.StartTypeOf(m.familyStatuses[m.selectedFamily].GetActiveConfig())
+	m.list.SetItems(buildStartTypeMenuItems(current))
+	m.list.Title = fmt.Sprintf("Tipo de Inicio - %s", capitalize(m.selectedFamily))
+	m.previousScreen = screenFamily
+	m.currentScreen = screenStartType
+	m.statusMessage = ""
+	return m, nil
+}
+
+// goToAccountMenu lists the accounts for the active variant, with the
+// registered one marked
+func (m Model) goToAccountMenu() (Model, tea.Cmd) {
+	current := m.familyStatuses[m.selectedFamily].GetActiveConfig().Account
+	m.list.SetItems(buildAccountMenuItems(current))
+	m.list.Title = fmt.Sprintf("Cuenta de Servicio - %s", capitalize(m.selectedFamily))
+	m.accountEditing = false
+	m.previousScreen = screenFamily
+	m.currentScreen = screenAccount
+	m.statusMessage = ""
+	return m, nil
+}
+
 // returnToFamilyMenu rebuilds the family menu and navigates back to it
+// (or to the conflict screen if both variants are now registered)
 func (m Model) returnToFamilyMenu() (Model, tea.Cmd) {
 	fs := m.familyStatuses[m.selectedFamily]
+	if fs.HasConflict() {
+		return m.goToConflictMenu()
+	}
 	items := buildFamilyMenuItems(fs)
 	m.list.SetItems(items)
 	m.list.Title = fmt.Sprintf("Gestión - %s", capitalize(m.selectedFamily))
@@ -281,11 +681,16 @@ func (m Model) returnToFamilyMenu() (Model, tea.Cmd) {
 	return m, nil
 }
 
-// simulateProgress creates a progress animation command
-func simulateProgress() tea.Cmd {
-	return tea.Every(100*time.Millisecond, func(_ time.Time) tea.Msg {
-		return progressMsg(0.1)
-	})
+// waitProgressCmd waits for the next step report of an operation. Returns
+// nil once the operation has returned and closed the channel.
+func waitProgressCmd(updates <-chan service.Progress) tea.Cmd {
+	return func() tea.Msg {
+		p, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return progressMsg{progress: p, updates: updates}
+	}
 }
 
 // capitalize returns a string with the first letter uppercased
@@ -6,9 +6,16 @@ package ui
 // Defines the strict state machine for UI navigation.
 //
 // Navigation flow:
-//   screenDashboard → screenFamily → screenLogs
+//   screenDashboard → screenFamily → screenLogs → screenLogViewer
 //                                  → screenProcessing → screenResult
 //                                  → screenConfirm → screenProcessing → screenResult
+//                   → screenConflict → screenConfirm → screenProcessing → screenResult
+//                   screenFamily → screenRecovery → screenConfirm → screenProcessing → screenResult
+//                   screenFamily → screenStartType → screenProcessing → screenResult
+//                   screenFamily → screenAccount → screenProcessing → screenResult
+//                   screenFamily → screenPorts → screenConfirm / screenProcessing → screenResult
+//                   → screenHistory
+//                   → screenSupport → screenProcessing → screenResult
 
 type screen int
 
@@ -19,4 +26,12 @@ const (
 	screenProcessing               // Blocking operation indicator (with spinner/progress)
 	screenResult                   // Operation result display (success/error)
 	screenConfirm                  // Yes/No confirmation dialog
+	screenConflict                 // Both variants registered: choose which one to keep
+	screenRecovery                 // View/edit the recovery policy of the installed variant
+	screenStartType                // Change the start type of the installed variant
+	screenAccount                  // Change the logon account of the installed variant
+	screenHistory                  // Audit log of past operations, filterable by family and outcome
+	screenLogViewer                // Built-in viewer following the service log
+	screenSupport                  // Choose where to write the support bundle
+	screenPorts                    // Port in use before install/start: continue or abort
 )
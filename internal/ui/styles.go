@@ -75,4 +75,8 @@ var (
 
 	helpDescStyle = lipgloss.NewStyle().
 			Foreground(lightColor)
+
+	matchStyle = lipgloss.NewStyle().
+			Foreground(darkColor).
+			Background(warningColor)
 )
@@ -1,8 +1,11 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -37,22 +40,68 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case statusUpdateMsg:
 		return m.handleStatusUpdate(msg)
 
+	case portCheckMsg:
+		return m.handlePortCheck(msg)
+
+	case healthTickMsg:
+		return m, probeHealthCmd(m.familyStatuses, true)
+
+	case healthProbeMsg:
+		return m.handleHealthProbe(msg)
+
+	case statusChangedMsg:
+		// Rebuild the family statuses and keep listening
+		return m, tea.Batch(m.refreshStatusCmd(), m.watchStatusCmd())
+
 	case progressMsg:
-		if m.processing {
-			m.progressPercent += float64(msg)
-			if m.progressPercent >= 1.0 {
-				m.progressPercent = 1.0
-			}
-			return m, simulateProgress()
+		// Reports of a finished operation are drained but not shown
+		if m.processing && msg.updates == m.progressUpdates {
+			m.opProgress = msg.progress
+		}
+		return m, waitProgressCmd(msg.updates)
+
+	case logOpenedMsg:
+		return m.handleLogOpened(msg)
+
+	case logPolledMsg:
+		return m.handleLogPolled(msg)
+
+	case tea.MouseMsg:
+		if m.currentScreen == screenLogViewer {
+			return m.scrollLog(msg)
+		}
+
+	case auditLoadedMsg:
+		m.auditEntries = msg.entries
+		m.auditErr = msg.err
+		return m, nil
+
+	case recoveryLoadedMsg:
+		if msg.err != nil {
+			m.recoveryErr = msg.err
+			return m, nil
+		}
+		m.recoveryApplied = &msg.policy
+		// Edit what is actually in effect, not the installer's default
+		if !m.recoveryTouched {
+			m.recoveryDraft = padRecoveryActions(msg.policy)
 		}
+		return m, nil
 
 	case operationDoneMsg:
+		if m.cancelOp != nil {
+			m.cancelOp() // Release the context
+			m.cancelOp = nil
+		}
+		m.cancelling = false
 		m.processing = false
 		m.result = msg.message
+		m.resultErr = msg.err
 		m.success = msg.success
 		m.previousScreen = m.currentScreen
 		m.currentScreen = screenResult
-		m.progressPercent = 0
+		m.opProgress = service.Progress{}
+		m.progressUpdates = nil
 		return m, m.refreshStatusCmd()
 
 	case tea.KeyMsg:
@@ -67,10 +116,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleResultKey(msg)
 		case screenConfirm:
 			return m.handleConfirmKey(msg)
+		case screenConflict:
+			return m.handleConflictKey(msg)
+		case screenRecovery:
+			return m.handleRecoveryKey(msg)
+		case screenStartType:
+			return m.handleStartTypeKey(msg)
+		case screenAccount:
+			return m.handleAccountKey(msg)
 		case screenProcessing:
-			if msg.String() == "ctrl+c" {
-				return m, tea.Quit
-			}
+			return m.handleProcessingKey(msg)
+		case screenHistory:
+			return m.handleHistoryKey(msg)
+		case screenLogViewer:
+			return m.handleLogViewerKey(msg)
+		case screenSupport:
+			return m.handleSupportKey(msg)
+		case screenPorts:
+			return m.handlePortsKey(msg)
 		}
 
 	case spinner.TickMsg:
@@ -94,7 +157,7 @@ func (m Model) handleWindowResize(msg tea.WindowSizeMsg) Model {
 	m.ready = true
 
 	headerHeight := 13
-	footerHeight := 3
+	footerHeight := 4 // Health summary, status round measurements, help
 
 	listHeight := m.height - headerHeight - footerHeight
 	if listHeight < 8 {
@@ -102,6 +165,8 @@ func (m Model) handleWindowResize(msg tea.WindowSizeMsg) Model {
 	}
 
 	m.list.SetSize(m.width-4, listHeight)
+	m.logViewport.Width = m.width - 4
+	m.logViewport.Height = max(m.height-headerHeight-footerHeight-2, 5)
 	m.progress.Width = m.width - 10
 	if m.progress.Width < 20 {
 		m.progress.Width = 20
@@ -116,12 +181,28 @@ func (m Model) handleWindowResize(msg tea.WindowSizeMsg) Model {
 // ══════════════════════════════════════════════════════════════
 
 func (m Model) handleStatusUpdate(msg statusUpdateMsg) (Model, tea.Cmd) {
-	// If the message has actual data, use it; otherwise do a fresh check
-	if msg.statuses != nil {
-		m.familyStatuses = msg.statuses
-	} else {
-		// Periodic refresh: trigger actual check
-		return m, m.refreshStatusCmd()
+	previous := m.familyStatuses
+	m.familyStatuses = msg.statuses
+	m.queryStats = msg.stats
+
+	// Status rounds do not probe: a daemon still running keeps its last
+	// result, one that just started is probed now
+	started := make(map[string]service.FamilyStatus)
+	for family, fs := range m.familyStatuses {
+		if fs.Probe.Probed() || fs.GetActiveStatus() != service.StatusRunning {
+			continue
+		}
+		prev := previous[family]
+		if prev.GetActiveStatus() == service.StatusRunning && prev.GetInstalledVariant() == fs.GetInstalledVariant() {
+			fs.Probe = prev.Probe
+			m.familyStatuses[family] = fs
+			continue
+		}
+		started[family] = fs
+	}
+	var probe tea.Cmd
+	if len(started) > 0 {
+		probe = probeHealthCmd(started, false)
 	}
 
 	// Rebuild current menu to reflect updated statuses
@@ -132,15 +213,43 @@ func (m Model) handleStatusUpdate(msg statusUpdateMsg) (Model, tea.Cmd) {
 	case screenFamily:
 		if m.selectedFamily != "" {
 			fs := m.familyStatuses[m.selectedFamily]
+			if fs.HasConflict() {
+				m, _ = m.goToConflictMenu()
+				break
+			}
 			items := buildFamilyMenuItems(fs)
 			m.list.SetItems(items)
 		}
+	case screenConflict:
+		// Repaired (here or elsewhere): back to the regular family menu
+		if !m.familyStatuses[m.selectedFamily].HasConflict() {
+			m, _ = m.returnToFamilyMenu()
+		}
 	default:
 		// For other screens, we don't need to rebuild the menu on status update
 	}
 
-	// Schedule next periodic refresh
-	return m, periodicRefreshCmd()
+	// No rescheduling: the status watcher pushes the next change
+	return m, probe
+}
+
+// handleHealthProbe folds a probe round into the family statuses and, for
+// a scheduled round, schedules the next one. A result is dropped when its
+// family stopped running while the probe was in flight.
+func (m Model) handleHealthProbe(msg healthProbeMsg) (Model, tea.Cmd) {
+	statuses := make(map[string]service.FamilyStatus, len(m.familyStatuses))
+	for family, fs := range m.familyStatuses {
+		if res, ok := msg.results[family]; ok && fs.GetActiveStatus() == service.StatusRunning {
+			fs.Probe = res
+		}
+		statuses[family] = fs
+	}
+
+	m, cmd := m.handleStatusUpdate(statusUpdateMsg{statuses: statuses, stats: m.queryStats})
+	if msg.scheduled {
+		cmd = tea.Batch(cmd, healthTickCmd())
+	}
+	return m, cmd
 }
 
 // ══════════════════════════════════════════════════════════════
@@ -165,12 +274,17 @@ func (m Model) handleDashboardKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		if selected.data == "quit" {
+		switch selected.data {
+		case "quit":
 			return m, tea.Quit
+		case "history":
+			return m.goToHistory()
+		case "support":
+			return m.goToSupport()
+		default:
+			// Navigate to family management screen
+			return m.goToFamilyMenu(selected.data, selected.title)
 		}
-
-		// Navigate to family management screen
-		return m.goToFamilyMenu(selected.data, selected.title)
 	}
 
 	// Delegate to list for navigation (up/down)
@@ -215,18 +329,13 @@ func (m Model) handleFamilyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m.goToDashboard()
 
 		case "install-local":
-			return m.confirmInstall("Local")
+			return m.checkPorts(portActionInstall, service.Local)
 
 		case "install-remote":
-			return m.confirmInstall("Remoto")
+			return m.checkPorts(portActionInstall, service.Remoto)
 
 		case "start":
-			mgr := m.getActiveManager()
-			if mgr == nil {
-				m.statusMessage = NoServiceMsg
-				return m, nil
-			}
-			return m.executeAction("Iniciar Servicio", mgr.Start)
+			return m.checkPorts(portActionStart, m.familyStatuses[m.selectedFamily].GetInstalledVariant())
 
 		case "stop":
 			mgr := m.getActiveManager()
@@ -234,6 +343,9 @@ func (m Model) handleFamilyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.statusMessage = NoServiceMsg
 				return m, nil
 			}
+			if len(m.familyStatuses[m.selectedFamily].RunningDependents()) > 0 {
+				return m.confirmStopWithDependents()
+			}
 			return m.executeAction("Detener Servicio", mgr.Stop)
 
 		case "force-stop":
@@ -242,9 +354,7 @@ func (m Model) handleFamilyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.statusMessage = "No se pudo determinar el servicio a detener."
 				return m, nil
 			}
-			// We use the standard Stop() which now includes force-kill logic if it hangs,
-			// or you can create a dedicated ForceStop method in manager.go
-			return m.executeAction("Forzar Detención", mgr.Stop)
+			return m.executeAction("Forzar Detención", mgr.ForceStop)
 
 		case "restart":
 			mgr := m.getActiveManager()
@@ -257,6 +367,42 @@ func (m Model) handleFamilyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case "uninstall":
 			return m.confirmUninstall()
 
+		case "upgrade":
+			return m.confirmUpgrade()
+
+		case "switch":
+			return m.confirmSwitch()
+
+		case "start-type":
+			if m.getActiveManager() == nil {
+				m.statusMessage = NoServiceMsg
+				return m, nil
+			}
+			return m.goToStartTypeMenu()
+
+		case "account":
+			if m.getActiveManager() == nil {
+				m.statusMessage = NoServiceMsg
+				return m, nil
+			}
+			return m.goToAccountMenu()
+
+		case "recovery":
+			mgr := m.getActiveManager()
+			if mgr == nil {
+				m.statusMessage = NoServiceMsg
+				return m, nil
+			}
+			return m.goToRecovery(mgr)
+
+		case "verify":
+			mgr := m.getActiveManager()
+			if mgr == nil {
+				m.statusMessage = NoServiceMsg
+				return m, nil
+			}
+			return m.verifyInstallation(mgr)
+
 		case "logs":
 			return m.goToLogsMenu()
 		}
@@ -287,6 +433,14 @@ func (m Model) handleLogsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 		switch selected.data {
+		case "view":
+			mgr := m.getActiveManager()
+			if mgr == nil {
+				m.statusMessage = NoServiceMsg + "; no se pueden ver los logs."
+				return m, nil
+			}
+			return m.goToLogViewer(mgr)
+
 		case "open-file":
 			mgr := m.getActiveManager()
 			if mgr == nil {
@@ -313,6 +467,9 @@ func (m Model) handleLogsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.statusMessage = "Abriendo carpeta de logs..."
 			return m, nil
 
+		case "maintain":
+			return m.confirmLogMaintenance()
+
 		case "back":
 			return m.returnToFamilyMenu()
 		}
@@ -326,11 +483,13 @@ func (m Model) handleLogsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleResultKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if msg.String() == Enter || msg.String() == Esc {
 		m.result = ""
+		m.resultErr = nil
 		m.statusMessage = ""
 
-		// Navigate back to the appropriate screen
-		switch m.previousScreen {
-		case screenFamily, screenProcessing:
+		// Navigate back to the appropriate screen; operations started from
+		// the dashboard (the support bundle) have no family to return to
+		switch {
+		case m.selectedFamily != "" && (m.previousScreen == screenFamily || m.previousScreen == screenProcessing):
 			updated, cmd := m.returnToFamilyMenu()
 			return updated, tea.Batch(cmd, m.refreshStatusCmd())
 		default:
@@ -341,19 +500,349 @@ func (m Model) handleResultKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m Model) handleConflictKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// ESC/Q on conflict screen → back to dashboard
+	if msg.String() == Esc || msg.String() == Quit {
+		return m.goToDashboard()
+	}
+
+	if key.Matches(msg, m.keys.Enter) {
+		item := m.list.SelectedItem()
+		if item == nil {
+			// No item selected; ignore Enter.
+			return m, nil
+		}
+
+		selected, ok := item.(menuItem)
+		if !ok {
+			// Unexpected item type; ignore Enter.
+			return m, nil
+		}
+
+		switch selected.data {
+		case "keep-local":
+			return m.confirmResolveConflict(service.Local)
+		case "keep-remote":
+			return m.confirmResolveConflict(service.Remoto)
+		case "back":
+			return m.goToDashboard()
+		}
+	}
+
+	newList, cmd := m.list.Update(msg)
+	m.list = newList
+	return m, cmd
+}
+
+func (m Model) handleStartTypeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// ESC/Q on start type screen → back to family menu
+	if msg.String() == Esc || msg.String() == Quit {
+		return m.returnToFamilyMenu()
+	}
+
+	if key.Matches(msg, m.keys.Enter) {
+		item := m.list.SelectedItem()
+		if item == nil {
+			// No item selected; ignore Enter.
+			return m, nil
+		}
+
+		selected, ok := item.(menuItem)
+		if !ok {
+			// Unexpected item type; ignore Enter.
+			return m, nil
+		}
+
+		if selected.data == "back" {
+			return m.returnToFamilyMenu()
+		}
+
+		mgr := m.getActiveManager()
+		if mgr == nil {
+			m.statusMessage = NoServiceMsg
+			return m, nil
+		}
+		startType := service.StartType(selected.data)
+		return m.executeAction(fmt.Sprintf("Cambiar Tipo de Inicio a %s", startType.Label()), func(ctx context.Context) error {
+			return mgr.ChangeStartType(ctx, startType)
+		})
+	}
+
+	newList, cmd := m.list.Update(msg)
+	m.list = newList
+	return m, cmd
+}
+
+func (m Model) handleAccountKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Custom account form: the text inputs own the keyboard
+	if m.accountEditing {
+		return m.handleAccountFormKey(msg)
+	}
+
+	// ESC/Q on account screen → back to family menu
+	if msg.String() == Esc || msg.String() == Quit {
+		return m.returnToFamilyMenu()
+	}
+
+	if key.Matches(msg, m.keys.Enter) {
+		item := m.list.SelectedItem()
+		if item == nil {
+			// No item selected; ignore Enter.
+			return m, nil
+		}
+
+		selected, ok := item.(menuItem)
+		if !ok {
+			// Unexpected item type; ignore Enter.
+			return m, nil
+		}
+
+		switch selected.data {
+		case "back":
+			return m.returnToFamilyMenu()
+		case "custom":
+			return m.openAccountForm()
+		default:
+			return m.applyAccount(service.ServiceAccount{Name: selected.data})
+		}
+	}
+
+	newList, cmd := m.list.Update(msg)
+	m.list = newList
+	return m, cmd
+}
+
+// openAccountForm shows an empty custom account form with the user focused
+func (m Model) openAccountForm() (Model, tea.Cmd) {
+	m.accountEditing = true
+	m.accountField = 0
+	m.userInput.SetValue("")
+	m.passwordInput.SetValue("")
+	m.passwordInput.Blur()
+	m.statusMessage = ""
+	return m, m.userInput.Focus()
+}
+
+// handleAccountFormKey edits the custom account form: Enter/Tab moves from
+// the user to the password, Enter on the password applies (or, on the
+// install confirmation, chooses the account), Esc cancels
+func (m Model) handleAccountFormKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case Esc:
+		m.accountEditing = false
+		m.userInput.Blur()
+		m.passwordInput.Blur()
+		m.passwordInput.SetValue("")
+		return m, nil
+
+	case "tab", "shift+tab", "up", "down":
+		return m.focusAccountField(1 - m.accountField)
+
+	case Enter:
+		if m.accountField == 0 {
+			return m.focusAccountField(1)
+		}
+		account := service.ServiceAccount{
+			Name:     strings.TrimSpace(m.userInput.Value()),
+			Password: m.passwordInput.Value(),
+		}
+		m.passwordInput.SetValue("")
+		if err := account.Validate(); err != nil {
+			m.statusMessage = fmt.Sprintf("Cuenta no válida: %v", err)
+			return m.focusAccountField(1)
+		}
+		m.accountEditing = false
+		m.userInput.Blur()
+		m.passwordInput.Blur()
+		if m.currentScreen == screenConfirm {
+			m.installAccount = account
+			m.statusMessage = ""
+			return m, nil
+		}
+		return m.applyAccount(account)
+	}
+
+	var cmd tea.Cmd
+	if m.accountField == 0 {
+		m.userInput, cmd = m.userInput.Update(msg)
+	} else {
+		m.passwordInput, cmd = m.passwordInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// focusAccountField moves the cursor to the user (0) or password (1) input
+func (m Model) focusAccountField(field int) (Model, tea.Cmd) {
+	m.accountField = field
+	if field == 0 {
+		m.passwordInput.Blur()
+		return m, m.userInput.Focus()
+	}
+	m.userInput.Blur()
+	return m, m.passwordInput.Focus()
+}
+
+// applyAccount changes the account of the active variant
+func (m Model) applyAccount(account service.ServiceAccount) (Model, tea.Cmd) {
+	mgr := m.getActiveManager()
+	if mgr == nil {
+		m.statusMessage = NoServiceMsg
+		return m, nil
+	}
+	return m.executeAction(fmt.Sprintf("Cambiar Cuenta de Servicio a %s", service.AccountLabel(account.Name)), func(ctx context.Context) error {
+		return mgr.ChangeAccount(ctx, account)
+	})
+}
+
+// recoveryRows is the number of editable rows on the recovery screen:
+// reset period, one per action, failure command, non-crash flag
+const recoveryRows = service.MaxRecoveryActions + 3
+
+// Recovery editor row indices beyond the action rows
+const (
+	recoveryRowReset    = 0
+	recoveryRowCommand  = service.MaxRecoveryActions + 1
+	recoveryRowNonCrash = service.MaxRecoveryActions + 2
+)
+
+// Values offered by the recovery editor when stepping with ←/→ and +/-
+var (
+	resetPresets = []time.Duration{0, time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour, 72 * time.Hour, 7 * 24 * time.Hour}
+	delayPresets = []time.Duration{0, time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second,
+		30 * time.Second, time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute}
+)
+
+func (m Model) handleRecoveryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Typing the failure command: the text input owns the keyboard
+	if m.recoveryEditing {
+		switch msg.String() {
+		case Enter:
+			m.recoveryDraft.Command = strings.TrimSpace(m.commandInput.Value())
+			m.recoveryTouched = true
+			m.recoveryEditing = false
+			m.commandInput.Blur()
+			return m, nil
+		case Esc:
+			m.recoveryEditing = false
+			m.commandInput.Blur()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.commandInput, cmd = m.commandInput.Update(msg)
+		return m, cmd
+	}
+
+	draft := &m.recoveryDraft
+	action := m.recoveryCursor - 1 // Index into draft.Actions on action rows
+
+	switch msg.String() {
+	case Esc, Quit:
+		return m.returnToFamilyMenu()
+
+	case "up", "k":
+		m.recoveryCursor = (m.recoveryCursor + recoveryRows - 1) % recoveryRows
+	case "down", "j":
+		m.recoveryCursor = (m.recoveryCursor + 1) % recoveryRows
+
+	case "left", "h", "right", "l":
+		m.recoveryTouched = true
+		step := 1
+		if msg.String() == "left" || msg.String() == "h" {
+			step = -1
+		}
+		switch {
+		case m.recoveryCursor == recoveryRowReset:
+			draft.ResetPeriod = stepPreset(resetPresets, draft.ResetPeriod, step)
+		case m.recoveryCursor == recoveryRowNonCrash:
+			draft.NonCrashFailures = !draft.NonCrashFailures
+		case action >= 0 && action < len(draft.Actions):
+			draft.Actions[action].Type = stepActionType(draft.Actions[action].Type, step)
+		}
+
+	case "+", "=", "-":
+		m.recoveryTouched = true
+		step := 1
+		if msg.String() == "-" {
+			step = -1
+		}
+		if action >= 0 && action < len(draft.Actions) {
+			draft.Actions[action].Delay = stepPreset(delayPresets, draft.Actions[action].Delay, step)
+		}
+
+	case " ":
+		if m.recoveryCursor == recoveryRowNonCrash {
+			m.recoveryTouched = true
+			draft.NonCrashFailures = !draft.NonCrashFailures
+		}
+
+	case Enter:
+		if m.recoveryCursor == recoveryRowCommand {
+			m.recoveryEditing = true
+			m.commandInput.SetValue(draft.Command)
+			m.commandInput.CursorEnd()
+			return m, m.commandInput.Focus()
+		}
+
+	case "d":
+		m.recoveryDraft = padRecoveryActions(service.DefaultRecoveryPolicy(m.selectedFamily))
+		m.recoveryTouched = true
+		m.statusMessage = "Valores por defecto cargados (sin aplicar)"
+
+	case "g":
+		if err := m.recoveryDraft.Validate(); err != nil {
+			m.statusMessage = fmt.Sprintf("No se puede guardar: %v", err)
+			return m, nil
+		}
+		return m.confirmRecovery()
+	}
+
+	return m, nil
+}
+
 func (m Model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Custom account form of the install confirmation: the text inputs own the keyboard
+	if m.accountEditing {
+		return m.handleAccountFormKey(msg)
+	}
+
 	switch msg.String() {
+	case "left", "h", "right", "l":
+		// Only the install confirmation has a start type to choose
+		if m.installVariant == "" {
+			return m, nil
+		}
+		step := 1
+		if msg.String() == "left" || msg.String() == "h" {
+			step = -1
+		}
+		m.installStart = stepStartType(m.installStart, step)
+		return m, nil
+	case "up", "k", "down", "j":
+		// ...and an account
+		if m.installVariant == "" {
+			return m, nil
+		}
+		step := 1
+		if msg.String() == "up" || msg.String() == "k" {
+			step = -1
+		}
+		m.installAccount = stepAccount(m.installAccount, step)
+		return m, nil
+	case "c", "C":
+		if m.installVariant == "" {
+			return m, nil
+		}
+		return m.openAccountForm()
 	case "s", "S":
-		m.currentScreen = screenProcessing
-		m.processing = true
-		return m, tea.Batch(
-			m.spinner.Tick,
-			m.confirmCallback,
-			simulateProgress(),
-		)
+		if m.installVariant != "" {
+			return m.startInstall()
+		}
+		return m.startOperation(m.confirmCallback)
 	case "n", "N", Esc:
 		m.confirmAction = ""
 		m.confirmCallback = nil
+		m.installVariant = ""
+		m.installAccount = service.ServiceAccount{}
 		return m.returnToFamilyMenu()
 	}
 	return m, nil
@@ -363,44 +852,99 @@ func (m Model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 // Action Helpers
 // ══════════════════════════════════════════════════════════════
 
-// confirmInstall shows a confirmation dialog for installing a variant
+// confirmInstall shows a confirmation dialog for installing a variant; the
+// start type is chosen on the dialog with ←/→ and the account with ↑/↓ (or
+// C for a custom one)
 func (m Model) confirmInstall(variant string) (Model, tea.Cmd) {
 	m.confirmAction = fmt.Sprintf("¿Instalar versión %s de %s?",
 		variant, capitalize(m.selectedFamily))
 
-	m.confirmCallback = func() tea.Msg {
-		variantID := fmt.Sprintf("%s-%s", m.selectedFamily, strings.ToLower(variant))
-		mgr := m.managers[variantID]
+	variantID := fmt.Sprintf("%s-%s", m.selectedFamily, strings.ToLower(variant))
+	m.installVariant = variant
+	m.installStart = m.managers[variantID].StartType()
+	m.installAccount = m.managers[variantID].Account()
+	m.statusMessage = ""
+	m.confirmCallback = nil
+
+	m.previousScreen = screenFamily
+	m.currentScreen = screenConfirm
+	return m, nil
+}
+
+// startInstall applies the start type and account chosen on the install
+// confirmation to the variant's manager and starts the install. They are
+// set here, on the UI goroutine, so the operation never writes the manager
+// while a status refresh reads it.
+func (m Model) startInstall() (Model, tea.Cmd) {
+	variantID := fmt.Sprintf("%s-%s", m.selectedFamily, strings.ToLower(m.installVariant))
+	mgr := m.managers[variantID]
+	if mgr == nil {
+		m.statusMessage = NoServiceMsg
+		return m, nil
+	}
+	if err := mgr.SetInstallStartType(m.installStart); err != nil {
+		m.statusMessage = fmt.Sprintf("No se puede instalar: %v", err)
+		return m, nil
+	}
+	if err := mgr.SetInstallAccount(m.installAccount); err != nil {
+		m.statusMessage = fmt.Sprintf("No se puede instalar: %v", err)
+		return m, nil
+	}
+
+	cmd := m.installCmd(m.installVariant, m.installStart)
+	m.installVariant = ""
+	m.installAccount = service.ServiceAccount{}
+	return m.startOperation(cmd)
+}
+
+// installCmd installs a variant whose start type and account are already
+// set on its manager (see startInstall). A disabled service is only
+// registered; any other start type is started as part of the install
+// transaction.
+func (m Model) installCmd(variant string, startType service.StartType) operation {
+	family := m.selectedFamily
+	variantID := fmt.Sprintf("%s-%s", family, strings.ToLower(variant))
+	mgr := m.managers[variantID]
+	accepted := m.portAccepted
+
+	return func(ctx context.Context) tea.Msg {
+		if accepted {
+			ctx = service.AllowPortConflicts(ctx)
+		}
 
-		if err := mgr.Install(); err != nil {
+		if startType == service.StartDisabled {
+			if err := mgr.Install(ctx); err != nil {
+				return operationDoneMsg{
+					success: false,
+					message: fmt.Sprintf("[X] No se pudo instalar %s %s\n\nDetalle: %v", capitalize(family), variant, err),
+					err:     err,
+				}
+			}
 			return operationDoneMsg{
-				success: false,
+				success: true,
 				message: fmt.Sprintf(
-					"[X] No se pudo instalar %s %s\n\nDetalle: %v\n\nVerifique que no exista una instalación previa.",
-					capitalize(m.selectedFamily), variant, err),
+					"[+] %s %s instalado\n\nEl servicio quedó deshabilitado y no se inició.",
+					capitalize(family), variant),
 			}
 		}
 
-		if err := mgr.Start(); err != nil {
+		// Install and start run as one transaction: if the service
+		// cannot be registered, configured or started, everything is undone
+		if err := mgr.InstallAndStart(ctx); err != nil {
 			return operationDoneMsg{
-				success: true, // Install succeeded even though start failed
-				message: fmt.Sprintf(
-					"[+] %s %s instalado correctamente\n\n[!] El servicio no pudo iniciarse automáticamente.\nUse 'Iniciar Servicio' desde el menú o Services.msc.\n\nDetalle: %v",
-					capitalize(m.selectedFamily), variant, err),
+				success: false,
+				message: fmt.Sprintf("[X] No se pudo instalar %s %s\n\nDetalle: %v", capitalize(family), variant, err),
+				err:     err,
 			}
 		}
 
 		return operationDoneMsg{
 			success: true,
 			message: fmt.Sprintf(
-				"[+] %s %s instalado e iniciado correctamente\n\nEl servicio está activo y configurado para inicio automático.",
-				capitalize(m.selectedFamily), variant),
+				"[+] %s %s instalado e iniciado correctamente\n\nTipo de inicio: %s.",
+				capitalize(family), variant, startType.Label()),
 		}
 	}
-
-	m.previousScreen = screenFamily
-	m.currentScreen = screenConfirm
-	return m, nil
 }
 
 // confirmUninstall shows a confirmation dialog for uninstalling the active variant
@@ -410,8 +954,14 @@ func (m Model) confirmUninstall() (Model, tea.Cmd) {
 
 	m.confirmAction = fmt.Sprintf("¿Desinstalar %s de %s?",
 		installed, capitalize(m.selectedFamily))
+	if len(fs.Dependents) > 0 {
+		m.confirmAction += fmt.Sprintf("\n\n[!] Dependen de este servicio: %s\nSe detendrán y no podrán iniciar hasta que se vuelva a instalar.",
+			formatDependents(fs.Dependents))
+	}
+	// The dialog above warned about them, so confirming stops them too
+	withDependents := len(fs.RunningDependents()) > 0
 
-	m.confirmCallback = func() tea.Msg {
+	m.confirmCallback = func(ctx context.Context) tea.Msg {
 		mgr := m.getActiveManager()
 		if mgr == nil {
 			return operationDoneMsg{
@@ -420,10 +970,15 @@ func (m Model) confirmUninstall() (Model, tea.Cmd) {
 			}
 		}
 
-		if err := mgr.Uninstall(); err != nil {
+		uninstall := mgr.Uninstall
+		if withDependents {
+			uninstall = mgr.UninstallWithDependents
+		}
+		if err := uninstall(ctx); err != nil {
 			return operationDoneMsg{
 				success: false,
 				message: fmt.Sprintf("[X] Error al desinstalar: %v", err),
+				err:     err,
 			}
 		}
 
@@ -438,23 +993,794 @@ func (m Model) confirmUninstall() (Model, tea.Cmd) {
 	return m, nil
 }
 
-// executeAction wraps a service operation with a loading/processing screen
-func (m Model) executeAction(actionName string, fn func() error) (Model, tea.Cmd) {
-	m.processing = true
-	m.currentScreen = screenProcessing
+// confirmStopWithDependents warns that stopping the active variant also stops
+// the running services that depend on it
+func (m Model) confirmStopWithDependents() (Model, tea.Cmd) {
+	fs := m.familyStatuses[m.selectedFamily]
+	installed := fs.GetInstalledVariant()
 
-	cmd := func() tea.Msg {
-		if err := fn(); err != nil {
+	m.confirmAction = fmt.Sprintf("Al detener %s de %s también se detendrán:\n\n%s\n\n¿Detener estos servicios?",
+		installed, capitalize(m.selectedFamily), formatDependents(fs.RunningDependents()))
+
+	m.confirmCallback = func(ctx context.Context) tea.Msg {
+		mgr := m.getActiveManager()
+		if mgr == nil {
+			return operationDoneMsg{
+				success: false,
+				message: "[X] No se encontró el servicio instalado",
+			}
+		}
+
+		if err := mgr.StopWithDependents(ctx); err != nil {
+			return operationDoneMsg{
+				success: false,
+				message: fmt.Sprintf("[X] Detener Servicio falló: %v", err),
+				err:     err,
+			}
+		}
+
+		return operationDoneMsg{
+			success: true,
+			message: "[OK] Detener Servicio completado (incluidos los servicios dependientes)",
+		}
+	}
+
+	m.previousScreen = screenFamily
+	m.currentScreen = screenConfirm
+	return m, nil
+}
+
+// confirmUpgrade shows a confirmation dialog for upgrading the active variant in place
+func (m Model) confirmUpgrade() (Model, tea.Cmd) {
+	fs := m.familyStatuses[m.selectedFamily]
+	installed := fs.GetInstalledVariant()
+
+	status := fs.GetActiveStatus()
+	wasRunning := status == service.StatusRunning || status == service.StatusStartPending
+
+	restart := "El servicio está detenido y seguirá detenido tras la actualización."
+	if wasRunning {
+		restart = "El servicio se detendrá y reiniciará; si no arranca se restaurará la versión anterior."
+	}
+	m.confirmAction = fmt.Sprintf("¿Actualizar %s de %s con el binario embebido?\n\n%s",
+		installed, capitalize(m.selectedFamily), restart)
+
+	m.confirmCallback = func(ctx context.Context) tea.Msg {
+		mgr := m.getActiveManager()
+		if mgr == nil {
+			return operationDoneMsg{
+				success: false,
+				message: "[X] No se encontró el servicio instalado",
+			}
+		}
+
+		if err := mgr.Upgrade(ctx); err != nil {
+			return operationDoneMsg{
+				success: false,
+				message: fmt.Sprintf("[X] Error al actualizar %s: %v", installed, err),
+				err:     err,
+			}
+		}
+
+		message := fmt.Sprintf("[^] %s actualizado (sigue detenido)", installed)
+		if wasRunning {
+			message = fmt.Sprintf("[^] %s actualizado y en ejecución", installed)
+		}
+		return operationDoneMsg{
+			success: true,
+			message: message,
+		}
+	}
+
+	m.previousScreen = screenFamily
+	m.currentScreen = screenConfirm
+	return m, nil
+}
+
+// confirmSwitch shows a confirmation dialog for switching the family to its other variant
+func (m Model) confirmSwitch() (Model, tea.Cmd) {
+	fs := m.familyStatuses[m.selectedFamily]
+	installed := fs.GetInstalledVariant()
+	target := otherVariant(installed)
+	family := m.selectedFamily
+
+	m.confirmAction = fmt.Sprintf("¿Cambiar %s de %s a %s?\n\nSe desinstalará %s y se instalará %s; si la nueva versión no arranca se reinstalará %s.",
+		capitalize(family), installed, target, installed, target, installed)
+
+	m.confirmCallback = func(ctx context.Context) tea.Msg {
+		if err := service.SwitchVariant(ctx, family, target); err != nil {
+			return operationDoneMsg{
+				success: false,
+				message: fmt.Sprintf("[X] No se pudo cambiar a %s\n\nDetalle: %v", target, err),
+				err:     err,
+			}
+		}
+
+		return operationDoneMsg{
+			success: true,
+			message: fmt.Sprintf("[~] %s cambiado de %s a %s\n\nEl servicio está activo y configurado para inicio automático.",
+				capitalize(family), installed, target),
+		}
+	}
+
+	m.previousScreen = screenFamily
+	m.currentScreen = screenConfirm
+	return m, nil
+}
+
+// confirmResolveConflict shows a confirmation dialog for keeping one variant
+// of a conflicted family and uninstalling the other
+func (m Model) confirmResolveConflict(keep string) (Model, tea.Cmd) {
+	removed := otherVariant(keep)
+	family := m.selectedFamily
+
+	m.confirmAction = fmt.Sprintf("¿Conservar %s de %s y desinstalar %s?",
+		keep, capitalize(family), removed)
+
+	m.confirmCallback = func(ctx context.Context) tea.Msg {
+		if err := service.ResolveConflict(ctx, family, keep); err != nil {
+			return operationDoneMsg{
+				success: false,
+				message: fmt.Sprintf("[X] No se pudo reparar el conflicto: %v", err),
+				err:     err,
+			}
+		}
+
+		return operationDoneMsg{
+			success: true,
+			message: fmt.Sprintf("[+] Conflicto reparado: %s conserva %s (%s desinstalado)",
+				capitalize(family), keep, removed),
+		}
+	}
+
+	m.previousScreen = screenConflict
+	m.currentScreen = screenConfirm
+	return m, nil
+}
+
+// confirmRecovery shows a confirmation dialog for applying the edited
+// recovery policy, then reads it back to confirm what the system applied
+func (m Model) confirmRecovery() (Model, tea.Cmd) {
+	fs := m.familyStatuses[m.selectedFamily]
+	installed := fs.GetInstalledVariant()
+	policy := m.recoveryDraft
+
+	m.confirmAction = fmt.Sprintf("¿Aplicar la política de recuperación a %s de %s?\n\n%s",
+		installed, capitalize(m.selectedFamily), policy.Summary())
+
+	m.confirmCallback = func(ctx context.Context) tea.Msg {
+		mgr := m.getActiveManager()
+		if mgr == nil {
+			return operationDoneMsg{
+				success: false,
+				message: "[X] No se encontró el servicio instalado",
+			}
+		}
+
+		if err := mgr.SetRecovery(ctx, policy); err != nil {
+			return operationDoneMsg{
+				success: false,
+				message: fmt.Sprintf("[X] Error al aplicar la política de recuperación: %v", err),
+				err:     err,
+			}
+		}
+
+		applied, err := mgr.QueryRecovery(ctx)
+		if err != nil {
+			return operationDoneMsg{
+				success: true,
+				message: fmt.Sprintf("[+] Política aplicada, pero no se pudo leer de vuelta: %v", err),
+			}
+		}
+		if !applied.Equal(policy) {
+			return operationDoneMsg{
+				success: true,
+				message: fmt.Sprintf("[!] Política aplicada; el sistema reporta:\n\n%s\n\n(el backend no admite todos los valores solicitados)",
+					formatRecoveryPolicy(applied)),
+			}
+		}
+		return operationDoneMsg{
+			success: true,
+			message: fmt.Sprintf("[+] Política de recuperación aplicada y confirmada:\n\n%s", formatRecoveryPolicy(applied)),
+		}
+	}
+
+	m.previousScreen = screenRecovery
+	m.currentScreen = screenConfirm
+	return m, nil
+}
+
+// confirmLogMaintenance shows the family's log usage and the retention policy
+// before compressing and purging the logs of both variants
+func (m Model) confirmLogMaintenance() (Model, tea.Cmd) {
+	family := m.selectedFamily
+	cfg, err := service.LoadInstallerConfig()
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error al leer la configuración: %v", err)
+		return m, nil
+	}
+	usage, err := service.FamilyLogUsage(family)
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error al medir los logs: %v", err)
+		return m, nil
+	}
+	policy := cfg.LogRetention
+
+	m.confirmAction = fmt.Sprintf("¿Ejecutar el mantenimiento de logs de %s?\n\nUso actual: %s\nPolítica: %s\n\n(la política se define en %s)",
+		capitalize(family), usage.Summary(), policy.Summary(), service.InstallerConfigPath())
+
+	m.confirmCallback = func(ctx context.Context) tea.Msg {
+		res, err := service.MaintainLogs(ctx, family, policy)
+		if err != nil {
+			return operationDoneMsg{
+				success: false,
+				message: fmt.Sprintf("[X] Mantenimiento de logs incompleto: %v\n\n%s", err, formatLogMaintenance(res)),
+				err:     err,
+			}
+		}
+
+		return operationDoneMsg{
+			success: true,
+			message: fmt.Sprintf("[OK] Mantenimiento de logs de %s completado\n\n%s",
+				capitalize(family), formatLogMaintenance(res)),
+		}
+	}
+
+	m.previousScreen = screenLogs
+	m.currentScreen = screenConfirm
+	return m, nil
+}
+
+// padRecoveryActions returns a copy of p with exactly MaxRecoveryActions
+// actions, so every slot has a row in the editor
+func padRecoveryActions(p service.RecoveryPolicy) service.RecoveryPolicy {
+	actions := make([]service.RecoveryAction, service.MaxRecoveryActions)
+	copy(actions, p.Actions)
+	for i := range actions {
+		if actions[i].Type == "" {
+			actions[i].Type = service.RecoveryNone
+		}
+	}
+	p.Actions = actions
+	return p
+}
+
+// stepPreset moves from current to the next (step > 0) or previous preset,
+// clamping at both ends
+func stepPreset(presets []time.Duration, current time.Duration, step int) time.Duration {
+	if step > 0 {
+		for _, p := range presets {
+			if p > current {
+				return p
+			}
+		}
+		return presets[len(presets)-1]
+	}
+	for i := len(presets) - 1; i >= 0; i-- {
+		if presets[i] < current {
+			return presets[i]
+		}
+	}
+	return presets[0]
+}
+
+// stepActionType cycles through the recovery action types
+func stepActionType(current service.RecoveryActionType, step int) service.RecoveryActionType {
+	types := service.RecoveryActionTypes
+	for i, t := range types {
+		if t == current {
+			return types[(i+step+len(types))%len(types)]
+		}
+	}
+	return types[0]
+}
+
+// stepAccount cycles through service.BuiltinAccounts, wrapping at both
+// ends; a custom account steps to the first or last built-in one
+func stepAccount(current service.ServiceAccount, step int) service.ServiceAccount {
+	accounts := service.BuiltinAccounts
+	i := -1
+	for j, name := range accounts {
+		if service.AccountLabel(current.Name) == service.AccountLabel(name) {
+			i = j
+			break
+		}
+	}
+	switch {
+	case i < 0 && step > 0:
+		i = 0
+	case i < 0:
+		i = len(accounts) - 1
+	default:
+		i = (i + step + len(accounts)) % len(accounts)
+	}
+	return service.ServiceAccount{Name: accounts[i]}
+}
+
+// stepStartType cycles through service.StartTypes, wrapping at both ends
+func stepStartType(current service.StartType, step int) service.StartType {
+	types := service.StartTypes
+	for i, t := range types {
+		if t == current {
+			return types[(i+step+len(types))%len(types)]
+		}
+	}
+	return types[0]
+}
+
+// verifyInstallation hashes the installed binary and reports how it compares
+// to the build manifest on the result screen
+func (m Model) verifyInstallation(mgr *service.Manager) (Model, tea.Cmd) {
+	return m.startOperation(func(_ context.Context) tea.Msg {
+		res, err := mgr.VerifyInstallation()
+		if err != nil {
+			return operationDoneMsg{
+				success: false,
+				message: fmt.Sprintf("[X] Verificación falló: %v", err),
+				err:     err,
+			}
+		}
+		return operationDoneMsg{
+			success: res.Outcome == service.VerifyMatch,
+			message: formatVerifyResult(res),
+		}
+	})
+}
+
+// executeAction wraps a service operation with a loading/processing screen
+func (m Model) executeAction(actionName string, fn func(ctx context.Context) error) (Model, tea.Cmd) {
+	return m.startOperation(func(ctx context.Context) tea.Msg {
+		if err := fn(ctx); err != nil {
 			return operationDoneMsg{
 				success: false,
 				message: fmt.Sprintf("[X] %s falló: %v", actionName, err),
+				err:     err,
 			}
 		}
 		return operationDoneMsg{
 			success: true,
 			message: fmt.Sprintf("[OK] %s completado", actionName),
 		}
+	})
+}
+
+// startOperation shows the processing screen and runs op in the background
+// under a context the cancel key can cancel, listening to its step reports
+func (m Model) startOperation(op operation) (Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Capacity 1 and latest wins: the view only needs the newest snapshot
+	updates := make(chan service.Progress, 1)
+	ctx = service.WithProgress(ctx, func(p service.Progress) {
+		select {
+		case <-updates:
+		default:
+		}
+		select {
+		case updates <- p:
+		default:
+		}
+	})
+
+	m.cancelOp = cancel
+	m.cancelling = false
+	m.processing = true
+	m.opProgress = service.Progress{Current: -1}
+	m.progressUpdates = updates
+	m.currentScreen = screenProcessing
+
+	cmd := func() tea.Msg {
+		defer close(updates)
+		return op(ctx)
 	}
+	return m, tea.Batch(m.spinner.Tick, cmd, waitProgressCmd(updates))
+}
+
+// handleProcessingKey cancels the running operation with c/Esc; the
+// operation rolls back what it started and reports on the result screen.
+// Ctrl+C cancels and quits.
+func (m Model) handleProcessingKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		if m.cancelOp != nil {
+			m.cancelOp()
+		}
+		return m, tea.Quit
+	case "c", Esc:
+		if m.cancelOp != nil && !m.cancelling {
+			m.cancelOp()
+			m.cancelling = true
+		}
+	}
+	return m, nil
+}
+
+// ══════════════════════════════════════════════════════════════
+// Operation History
+// ══════════════════════════════════════════════════════════════
 
-	return m, tea.Batch(m.spinner.Tick, cmd, simulateProgress())
+// historyOutcomes are the outcome filter values, in cycling order
+var historyOutcomes = []string{"", service.AuditOK, service.AuditFailed, service.AuditCancelled}
+
+// handleHistoryKey scrolls the operation history and cycles its filters:
+// f changes the family, o the outcome and r reloads the audit log
+func (m Model) handleHistoryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	last := max(len(m.filteredAudit())-1, 0)
+
+	switch msg.String() {
+	case Esc, Quit:
+		return m.goToDashboard()
+	case "up", "k":
+		m.historyOffset = max(m.historyOffset-1, 0)
+	case "down", "j":
+		m.historyOffset = min(m.historyOffset+1, last)
+	case "pgup":
+		m.historyOffset = max(m.historyOffset-m.historyPageSize(), 0)
+	case "pgdown":
+		m.historyOffset = min(m.historyOffset+m.historyPageSize(), last)
+	case "f":
+		families := append([]string{""}, service.GetFamilyNames()...)
+		m.historyFamily = nextFilter(families, m.historyFamily)
+		m.historyOffset = 0
+	case "o":
+		m.historyOutcome = nextFilter(historyOutcomes, m.historyOutcome)
+		m.historyOffset = 0
+	case "r":
+		return m, loadAuditCmd()
+	}
+	return m, nil
+}
+
+// filteredAudit returns the audit entries that pass the history filters
+func (m Model) filteredAudit() []service.AuditEntry {
+	var entries []service.AuditEntry
+	for _, e := range m.auditEntries {
+		if m.historyFamily != "" && e.Family != m.historyFamily {
+			continue
+		}
+		if m.historyOutcome != "" && e.Outcome != m.historyOutcome {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// historyPageSize returns how many entries fit on the history screen
+func (m Model) historyPageSize() int {
+	return max(m.height-20, 5)
+}
+
+// nextFilter returns the value after current in values, wrapping around
+func nextFilter(values []string, current string) string {
+	for i, v := range values {
+		if v == current {
+			return values[(i+1)%len(values)]
+		}
+	}
+	return values[0]
+}
+
+// ══════════════════════════════════════════════════════════════
+// Log Viewer
+// ══════════════════════════════════════════════════════════════
+
+// handleLogOpened shows the tail of the log and starts following it
+func (m Model) handleLogOpened(msg logOpenedMsg) (Model, tea.Cmd) {
+	if m.currentScreen != screenLogViewer {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.logErr = msg.err
+		return m, nil
+	}
+	m.logTail = msg.tail
+	m.logTruncated = msg.update.Truncated
+	m.setLogLines(msg.update.Lines)
+	return m, pollLogCmd(m.logTail)
+}
+
+// handleLogPolled appends what the daemon wrote since the last poll and
+// schedules the next one while the viewer stays open
+func (m Model) handleLogPolled(msg logPolledMsg) (Model, tea.Cmd) {
+	if m.currentScreen != screenLogViewer || msg.tail != m.logTail {
+		// The viewer was closed or reopened; let this poll chain end
+		return m, nil
+	}
+	m.logErr = msg.err
+	switch {
+	case msg.update.Reset:
+		m.logTruncated = msg.update.Truncated
+		m.statusMessage = "El archivo fue truncado o rotado; se recargó desde el inicio."
+		m.setLogLines(msg.update.Lines)
+	case len(msg.update.Lines) > 0:
+		m.setLogLines(append(m.logLines, msg.update.Lines...))
+	}
+	return m, pollLogCmd(m.logTail)
+}
+
+// setLogLines replaces the lines read, keeping the last logMaxLines, and
+// redraws the viewer
+func (m *Model) setLogLines(lines []string) {
+	if len(lines) > logMaxLines {
+		lines = lines[len(lines)-logMaxLines:]
+	}
+	m.logLines = lines
+	m.renderLog()
+}
+
+// renderLog fills the viewer with the records that pass the level and time
+// filters, highlighting the search pattern, and keeps the bottom in view
+// while following
+func (m *Model) renderLog() {
+	q := m.logQuery
+	if span := logRanges[m.logRange].span; span > 0 {
+		q.From = time.Now().Add(-span)
+	}
+
+	var b strings.Builder
+	m.logMatches = nil
+	m.logShown = 0
+	for _, rec := range service.ParseLogLines(m.logLines) {
+		if !q.Selects(rec) {
+			continue
+		}
+		for _, line := range rec.Lines {
+			if m.logShown > 0 {
+				b.WriteByte('\n')
+			}
+			if highlighted, ok := highlightMatches(line, q.Highlights(line)); ok {
+				m.logMatches = append(m.logMatches, m.logShown)
+				line = highlighted
+			}
+			b.WriteString(line)
+			m.logShown++
+		}
+	}
+	m.logMatch = min(m.logMatch, len(m.logMatches)-1)
+
+	m.logViewport.SetContent(b.String())
+	if m.logFollow {
+		m.logViewport.GotoBottom()
+	}
+}
+
+// jumpToMatch scrolls to the next (dir 1) or previous (dir -1) line holding
+// a match, wrapping around at either end, and stops following. The first
+// jump starts from what is in view.
+func (m *Model) jumpToMatch(dir int) {
+	n := len(m.logMatches)
+	switch {
+	case n == 0:
+		m.statusMessage = "Sin coincidencias para la búsqueda."
+		return
+	case m.logMatch >= 0:
+		m.logMatch = (m.logMatch + dir + n) % n
+	case dir > 0:
+		m.logMatch = sort.SearchInts(m.logMatches, m.logViewport.YOffset) % n
+	default:
+		bottom := m.logViewport.YOffset + m.logViewport.Height
+		m.logMatch = (sort.SearchInts(m.logMatches, bottom) - 1 + n) % n
+	}
+	m.statusMessage = ""
+	m.logFollow = false
+	m.logViewport.SetYOffset(m.logMatches[m.logMatch] - m.logViewport.Height/2)
+}
+
+// handleLogViewerKey scrolls the log: g/G jump to the top/bottom, s toggles
+// follow mode and scrolling up leaves it. / searches, n/N jump between
+// matches, e and t step through the level and time filters. Other keys go
+// to the viewport (↑/↓, PgUp/PgDn, u/d).
+func (m Model) handleLogViewerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Typing a search pattern: the text input owns the keyboard
+	if m.logSearching {
+		switch msg.String() {
+		case Enter:
+			q, err := service.CompileLogQuery(strings.TrimSpace(m.searchInput.Value()),
+				m.logQuery.MinLevel, time.Time{}, time.Time{})
+			if err != nil {
+				m.statusMessage = err.Error()
+				return m, nil
+			}
+			m.logSearching = false
+			m.searchInput.Blur()
+			m.statusMessage = ""
+			m.logQuery = q
+			m.logMatch = -1
+			m.renderLog()
+			if q.Pattern != nil {
+				m.jumpToMatch(1)
+			}
+			return m, nil
+		case Esc:
+			// Back to the pattern in effect
+			applied := ""
+			if m.logQuery.Pattern != nil {
+				applied = strings.TrimPrefix(m.logQuery.Pattern.String(), "(?i)")
+			}
+			m.searchInput.SetValue(applied)
+			m.logSearching = false
+			m.searchInput.Blur()
+			m.statusMessage = ""
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case Esc, Quit:
+		m.logTail = nil
+		m.logLines = nil
+		m.logMatches = nil
+		m.logViewport.SetContent("")
+		m.statusMessage = ""
+		return m.goToLogsMenu()
+	case "g", "home":
+		m.logFollow = false
+		m.logViewport.GotoTop()
+		return m, nil
+	case "G", "end":
+		m.logFollow = true
+		m.logViewport.GotoBottom()
+		return m, nil
+	case "s":
+		m.logFollow = !m.logFollow
+		if m.logFollow {
+			m.logViewport.GotoBottom()
+		}
+		return m, nil
+	case "/":
+		m.logSearching = true
+		m.searchInput.CursorEnd()
+		return m, m.searchInput.Focus()
+	case "n":
+		m.jumpToMatch(1)
+		return m, nil
+	case "N":
+		m.jumpToMatch(-1)
+		return m, nil
+	case "e":
+		levels := len(service.LogLevels)
+		m.logQuery.MinLevel = service.LogLevels[(int(m.logQuery.MinLevel)+1)%levels]
+		m.renderLog()
+		return m, nil
+	case "t":
+		m.logRange = (m.logRange + 1) % len(logRanges)
+		m.renderLog()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.logViewport, cmd = m.logViewport.Update(msg)
+	// Following stops as soon as the newest line scrolls out of view
+	m.logFollow = m.logViewport.AtBottom()
+	return m, cmd
+}
+
+// scrollLog applies mouse wheel scrolling to the log viewer
+func (m Model) scrollLog(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.logViewport, cmd = m.logViewport.Update(msg)
+	m.logFollow = m.logViewport.AtBottom()
+	return m, cmd
+}
+
+// ══════════════════════════════════════════════════════════════
+// Support Bundle
+// ══════════════════════════════════════════════════════════════
+
+// handleSupportKey edits the destination directory; Enter writes the bundle
+func (m Model) handleSupportKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case Esc:
+		m.supportInput.Blur()
+		return m.goToDashboard()
+	case Enter:
+		dir := strings.TrimSpace(m.supportInput.Value())
+		if dir == "" {
+			m.statusMessage = "Indique el directorio de destino."
+			return m, nil
+		}
+		m.supportInput.Blur()
+		m.statusMessage = ""
+		return m.startOperation(func(ctx context.Context) tea.Msg {
+			path, err := service.WriteSupportBundle(ctx, dir)
+			if err != nil {
+				return operationDoneMsg{
+					success: false,
+					message: fmt.Sprintf("[X] No se pudo generar el paquete de soporte: %v", err),
+					err:     err,
+				}
+			}
+			return operationDoneMsg{
+				success: true,
+				message: fmt.Sprintf("[P] Paquete de soporte generado:\n\n%s\n\nEnvíe este archivo a soporte técnico.", path),
+			}
+		})
+	}
+
+	var cmd tea.Cmd
+	m.supportInput, cmd = m.supportInput.Update(msg)
+	return m, cmd
+}
+
+// ══════════════════════════════════════════════════════════════
+// Port Check
+// ══════════════════════════════════════════════════════════════
+
+// Actions gated by the port check
+const (
+	portActionInstall = "install"
+	portActionStart   = "start"
+)
+
+// checkPorts looks for port conflicts of a variant in the background; the
+// install or start goes ahead once the check comes back clean
+func (m Model) checkPorts(action, variant string) (Model, tea.Cmd) {
+	family := m.selectedFamily
+	mgr := m.managers[fmt.Sprintf("%s-%s", family, strings.ToLower(variant))]
+	if mgr == nil {
+		m.statusMessage = NoServiceMsg
+		return m, nil
+	}
+	m.statusMessage = "Comprobando el puerto del servicio..."
+	return m, func() tea.Msg {
+		return portCheckMsg{
+			family:    family,
+			action:    action,
+			variant:   variant,
+			conflicts: mgr.PortConflicts(context.Background()),
+		}
+	}
+}
+
+// handlePortCheck goes ahead with the action, or shows the conflicts and
+// lets the user decide
+func (m Model) handlePortCheck(msg portCheckMsg) (Model, tea.Cmd) {
+	// The user left the family menu while the check ran
+	if msg.family != m.selectedFamily || (m.currentScreen != screenFamily && m.currentScreen != screenPorts) {
+		return m, nil
+	}
+	m.statusMessage = ""
+	if len(msg.conflicts) == 0 {
+		return m.proceedPortAction(msg.action, msg.variant, false)
+	}
+
+	m.portConflicts = msg.conflicts
+	m.portAction = msg.action
+	m.portVariant = msg.variant
+	m.previousScreen = screenFamily
+	m.currentScreen = screenPorts
+	return m, nil
+}
+
+// proceedPortAction runs the action the port check was guarding; accepted
+// lets it past the manager's own port check
+func (m Model) proceedPortAction(action, variant string, accepted bool) (Model, tea.Cmd) {
+	m.portAccepted = accepted
+	if action == portActionInstall {
+		return m.confirmInstall(variant)
+	}
+	mgr := m.managers[fmt.Sprintf("%s-%s", m.selectedFamily, strings.ToLower(variant))]
+	return m.executeAction("Iniciar Servicio", func(ctx context.Context) error {
+		if accepted {
+			ctx = service.AllowPortConflicts(ctx)
+		}
+		return mgr.Start(ctx)
+	})
+}
+
+// handlePortsKey chooses between continuing and aborting
+func (m Model) handlePortsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "c", "C":
+		m.portConflicts = nil
+		return m.proceedPortAction(m.portAction, m.portVariant, true)
+	case "n", "N", Esc:
+		m.portConflicts = nil
+		return m.returnToFamilyMenu()
+	}
+	return m, nil
 }
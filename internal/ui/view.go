@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 
@@ -33,6 +34,22 @@ func (m Model) View() string {
 		return m.viewResult()
 	case screenConfirm:
 		return m.viewConfirm()
+	case screenConflict:
+		return m.viewConflict()
+	case screenRecovery:
+		return m.viewRecovery()
+	case screenStartType:
+		return m.viewStartType()
+	case screenAccount:
+		return m.viewAccount()
+	case screenHistory:
+		return m.viewHistory()
+	case screenLogViewer:
+		return m.viewLogViewer()
+	case screenSupport:
+		return m.viewSupport()
+	case screenPorts:
+		return m.viewPorts()
 	default:
 		return "Estado desconocido"
 	}
@@ -48,11 +65,22 @@ func (m Model) viewDashboard() string {
 	b.WriteString(bannerStyle.Render(config.GetBanner()) + "\n\n")
 	b.WriteString(titleStyle.Render("SELECCIONE UNA FAMILIA DE SERVICIOS") + "\n\n")
 
+	if len(m.integrityIssues) > 0 {
+		b.WriteString(warningStyle.Render("[!] Binarios embebidos no coinciden con el manifiesto:") + "\n")
+		for _, issue := range m.integrityIssues {
+			b.WriteString(warningStyle.Render("    "+issue) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
 	b.WriteString(m.list.View())
 
 	// Health summary bar
 	healthSummary := m.renderHealthSummary()
 	b.WriteString("\n" + statusBarStyle.Render(healthSummary))
+	if stats := m.renderQueryStats(); stats != "" {
+		b.WriteString("\n" + disabledStyle.Render(stats))
+	}
 
 	b.WriteString("\n" + m.help.View(m.keys))
 
@@ -81,8 +109,80 @@ func (m Model) viewFamily() string {
 		b.WriteString(titleStyle.Render(
 			fmt.Sprintf("%s - %s", strings.ToUpper(m.selectedFamily), installed)) + "\n")
 		b.WriteString(statusBarStyle.Render(
-			fmt.Sprintf("Estado: %s", status.String())) + "\n\n")
+			fmt.Sprintf("Estado: %s", status.String())) + "\n")
+		if details := formatStateDetails(fs.GetActiveState()); details != "" {
+			b.WriteString(infoStyle.Render(details) + "\n")
+		}
+		switch fs.Health() {
+		case service.HealthHealthy:
+			b.WriteString(successStyle.Render("Salud: "+fs.Probe.Summary()) + "\n")
+		case service.HealthUnhealthy:
+			b.WriteString(warningStyle.Render("[!] Salud: "+fs.Probe.Summary()) + "\n")
+		default:
+			// Stopped, pending or without a probe: the state line says it all
+		}
+		cfg := fs.GetActiveConfig()
+		if cfg.Description != "" {
+			b.WriteString(infoStyle.Render(cfg.Description) + "\n")
+		}
+		if startType := service.StartTypeOf(cfg); startType != "" {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("Tipo de inicio: %s", startType.Label())) + "\n")
+		}
+		if cfg.Account != "" {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("Cuenta: %s", service.AccountLabel(cfg.Account))) + "\n")
+		}
+		for _, v := range m.registry[m.selectedFamily] {
+			if v.Variant == installed && v.Listen.Port != 0 {
+				b.WriteString(infoStyle.Render(fmt.Sprintf("Escucha en: %s", v.Listen)) + "\n")
+			}
+		}
+		if fs.BinaryDiffers {
+			b.WriteString(warningStyle.Render("[!] El binario instalado difiere del embebido en este instalador") + "\n")
+		}
+		if len(fs.Dependencies) > 0 {
+			b.WriteString(infoStyle.Render("Dependencias:\n"+formatDependencyTree(fs.Dependencies, "  ")) + "\n")
+		}
+		if len(fs.Dependents) > 0 {
+			b.WriteString(infoStyle.Render("Dependientes: "+formatDependents(fs.Dependents)) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(m.list.View())
+	b.WriteString("\n" + m.help.View(m.keys))
+
+	if m.statusMessage != "" {
+		b.WriteString("\n" + successStyle.Render(m.statusMessage))
+	}
+
+	return b.String()
+}
+
+func (m Model) viewConflict() string {
+	var b strings.Builder
+
+	fs := m.familyStatuses[m.selectedFamily]
+
+	b.WriteString(bannerStyle.Render(config.GetBanner()) + "\n\n")
+	b.WriteString(errorStyle.Render(
+		fmt.Sprintf("[!] %s - CONFLICTO: AMBAS VERSIONES REGISTRADAS", strings.ToUpper(m.selectedFamily))) + "\n")
+	b.WriteString(infoStyle.Render(
+		"Solo una versión (Local o Remoto) puede estar instalada. Elija cuál conservar.") + "\n\n")
+
+	for _, row := range []struct {
+		name  string
+		state service.ServiceState
+	}{
+		{service.Local, fs.LocalState},
+		{service.Remoto, fs.RemoteState},
+	} {
+		line := fmt.Sprintf("%-7s %s", row.name+":", row.state.Status.String())
+		if details := formatStateDetails(row.state); details != "" {
+			line += "  " + details
+		}
+		b.WriteString(statusBarStyle.Render(line) + "\n")
 	}
+	b.WriteString("\n")
 
 	b.WriteString(m.list.View())
 	b.WriteString("\n" + m.help.View(m.keys))
@@ -94,6 +194,68 @@ func (m Model) viewFamily() string {
 	return b.String()
 }
 
+func (m Model) viewRecovery() string {
+	var b strings.Builder
+
+	fs := m.familyStatuses[m.selectedFamily]
+
+	b.WriteString(bannerStyle.Render(config.GetBanner()) + "\n\n")
+	b.WriteString(titleStyle.Render(
+		fmt.Sprintf("POLÍTICA DE RECUPERACIÓN - %s %s", strings.ToUpper(m.selectedFamily), fs.GetInstalledVariant())) + "\n\n")
+
+	// What the system reports (sc qfailure)
+	switch {
+	case m.recoveryErr != nil:
+		b.WriteString(errorStyle.Render(fmt.Sprintf("[X] No se pudo leer la política aplicada: %v", m.recoveryErr)) + "\n\n")
+	case m.recoveryApplied == nil:
+		b.WriteString(infoStyle.Render(m.spinner.View()+" Leyendo política aplicada...") + "\n\n")
+	default:
+		b.WriteString(statusBarStyle.Render("Aplicada: "+m.recoveryApplied.Summary()) + "\n\n")
+	}
+
+	// Editable rows
+	draft := m.recoveryDraft
+	rows := make([]string, 0, recoveryRows)
+	rows = append(rows, fmt.Sprintf("Restablecer contador tras: %s", service.FormatDuration(draft.ResetPeriod)))
+	for i, a := range draft.Actions {
+		rows = append(rows, fmt.Sprintf("%-17s: %-20s espera %s", recoveryOrdinal(i), a.Type.Label(), service.FormatDuration(a.Delay)))
+	}
+	command := draft.Command
+	if m.recoveryEditing {
+		command = m.commandInput.View()
+	} else if command == "" {
+		command = "(ninguno)"
+	}
+	rows = append(rows, "Comando al fallar: "+command)
+	rows = append(rows, fmt.Sprintf("Acciones en detenciones con errores: %s", yesNo(draft.NonCrashFailures)))
+
+	for i, row := range rows {
+		if i == m.recoveryCursor {
+			b.WriteString(selectedStyle.Render("> "+row) + "\n")
+		} else {
+			b.WriteString(normalStyle.Render("  "+row) + "\n")
+		}
+	}
+
+	if err := draft.Validate(); err != nil {
+		b.WriteString("\n" + warningStyle.Render("[!] "+err.Error()))
+	} else if m.recoveryApplied != nil && !draft.Equal(*m.recoveryApplied) {
+		b.WriteString("\n" + warningStyle.Render("[*] Hay cambios sin aplicar"))
+	}
+
+	if m.recoveryEditing {
+		b.WriteString("\n\n" + infoStyle.Render("[Enter] Aceptar  [ESC] Cancelar"))
+	} else {
+		b.WriteString("\n\n" + infoStyle.Render("[↑/↓] Campo  [←/→] Cambiar  [+/-] Espera  [Enter] Editar comando  [d] Por defecto  [g] Guardar  [ESC] Volver"))
+	}
+
+	if m.statusMessage != "" {
+		b.WriteString("\n" + successStyle.Render(m.statusMessage))
+	}
+
+	return b.String()
+}
+
 func (m Model) viewLogs() string {
 	var b strings.Builder
 
@@ -112,6 +274,231 @@ func (m Model) viewLogs() string {
 	return b.String()
 }
 
+func (m Model) viewStartType() string {
+	var b strings.Builder
+
+	b.WriteString(bannerStyle.Render(config.GetBanner()) + "\n\n")
+	b.WriteString(statusBarStyle.Render(
+		fmt.Sprintf("[T] TIPO DE INICIO - %s %s", strings.ToUpper(m.selectedFamily),
+			m.familyStatuses[m.selectedFamily].GetInstalledVariant())) + "\n\n")
+
+	b.WriteString(m.list.View())
+
+	b.WriteString("\n" + infoStyle.Render("[ENTER] Aplicar  [ESC] Volver al menú de servicio"))
+
+	if m.statusMessage != "" {
+		b.WriteString("\n" + successStyle.Render(m.statusMessage))
+	}
+
+	return b.String()
+}
+
+func (m Model) viewAccount() string {
+	var b strings.Builder
+
+	fs := m.familyStatuses[m.selectedFamily]
+
+	b.WriteString(bannerStyle.Render(config.GetBanner()) + "\n\n")
+	b.WriteString(statusBarStyle.Render(
+		fmt.Sprintf("[U] CUENTA DE SERVICIO - %s %s", strings.ToUpper(m.selectedFamily), fs.GetInstalledVariant())) + "\n")
+	if current := fs.GetActiveConfig().Account; current != "" {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("Cuenta actual: %s", service.AccountLabel(current))) + "\n")
+	}
+	b.WriteString("\n")
+
+	if m.accountEditing {
+		b.WriteString(titleStyle.Render("Cuenta personalizada") + "\n\n")
+		_, _ = fmt.Fprintf(&b, "Usuario:     %s\n", m.userInput.View())
+		_, _ = fmt.Fprintf(&b, "Contraseña:  %s\n\n", m.passwordInput.View())
+		b.WriteString(infoStyle.Render("La cuenta necesita el derecho 'Iniciar sesión como servicio'. La contraseña no se guarda ni se registra.") + "\n\n")
+		b.WriteString(infoStyle.Render("[TAB] Cambiar campo  [ENTER] Aplicar  [ESC] Cancelar"))
+	} else {
+		b.WriteString(m.list.View())
+		b.WriteString("\n" + infoStyle.Render("[ENTER] Aplicar (efectivo al reiniciar el servicio)  [ESC] Volver al menú de servicio"))
+	}
+
+	if m.statusMessage != "" {
+		b.WriteString("\n" + warningStyle.Render(m.statusMessage))
+	}
+
+	return b.String()
+}
+
+func (m Model) viewSupport() string {
+	var b strings.Builder
+
+	b.WriteString(bannerStyle.Render(config.GetBanner()) + "\n\n")
+	b.WriteString(statusBarStyle.Render("[P] PAQUETE DE SOPORTE") + "\n\n")
+	b.WriteString(infoStyle.Render("Se generará un .zip con los logs recientes, el estado y la configuración de los servicios,") + "\n")
+	b.WriteString(infoStyle.Render("los hashes de los binarios, la versión del instalador, el entorno (sin secretos) y el historial.") + "\n\n")
+	_, _ = fmt.Fprintf(&b, "Directorio de destino:\n%s\n\n", m.supportInput.View())
+	b.WriteString(infoStyle.Render("[ENTER] Generar  [ESC] Volver"))
+
+	if m.statusMessage != "" {
+		b.WriteString("\n" + warningStyle.Render(m.statusMessage))
+	}
+
+	return b.String()
+}
+
+func (m Model) viewPorts() string {
+	var b strings.Builder
+
+	action := "iniciar"
+	if m.portAction == portActionInstall {
+		action = "instalar"
+	}
+
+	b.WriteString(bannerStyle.Render(config.GetBanner()) + "\n\n")
+	b.WriteString(statusBarStyle.Render(fmt.Sprintf("[!] %s %s - PUERTO EN USO",
+		strings.ToUpper(m.selectedFamily), m.portVariant)) + "\n\n")
+	b.WriteString(infoStyle.Render(fmt.Sprintf("Antes de %s el servicio se encontró:", action)) + "\n")
+	for _, c := range m.portConflicts {
+		b.WriteString(warningStyle.Render("  - "+c.String()) + "\n")
+	}
+	b.WriteString("\n" + infoStyle.Render("Si continúa, el servicio no podrá abrir su puerto y se reiniciará en bucle") + "\n")
+	b.WriteString(infoStyle.Render("hasta que el puerto quede libre.") + "\n\n")
+
+	b.WriteString(disabledStyle.Render(fmt.Sprintf(
+		"El puerto está fijado en la compilación del servicio (%s_PORT en .env).",
+		strings.ToUpper(m.selectedFamily))) + "\n")
+	b.WriteString(infoStyle.Render("[C] Continuar de todos modos  [N/ESC] Cancelar"))
+
+	if m.statusMessage != "" {
+		b.WriteString("\n" + warningStyle.Render(m.statusMessage))
+	}
+
+	return b.String()
+}
+
+func (m Model) viewLogViewer() string {
+	var b strings.Builder
+
+	b.WriteString(bannerStyle.Render(config.GetBanner()) + "\n\n")
+	b.WriteString(statusBarStyle.Render(
+		fmt.Sprintf("[V] LOG - %s %s", strings.ToUpper(m.selectedFamily),
+			m.familyStatuses[m.selectedFamily].GetInstalledVariant())) + "\n")
+
+	switch {
+	case m.logErr != nil && m.logTail == nil:
+		b.WriteString("\n" + errorStyle.Render(fmt.Sprintf("[X] No se pudo abrir el log: %v", m.logErr)) + "\n")
+		b.WriteString("\n" + infoStyle.Render("[ESC] Volver"))
+		return b.String()
+	case m.logTail == nil:
+		b.WriteString("\n" + infoStyle.Render("Cargando log...") + "\n")
+		return b.String()
+	}
+
+	b.WriteString(disabledStyle.Render(m.logTail.Path()) + "\n")
+	b.WriteString(m.logViewport.View() + "\n")
+
+	follow := disabledStyle.Render("[s] Seguir: NO")
+	if m.logFollow {
+		follow = successStyle.Render("[s] Seguir: SÍ")
+	}
+	position := fmt.Sprintf("%d líneas · %3.0f%%", len(m.logLines), m.logViewport.ScrollPercent()*100)
+	if m.logShown < len(m.logLines) {
+		position = fmt.Sprintf("%d de %d líneas · %3.0f%%", m.logShown, len(m.logLines), m.logViewport.ScrollPercent()*100)
+	}
+	if m.logTruncated {
+		position += " · solo el final del archivo"
+	}
+	b.WriteString(follow + "  " + infoStyle.Render(position) + "\n")
+	b.WriteString(m.renderLogFilters())
+
+	switch {
+	case m.logSearching:
+		b.WriteString("\n" + m.searchInput.View())
+		if m.statusMessage != "" {
+			b.WriteString("  " + errorStyle.Render(m.statusMessage))
+		}
+		b.WriteString("\n" + infoStyle.Render("[ENTER] Buscar (vacío para quitar)  [ESC] Cancelar"))
+		return b.String()
+	case m.logErr != nil:
+		b.WriteString("\n" + warningStyle.Render(fmt.Sprintf("[!] %v", m.logErr)))
+	case m.statusMessage != "":
+		b.WriteString("\n" + warningStyle.Render("[!] "+m.statusMessage))
+	}
+
+	b.WriteString("\n" + infoStyle.Render("[↑/↓] Línea  [PgUp/PgDn] Página  [g/G] Inicio/Final  [s] Seguir  [ESC] Volver"))
+	b.WriteString("\n" + infoStyle.Render("[/] Buscar  [n/N] Siguiente/Anterior  [e] Nivel  [t] Periodo"))
+
+	return b.String()
+}
+
+// renderLogFilters describes the level, time and search filters in effect
+func (m Model) renderLogFilters() string {
+	level := "todos"
+	if m.logQuery.MinLevel > service.LevelDebug {
+		level = m.logQuery.MinLevel.String() + " o superior"
+	}
+	filters := fmt.Sprintf("Nivel: %s  Periodo: %s", level, logRanges[m.logRange].label)
+
+	if m.logQuery.Pattern != nil {
+		found := "sin coincidencias"
+		switch {
+		case len(m.logMatches) > 0 && m.logMatch >= 0:
+			found = fmt.Sprintf("%d de %d", m.logMatch+1, len(m.logMatches))
+		case len(m.logMatches) > 0:
+			found = fmt.Sprintf("%d coincidencias", len(m.logMatches))
+		}
+		filters += fmt.Sprintf("  Búsqueda: %s (%s)", m.searchInput.Value(), found)
+	}
+	return disabledStyle.Render(filters)
+}
+
+// highlightMatches renders the [start, end) spans of line in matchStyle.
+// ok is false when there was nothing to highlight.
+func highlightMatches(line string, spans [][]int) (string, bool) {
+	var b strings.Builder
+	last, marked := 0, false
+	for _, span := range spans {
+		if span[0] == span[1] {
+			continue // An empty match (a pattern like "x*") marks nothing
+		}
+		b.WriteString(line[last:span[0]])
+		b.WriteString(matchStyle.Render(line[span[0]:span[1]]))
+		last, marked = span[1], true
+	}
+	if !marked {
+		return line, false
+	}
+	b.WriteString(line[last:])
+	return b.String(), true
+}
+
+func (m Model) viewHistory() string {
+	var b strings.Builder
+
+	b.WriteString(bannerStyle.Render(config.GetBanner()) + "\n\n")
+	b.WriteString(statusBarStyle.Render("[H] HISTORIAL DE OPERACIONES") + "\n\n")
+
+	entries := m.filteredAudit()
+	_, _ = fmt.Fprintf(&b, "Familia: %s   Resultado: %s   (%d de %d registros)\n\n",
+		historyFamilyLabel(m.historyFamily), historyOutcomeLabel(m.historyOutcome), len(entries), len(m.auditEntries))
+
+	switch {
+	case m.auditErr != nil:
+		b.WriteString(errorStyle.Render(fmt.Sprintf("[X] No se pudo leer el historial: %v", m.auditErr)) + "\n")
+	case len(entries) == 0:
+		b.WriteString(infoStyle.Render("No hay operaciones registradas con estos filtros.") + "\n")
+	default:
+		first := min(m.historyOffset, len(entries)-1)
+		last := min(first+m.historyPageSize(), len(entries))
+		for _, e := range entries[first:last] {
+			b.WriteString(renderAuditEntry(e) + "\n")
+		}
+		if first > 0 || last < len(entries) {
+			b.WriteString(disabledStyle.Render(fmt.Sprintf("Mostrando %d-%d de %d", first+1, last, len(entries))) + "\n")
+		}
+	}
+
+	b.WriteString("\n" + infoStyle.Render("[↑/↓] Desplazar  [f] Familia  [o] Resultado  [r] Recargar  [ESC] Volver"))
+	b.WriteString("\n" + disabledStyle.Render("Archivo: "+service.AuditLogPath()))
+
+	return b.String()
+}
+
 func (m Model) viewProcessing() string {
 	var b strings.Builder
 
@@ -124,18 +511,63 @@ func (m Model) viewProcessing() string {
 	)
 	b.WriteString(spinnerView + "\n\n")
 
-	if m.progressPercent > 0 {
-		b.WriteString(m.progress.ViewAs(m.progressPercent))
-		//nolint:staticcheck // false positive: string builder is needed for the progress view
-		b.WriteString(fmt.Sprintf("\n%.0f%% completado", m.progressPercent*100))
+	if p := m.opProgress; p.Total() > 0 {
+		b.WriteString(renderSteps(p) + "\n")
+		b.WriteString(m.progress.ViewAs(float64(p.Completed()) / float64(p.Total())))
+		_, _ = fmt.Fprintf(&b, "\n%d de %d pasos completados", p.Completed(), p.Total())
 	}
 
 	pulseStyle := lipgloss.NewStyle().Foreground(secondaryColor)
-	b.WriteString("\n\n" + pulseStyle.Render("[~] Por favor espere..."))
+	if m.cancelling {
+		b.WriteString("\n\n" + warningStyle.Render("[!] Cancelando — revirtiendo los cambios iniciados..."))
+	} else {
+		b.WriteString("\n\n" + pulseStyle.Render("[~] Por favor espere..."))
+		b.WriteString("\n\n" + infoStyle.Render("[c/ESC] Cancelar operación"))
+	}
+
+	return b.String()
+}
+
+// renderSteps lists the steps of the running operation with their state and
+// the time spent in each; the running one shows its sub-status below
+func renderSteps(p service.Progress) string {
+	var b strings.Builder
+	runningStyle := lipgloss.NewStyle().Foreground(secondaryColor).Bold(true)
+	for i, st := range p.Steps {
+		elapsed := st.Elapsed
+		if i == p.Current {
+			// The snapshot is as old as the last report; keep the clock moving
+			elapsed = time.Since(st.Started)
+		}
+
+		var line string
+		switch st.State {
+		case service.StepDone:
+			line = successStyle.Render(fmt.Sprintf("[OK] %-32s %s", st.Name, formatStepDuration(elapsed)))
+		case service.StepFailed:
+			line = errorStyle.Render(fmt.Sprintf("[X]  %-32s %s", st.Name, formatStepDuration(elapsed)))
+		case service.StepRunning:
+			line = runningStyle.Render(fmt.Sprintf("[>]  %-32s %s", st.Name, formatStepDuration(elapsed)))
+		default:
+			line = disabledStyle.Render(fmt.Sprintf("[ ]  %s", st.Name))
+		}
+		b.WriteString(line + "\n")
 
+		if st.Detail != "" && st.State != service.StepDone {
+			b.WriteString(infoStyle.Render("     "+st.Detail) + "\n")
+		}
+	}
 	return b.String()
 }
 
+// formatStepDuration formats the time spent in a step, e.g. "0.4s" or "12s"
+func formatStepDuration(d time.Duration) string {
+	if d < 10*time.Second {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
+
 func (m Model) viewResult() string {
 	var b strings.Builder
 
@@ -149,6 +581,9 @@ func (m Model) viewResult() string {
 	}
 
 	b.WriteString(boxStyle.Render(m.result))
+	if hint := remediationHint(m.resultErr); hint != "" {
+		b.WriteString("\n\n" + warningStyle.Render("[i] "+hint))
+	}
 	b.WriteString("\n\n" + infoStyle.Render("Presione Enter para continuar..."))
 
 	return b.String()
@@ -167,9 +602,26 @@ func (m Model) viewConfirm() string {
 		Align(lipgloss.Center)
 
 	content := fmt.Sprintf("[!] CONFIRMACIÓN\n\n%s\n\n", m.confirmAction)
-	content += successStyle.Render("[S]í") + "    " + warningStyle.Render("[N]o")
+	switch {
+	case m.installVariant != "" && m.accountEditing:
+		content += titleStyle.Render("Cuenta personalizada") + "\n\n"
+		content += fmt.Sprintf("Usuario:     %s\n", m.userInput.View())
+		content += fmt.Sprintf("Contraseña:  %s\n\n", m.passwordInput.View())
+		content += infoStyle.Render("La cuenta necesita el derecho 'Iniciar sesión como servicio'. La contraseña no se guarda ni se registra.") + "\n\n"
+		content += infoStyle.Render("[TAB] Cambiar campo  [ENTER] Elegir  [ESC] Cancelar")
+	case m.installVariant != "":
+		content += fmt.Sprintf("Tipo de inicio: ◀ %s ▶\n", m.installStart.Label())
+		content += fmt.Sprintf("Cuenta:         ▲ %s ▼\n", service.AccountLabel(m.installAccount.Name))
+		content += infoStyle.Render("[←/→] Cambiar tipo de inicio  [↑/↓] Cambiar cuenta  [C] Cuenta personalizada") + "\n\n"
+		content += successStyle.Render("[S]í") + "    " + warningStyle.Render("[N]o")
+	default:
+		content += successStyle.Render("[S]í") + "    " + warningStyle.Render("[N]o")
+	}
 
 	b.WriteString(confirmBox.Render(content))
+	if m.statusMessage != "" {
+		b.WriteString("\n" + warningStyle.Render(m.statusMessage))
+	}
 
 	return b.String()
 }
@@ -178,32 +630,246 @@ func (m Model) viewConfirm() string {
 // Helper Renderers
 // ══════════════════════════════════════════════════════════════
 
-// renderHealthSummary generates the status bar showing all families' health
+// renderHealthSummary generates the status bar showing all families'
+// health: the SCM state of the installed variant combined with its probe,
+// e.g. "scale: [+] Local 4ms | ticket: [!] Remoto SIN RESPUESTA"
 func (m Model) renderHealthSummary() string {
 	var parts []string
 
 	for _, family := range service.GetFamilyNames() {
 		fs := m.familyStatuses[family]
-		installed := fs.GetInstalledVariant()
+		health := fs.Health()
 
-		if installed == "" {
+		switch {
+		case fs.HasConflict():
+			parts = append(parts, fmt.Sprintf("%s: [!] CONFLICTO", family))
+		case health == service.HealthNotInstalled:
 			parts = append(parts, fmt.Sprintf("%s: [-]", family))
-		} else {
-			status := fs.GetActiveStatus()
-
-			icon := "?"
-			switch status {
-			case service.StatusRunning:
-				icon = "+"
-			case service.StatusStopped:
-				icon = "."
-			default:
-				// For any unexpected status, we show "?" to indicate uncertainty.
-			}
-
-			parts = append(parts, fmt.Sprintf("%s: [%s] %s", family, icon, installed))
+		case health == service.HealthHealthy:
+			parts = append(parts, fmt.Sprintf("%s: [%s] %s %s", family, health.Icon(),
+				fs.GetInstalledVariant(), fs.Probe.LatencyText()))
+		case health == service.HealthUnhealthy:
+			parts = append(parts, fmt.Sprintf("%s: [%s] %s SIN RESPUESTA", family, health.Icon(),
+				fs.GetInstalledVariant()))
+		default:
+			parts = append(parts, fmt.Sprintf("%s: [%s] %s", family, health.Icon(), fs.GetInstalledVariant()))
 		}
 	}
 
 	return strings.Join(parts, " | ")
 }
+
+// renderQueryStats describes the last status round for the dashboard
+// footer, e.g. "Estado: 4 variantes en 182ms · 6 consultas, 2 en caché · 4 en paralelo"
+func (m Model) renderQueryStats() string {
+	st := m.queryStats
+	if st.Variants == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Estado: %d variantes en %s · %d consultas, %d en caché · %d en paralelo",
+		st.Variants, st.Elapsed.Round(time.Millisecond), st.Queries, st.CacheHits, st.Workers)
+}
+
+// renderAuditEntry formats one history line; failures add their error below
+func renderAuditEntry(e service.AuditEntry) string {
+	target := e.Variant
+	if target == "" {
+		target = e.Family
+	}
+	line := fmt.Sprintf("%s  %-14s %-24s %6s  %s@%s",
+		e.Time.Local().Format("2006-01-02 15:04:05"), target, e.ActionLabel(),
+		formatStepDuration(e.Duration()), e.User, e.Machine)
+
+	switch e.Outcome {
+	case service.AuditOK:
+		return successStyle.Render("[OK] ") + normalStyle.Render(line)
+	case service.AuditCancelled:
+		return warningStyle.Render("[~]  ") + normalStyle.Render(line)
+	default:
+		detail := e.Error
+		if e.Category != "" {
+			detail = fmt.Sprintf("(%s) %s", e.Category, detail)
+		}
+		return errorStyle.Render("[X]  ") + normalStyle.Render(line) + "\n" + disabledStyle.Render("     "+detail)
+	}
+}
+
+// historyFamilyLabel names the family filter of the history screen
+func historyFamilyLabel(family string) string {
+	if family == "" {
+		return "Todas"
+	}
+	return capitalize(family)
+}
+
+// historyOutcomeLabel names the outcome filter of the history screen
+func historyOutcomeLabel(outcome string) string {
+	switch outcome {
+	case service.AuditOK:
+		return "Éxito"
+	case service.AuditFailed:
+		return "Fallo"
+	case service.AuditCancelled:
+		return "Cancelada"
+	default:
+		return "Todos"
+	}
+}
+
+// remediationHint suggests the next step for a failed operation based on
+// its error category. Returns "" when there is nothing to suggest.
+func remediationHint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	switch service.CategoryOf(err) {
+	case service.CategoryNotInstalled:
+		return "El servicio ya no está registrado; regrese al menú para actualizar el estado."
+	case service.CategoryAlreadyRunning:
+		return "No se requiere acción: el servicio ya está activo."
+	case service.CategoryNotRunning:
+		return "No se requiere acción: el servicio ya estaba detenido."
+	case service.CategoryMarkedForDeletion:
+		return "Cierre services.msc y el Visor de eventos, o reinicie el equipo para completar la eliminación."
+	case service.CategoryAlreadyExists:
+		return "Desinstale la versión existente antes de volver a instalar."
+	case service.CategoryAccessDenied:
+		return "Ejecute el instalador como administrador."
+	case service.CategoryBusy:
+		return "El servicio está en transición; espere unos segundos e intente de nuevo."
+	case service.CategoryTimeout:
+		return "El servicio no respondió a tiempo; use 'Forzar Detención' o revise los logs."
+	case service.CategoryInvalid:
+		return "Revise la configuración de compilación (Taskfile / ldflags) del instalador."
+	case service.CategoryFilesystem:
+		return "Verifique permisos y espacio en disco del directorio de instalación."
+	case service.CategoryDependentsRunning:
+		return "Use 'Detener Servicio' o 'Desinstalar', que avisan y detienen también los servicios dependientes."
+	case service.CategoryLogonFailed:
+		return "Conceda a la cuenta el derecho 'Iniciar sesión como servicio' (secpol.msc → Directivas locales → Asignación de derechos de usuario) o verifique usuario y contraseña."
+	case service.CategoryDisabled:
+		return "Cambie el tipo de inicio desde 'Tipo de Inicio' antes de iniciar el servicio."
+	case service.CategoryCancelled:
+		return "La operación se canceló y se revirtieron sus cambios; revise el estado antes de reintentar."
+	case service.CategoryPortInUse:
+		return "Cierre el programa que ocupa el puerto del servicio, o use 'Instalar' / 'Iniciar Servicio' y elija continuar de todos modos."
+	default:
+		return "Revise los logs del servicio para más detalles."
+	}
+}
+
+// formatDependencyTree renders dependency nodes as an indented tree with
+// each service's status
+func formatDependencyTree(nodes []service.DependencyNode, indent string) string {
+	var b strings.Builder
+	for i, node := range nodes {
+		branch, next := "├─ ", "│  "
+		if i == len(nodes)-1 {
+			branch, next = "└─ ", "   "
+		}
+		_, _ = fmt.Fprintf(&b, "%s%s%s  %s\n", indent, branch, node.Name, node.Status.String())
+		if children := formatDependencyTree(node.Children, indent+next); children != "" {
+			b.WriteString(children + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// formatDependents renders dependent services on one line with their status
+func formatDependents(nodes []service.DependencyNode) string {
+	parts := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		parts = append(parts, fmt.Sprintf("%s (%s)", node.Name, node.Status.String()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatVerifyResult renders the installed-binary verification report
+func formatVerifyResult(res service.VerifyResult) string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "Verificación de instalación: %s\n\n", res.Outcome)
+	_, _ = fmt.Fprintf(&b, "Archivo:   %s\n", res.Path)
+	_, _ = fmt.Fprintf(&b, "Esperado:  %s (%d bytes)\n", res.Expected.SHA256, res.Expected.Size)
+	if res.Outcome != service.VerifyMissing {
+		_, _ = fmt.Fprintf(&b, "Instalado: %s (%d bytes)\n", res.ActualSHA256, res.ActualSize)
+	}
+	_, _ = fmt.Fprintf(&b, "Commit:    %s\n", res.Expected.Commit)
+	_, _ = fmt.Fprintf(&b, "Compilado: %s", res.Expected.BuildDate)
+	return b.String()
+}
+
+// formatLogMaintenance renders what a log maintenance run did
+func formatLogMaintenance(res service.LogMaintenance) string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "Comprimidos: %d\n", res.Compressed)
+	_, _ = fmt.Fprintf(&b, "Eliminados:  %d\n", res.Purged)
+	_, _ = fmt.Fprintf(&b, "Liberado:    %s\n\n", service.FormatSize(max(res.Freed(), 0)))
+	_, _ = fmt.Fprintf(&b, "Antes:   %s\n", res.Before.Summary())
+	_, _ = fmt.Fprintf(&b, "Después: %s", res.After.Summary())
+	return b.String()
+}
+
+// formatRecoveryPolicy renders a policy read back from the system, one line per field
+func formatRecoveryPolicy(p service.RecoveryPolicy) string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "Restablecer contador tras: %s\n", service.FormatDuration(p.ResetPeriod))
+	if len(p.Actions) == 0 {
+		b.WriteString("Sin acciones de recuperación\n")
+	}
+	for i, a := range p.Actions {
+		_, _ = fmt.Fprintf(&b, "%s: %s (espera %s)\n", recoveryOrdinal(i), a.Type.Label(), service.FormatDuration(a.Delay))
+	}
+	if p.Command != "" {
+		_, _ = fmt.Fprintf(&b, "Comando: %s\n", p.Command)
+	}
+	_, _ = fmt.Fprintf(&b, "Acciones en detenciones con errores: %s", yesNo(p.NonCrashFailures))
+	return b.String()
+}
+
+// recoveryOrdinal labels the i-th failure action as services.msc does
+func recoveryOrdinal(i int) string {
+	switch i {
+	case 0:
+		return "Primer fallo"
+	case 1:
+		return "Segundo fallo"
+	default:
+		return "Fallos siguientes"
+	}
+}
+
+// yesNo renders a boolean in Spanish
+func yesNo(v bool) string {
+	if v {
+		return "Sí"
+	}
+	return "No"
+}
+
+// formatStateDetails renders the parsed sc queryex details worth showing
+// for the current state: PID while running, progress while pending and the
+// last exit code when the service stopped with an error.
+func formatStateDetails(state service.ServiceState) string {
+	switch state.Status {
+	case service.StatusRunning:
+		if state.PID > 0 {
+			return fmt.Sprintf("PID: %d", state.PID)
+		}
+	case service.StatusStartPending, service.StatusStopPending:
+		return fmt.Sprintf("Punto de control: %d (espera estimada %s)", state.CheckPoint, state.WaitHint)
+	case service.StatusStopped:
+		// 1077 (ERROR_SERVICE_NEVER_STARTED) is the normal code for a fresh install
+		if state.Win32ExitCode == 0 || state.Win32ExitCode == 1077 {
+			return ""
+		}
+		// 1066 (ERROR_SERVICE_SPECIFIC_ERROR) means the daemon set its own code
+		if state.Win32ExitCode == 1066 {
+			return fmt.Sprintf("Último código de salida: %d (servicio: %d)", state.Win32ExitCode, state.ServiceExitCode)
+		}
+		return fmt.Sprintf("Último código de salida: %d", state.Win32ExitCode)
+	default:
+		// No additional details for other states
+	}
+	return ""
+}